@@ -6,6 +6,7 @@ import (
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/config"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/middleware"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/routes"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
@@ -18,10 +19,17 @@ func main() {
 	if err != nil {
 		log.Fatal("Config load failed:", err)
 	}
+	if err := services.CheckLinkSigningSecret(); err != nil {
+		log.Fatal("Config load failed:", err)
+	}
 
 	database.Connect(cfg.DSN())
 	database.EnsureSchema()
 
+	// Existing rows need these columns filled in once, when AutoMigrate first adds them
+	backfillAssignees := !database.DB.Migrator().HasColumn(&models.Contact{}, "AssignedTo")
+	backfillVersionAttachments := !database.DB.Migrator().HasColumn(&models.EmailTemplateVersion{}, "AttachmentIDs")
+
 	if err := database.DB.AutoMigrate(
 		// &models.SuperAdmin{},
 		&models.Organization{},
@@ -30,15 +38,65 @@ func main() {
 		&models.Audience{},
 		&models.AudienceContact{},
 		&models.EmailTemplate{},
+		&models.EmailTemplateVersion{},
+		&models.EmailTemplateAttachment{},
+		&models.EmailTemplateVariant{},
+		&models.TemplatePartial{},
+		&models.SystemTemplate{},
+		&models.SystemTemplateSeed{},
 		&models.Campaign{},
 		&models.CampaignLog{},
+		&models.CampaignVariant{},
+		&models.CampaignPreview{},
+		&models.Sequence{},
+		&models.SequenceStep{},
+		&models.SequenceEnrollment{},
+		&models.SequenceMessage{},
 		&models.Notification{},
 		&models.BackgroundJobLog{},
+		&models.PipelineStage{},
+		&models.ContactStageHistory{},
+		&models.LeadRoutingRule{},
+		&models.Activity{},
+		&models.AudienceMembershipLog{},
+		&models.Task{},
+		&models.OrganizationSettings{},
+		&models.Appointment{},
+		&models.Deal{},
+		&models.CommissionRule{},
+		&models.SenderIdentity{},
 	); err != nil {
 		log.Fatal("Database migration failed:", err)
 	}
 
-	app := fiber.New()
+	// Contacts created before agent assignment belong to the agent who created them
+	if backfillAssignees {
+		if err := database.DB.Exec(`UPDATE contact SET assigned_to = created_by
+			WHERE assigned_to IS NULL
+			AND created_by IN (SELECT id FROM "user" WHERE "user".organization_id = contact.organization_id AND "user".is_active)`).Error; err != nil {
+			log.Fatal("Contact assignee backfill failed:", err)
+		}
+	}
+	// Versions saved before attachments were versioned sent the template's current attachments
+	if backfillVersionAttachments {
+		if err := database.DB.Exec(`UPDATE email_template_version SET attachment_ids = COALESCE((
+				SELECT jsonb_agg(a.id ORDER BY a.created_at) FROM email_template_attachment a
+				WHERE a.template_id = email_template_version.template_id AND a.deleted_at IS NULL
+			), '[]'::jsonb)`).Error; err != nil {
+			log.Fatal("Template version attachment backfill failed:", err)
+		}
+	}
+
+	if err := services.NewSystemTemplateService().SeedSystemTemplates(); err != nil {
+		log.Println("System template seeding failed:", err)
+	}
+
+	// Bodies are held to Fiber's 4 MB default before they are read; template attachment
+	// uploads may send more once authenticated
+	app := fiber.New(middleware.BodyConfig)
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, middleware.LargeBodyRoute{
+		Method: fiber.MethodPost, Path: "/agent/templates/:id/attachments", Limit: middleware.UploadBodyLimit,
+	}))
 
 	// Configure CORS middleware
 	app.Use(cors.New(cors.Config{
@@ -52,6 +110,7 @@ func main() {
 	routes.RegisterSuperAdminRoutes(app)
 	routes.RegisterOrgAdminRoutes(app)
 	routes.RegisterAgentRoutes(app)
+	routes.RegisterPublicRoutes(app)
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
@@ -64,7 +123,7 @@ func main() {
 	app.Listen(":" + cfg.Server.Port)
 }
 
-// startBackgroundScheduler runs the campaign scheduler periodically
+// startBackgroundScheduler runs the campaign scheduler, task reminders and sequences periodically
 func startBackgroundScheduler() {
 	bgJobService := services.NewBackgroundJobService()
 	ticker := time.NewTicker(1 * time.Minute) // Run every  minutes
@@ -74,8 +133,12 @@ func startBackgroundScheduler() {
 
 	// Run immediately on startup
 	bgJobService.ProcessCampaignScheduler()
+	bgJobService.ProcessTaskReminders()
+	bgJobService.ProcessSequences()
 
 	for range ticker.C {
 		bgJobService.ProcessCampaignScheduler()
+		bgJobService.ProcessTaskReminders()
+		bgJobService.ProcessSequences()
 	}
 }
@@ -37,6 +37,13 @@ func EnsureSchema() {
 		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS token_expires_at timestamptz;`,
 		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS is_password_set boolean NOT NULL DEFAULT false;`,
 		`CREATE INDEX IF NOT EXISTS idx_user_invite_token ON "user"(invite_token);`,
+
+		// Notification types are validated in the application; the original
+		// CHECK constraint only knew the first five types.
+		`ALTER TABLE IF EXISTS notification DROP CONSTRAINT IF EXISTS notification_notification_type_check;`,
+
+		// Campaign statuses are validated in the application; A/B tests added "testing".
+		`ALTER TABLE IF EXISTS campaign DROP CONSTRAINT IF EXISTS campaign_status_check;`,
 	}
 
 	for _, s := range stmts {
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+var activityRepo = &repository.ActivityRepository{}
+
+// isValidActivityType checks an activity type value
+func isValidActivityType(activityType string) bool {
+	switch activityType {
+	case "call", "email", "meeting", "showing", "note":
+		return true
+	}
+	return false
+}
+
+// canEditActivity reports whether a user may change an activity: its author or an org admin
+func canEditActivity(role, userID string, activity *models.Activity) bool {
+	return role == "org_admin" || activity.AuthorID == userID
+}
+
+// CreateContactActivity logs a call, email, meeting, showing or note against a contact
+func CreateContactActivity(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	contactID := c.Params("id")
+
+	if _, err := contactRepo.FindByID(contactID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	var req struct {
+		Type       string     `json:"type"` // call | email | meeting | showing | note
+		Body       string     `json:"body"`
+		Outcome    string     `json:"outcome"`
+		OccurredAt *time.Time `json:"occurred_at"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if !isValidActivityType(req.Type) {
+		return c.Status(400).JSON(fiber.Map{"error": "type must be one of 'call', 'email', 'meeting', 'showing', 'note'"})
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Type == "note" && req.Body == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Body is required for notes"})
+	}
+
+	// Activities can be back-dated; default to now
+	occurredAt := time.Now()
+	if req.OccurredAt != nil {
+		occurredAt = *req.OccurredAt
+	}
+
+	activity := models.Activity{
+		OrganizationID: orgID,
+		ContactID:      contactID,
+		AuthorID:       userID,
+		Type:           req.Type,
+		Body:           req.Body,
+		Outcome:        req.Outcome,
+		OccurredAt:     occurredAt,
+	}
+
+	if err := activityRepo.Create(&activity); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create activity"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":  "Activity created successfully",
+		"activity": activity,
+	})
+}
+
+// GetContactActivities returns the paginated activities of a contact, newest first
+func GetContactActivities(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	contactID := c.Params("id")
+
+	if _, err := contactRepo.FindByID(contactID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	activityType := c.Query("type", "")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	activities, total, err := activityRepo.FindByContact(contactID, orgID, activityType, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch activities"})
+	}
+
+	return c.JSON(fiber.Map{
+		"activities": activities,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// UpdateContactActivity updates an activity
+func UpdateContactActivity(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	contactID := c.Params("id")
+	activityID := c.Params("activityId")
+
+	activity, err := activityRepo.FindByID(activityID, contactID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Activity not found"})
+	}
+
+	if !canEditActivity(role, userID, activity) {
+		return c.Status(403).JSON(fiber.Map{"error": "You can only edit your own activities"})
+	}
+
+	var req struct {
+		Type       *string    `json:"type"`
+		Body       *string    `json:"body"`
+		Outcome    *string    `json:"outcome"`
+		OccurredAt *time.Time `json:"occurred_at"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Type != nil {
+		if !isValidActivityType(*req.Type) {
+			return c.Status(400).JSON(fiber.Map{"error": "type must be one of 'call', 'email', 'meeting', 'showing', 'note'"})
+		}
+		activity.Type = *req.Type
+	}
+	if req.Body != nil {
+		activity.Body = strings.TrimSpace(*req.Body)
+	}
+	if req.Outcome != nil {
+		activity.Outcome = *req.Outcome
+	}
+	if req.OccurredAt != nil {
+		activity.OccurredAt = *req.OccurredAt
+	}
+
+	if activity.Type == "note" && activity.Body == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Body is required for notes"})
+	}
+
+	if err := activityRepo.Update(activity); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update activity"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Activity updated successfully",
+		"activity": activity,
+	})
+}
+
+// DeleteContactActivity deletes an activity
+func DeleteContactActivity(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	contactID := c.Params("id")
+	activityID := c.Params("activityId")
+
+	activity, err := activityRepo.FindByID(activityID, contactID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Activity not found"})
+	}
+
+	if !canEditActivity(role, userID, activity) {
+		return c.Status(403).JSON(fiber.Map{"error": "You can only delete your own activities"})
+	}
+
+	if err := activityRepo.Delete(activity.ID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete activity"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Activity deleted successfully"})
+}
+
+// GetContactTimeline returns activities, campaign sends, audience changes and stage changes
+// of a contact merged into one paginated feed, newest first
+func GetContactTimeline(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	contactID := c.Params("id")
+
+	if _, err := contactRepo.FindByID(contactID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, total, err := activityRepo.FindTimeline(contactID, orgID, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch timeline"})
+	}
+
+	return c.JSON(fiber.Map{
+		"events": events,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
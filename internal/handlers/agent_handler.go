@@ -215,14 +215,33 @@ func DeactivateAgent(c *fiber.Ctx) error {
 		return c.Status(403).JSON(fiber.Map{"error": "You cannot modify agents of another organization"})
 	}
 
-	agent.IsActive = false
+	// Contacts go to the colleague in reassign_to, or back to the unassigned pool
+	var req struct {
+		ReassignTo string `json:"reassign_to"`
+	}
+	_ = c.BodyParser(&req)
+	if reassignTo := c.Query("reassign_to"); reassignTo != "" {
+		req.ReassignTo = reassignTo
+	}
 
-	if err := userRepo.Update(agent); err != nil {
+	if req.ReassignTo != "" {
+		if err := contactService.ValidateAssignee(orgID.(string), req.ReassignTo); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if req.ReassignTo == agent.ID.String() {
+			return c.Status(400).JSON(fiber.Map{"error": "Cannot reassign contacts to the agent being deactivated"})
+		}
+	}
+
+	// The agent stays active unless their contacts were handed over too
+	reassigned, err := contactService.DeactivateAgent(orgID.(string), agent, req.ReassignTo)
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to deactivate agent"})
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Agent deactivated successfully",
+		"message":             "Agent deactivated successfully",
+		"reassigned_contacts": reassigned,
 	})
 }
 
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var (
+	appointmentRepo    = &repository.AppointmentRepository{}
+	appointmentService = services.NewAppointmentService()
+)
+
+// CreateAppointment books a showing or meeting for an agent with a contact
+func CreateAppointment(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+
+	var req struct {
+		AgentID          string    `json:"agent_id"`
+		ContactID        string    `json:"contact_id"`
+		PropertyAddress  string    `json:"property_address"`
+		Title            string    `json:"title"`
+		Notes            string    `json:"notes"`
+		Location         string    `json:"location"`
+		StartAt          time.Time `json:"start_at"`
+		EndAt            time.Time `json:"end_at"`
+		SendConfirmation *bool     `json:"send_confirmation"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.ContactID == "" || req.StartAt.IsZero() || req.EndAt.IsZero() {
+		return c.Status(400).JSON(fiber.Map{"error": "contact_id, start_at and end_at are required"})
+	}
+
+	contact, err := contactRepo.FindByID(req.ContactID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	// Agents book for themselves; admins may book for any active agent
+	if req.AgentID == "" || role != "org_admin" {
+		req.AgentID = userID
+	} else if req.AgentID != userID {
+		if err := contactService.ValidateAssignee(orgID, req.AgentID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	appointment := models.Appointment{
+		OrganizationID:  orgID,
+		AgentID:         req.AgentID,
+		ContactID:       contact.ID,
+		PropertyAddress: strings.TrimSpace(req.PropertyAddress),
+		Title:           strings.TrimSpace(req.Title),
+		Notes:           req.Notes,
+		Location:        strings.TrimSpace(req.Location),
+		StartAt:         req.StartAt,
+		EndAt:           req.EndAt,
+		Status:          "scheduled",
+		CreatedBy:       userID,
+	}
+
+	conflicts, err := appointmentService.Book(&appointment)
+	if errors.Is(err, services.ErrAppointmentConflict) {
+		return c.Status(409).JSON(fiber.Map{
+			"error":     err.Error(),
+			"conflicts": conflicts,
+		})
+	}
+	if errors.Is(err, services.ErrAppointmentTimes) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create appointment"})
+	}
+
+	// Confirmation emails are on by default; a failed send does not undo the booking
+	if (req.SendConfirmation == nil || *req.SendConfirmation) && contact.Email != "" {
+		if err := appointmentService.SendConfirmation(&appointment, contact); err != nil {
+			log.Printf("Failed to send appointment confirmation %s: %v", appointment.ID, err)
+		}
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":     "Appointment created successfully",
+		"appointment": appointment,
+	})
+}
+
+// GetAppointments returns paginated appointments in start order
+func GetAppointments(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := repository.AppointmentFilter{
+		AgentID:   c.Query("agent_id", ""),
+		ContactID: c.Query("contact_id", ""),
+		Status:    c.Query("status", ""),
+	}
+
+	// Agents only see their own calendar; admins may filter by agent
+	if role != "org_admin" || filter.AgentID == "me" {
+		filter.AgentID = userID
+	}
+
+	if from := c.Query("from", ""); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "from must be an RFC3339 timestamp"})
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to", ""); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "to must be an RFC3339 timestamp"})
+		}
+		filter.To = &t
+	}
+
+	appointments, total, err := appointmentRepo.FindAllByOrg(orgID, filter, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch appointments"})
+	}
+
+	return c.JSON(fiber.Map{
+		"appointments": appointments,
+		"total":        total,
+		"page":         page,
+		"limit":        limit,
+	})
+}
+
+// GetAppointmentByID returns a single appointment
+func GetAppointmentByID(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	appointmentID := c.Params("id")
+
+	appointment, err := appointmentRepo.FindByID(appointmentID, orgID)
+	if err != nil || (role != "org_admin" && appointment.AgentID != userID) {
+		return c.Status(404).JSON(fiber.Map{"error": "Appointment not found"})
+	}
+
+	return c.JSON(appointment)
+}
+
+// UpdateAppointment reschedules an appointment or changes its details and status
+func UpdateAppointment(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	appointmentID := c.Params("id")
+
+	appointment, err := appointmentRepo.FindByID(appointmentID, orgID)
+	if err != nil || (role != "org_admin" && appointment.AgentID != userID) {
+		return c.Status(404).JSON(fiber.Map{"error": "Appointment not found"})
+	}
+
+	var req struct {
+		AgentID         *string    `json:"agent_id"`
+		PropertyAddress *string    `json:"property_address"`
+		Title           *string    `json:"title"`
+		Notes           *string    `json:"notes"`
+		Location        *string    `json:"location"`
+		StartAt         *time.Time `json:"start_at"`
+		EndAt           *time.Time `json:"end_at"`
+		Status          *string    `json:"status"` // scheduled | confirmed | cancelled | no_show
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	needsConflictCheck := false
+
+	if req.AgentID != nil && *req.AgentID != appointment.AgentID {
+		if role != "org_admin" {
+			return c.Status(403).JSON(fiber.Map{"error": "Only admins can move appointments between agents"})
+		}
+		if err := contactService.ValidateAssignee(orgID, *req.AgentID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		appointment.AgentID = *req.AgentID
+		needsConflictCheck = true
+	}
+	if req.StartAt != nil {
+		appointment.StartAt = *req.StartAt
+		needsConflictCheck = true
+	}
+	if req.EndAt != nil {
+		appointment.EndAt = *req.EndAt
+		needsConflictCheck = true
+	}
+	if req.PropertyAddress != nil {
+		appointment.PropertyAddress = strings.TrimSpace(*req.PropertyAddress)
+	}
+	if req.Title != nil {
+		appointment.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Notes != nil {
+		appointment.Notes = *req.Notes
+	}
+	if req.Location != nil {
+		appointment.Location = strings.TrimSpace(*req.Location)
+	}
+	if req.Status != nil {
+		if !services.IsValidAppointmentStatus(*req.Status) {
+			return c.Status(400).JSON(fiber.Map{"error": "status must be 'scheduled', 'confirmed', 'cancelled', or 'no_show'"})
+		}
+		// Re-activating a cancelled appointment must still fit the calendar
+		if (*req.Status == "scheduled" || *req.Status == "confirmed") &&
+			(appointment.Status == "cancelled" || appointment.Status == "no_show") {
+			needsConflictCheck = true
+		}
+		appointment.Status = *req.Status
+	}
+
+	if needsConflictCheck && (appointment.Status == "scheduled" || appointment.Status == "confirmed") {
+		conflicts, err := appointmentService.Book(appointment)
+		if errors.Is(err, services.ErrAppointmentConflict) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":     err.Error(),
+				"conflicts": conflicts,
+			})
+		}
+		if errors.Is(err, services.ErrAppointmentTimes) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to update appointment"})
+		}
+	} else if err := appointmentRepo.Update(appointment); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update appointment"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":     "Appointment updated successfully",
+		"appointment": appointment,
+	})
+}
+
+// SendAppointmentConfirmation (re)sends the confirmation email to the contact
+func SendAppointmentConfirmation(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	appointmentID := c.Params("id")
+
+	appointment, err := appointmentRepo.FindByID(appointmentID, orgID)
+	if err != nil || (role != "org_admin" && appointment.AgentID != userID) {
+		return c.Status(404).JSON(fiber.Map{"error": "Appointment not found"})
+	}
+
+	if appointment.Status == "cancelled" || appointment.Status == "no_show" {
+		return c.Status(400).JSON(fiber.Map{"error": "Cannot confirm a " + appointment.Status + " appointment"})
+	}
+
+	contact, err := contactRepo.FindByID(appointment.ContactID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	if err := appointmentService.SendConfirmation(appointment, contact); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to send confirmation: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":     "Confirmation sent successfully",
+		"appointment": appointment,
+	})
+}
+
+// GetCalendarFeedURL returns the caller's private calendar subscription URL.
+// ?regenerate=true issues a new token and invalidates the old URL.
+func GetCalendarFeedURL(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	user, err := userRepo.FindByID(userUUID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	token, err := appointmentService.CalendarToken(user, c.QueryBool("regenerate"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create calendar token"})
+	}
+
+	return c.JSON(fiber.Map{
+		"url": services.BuildPublicURL("/public/calendar/" + token + ".ics"),
+	})
+}
+
+// GetCalendarFeed serves an agent's appointments as an iCalendar feed (public, token-authenticated)
+func GetCalendarFeed(c *fiber.Ctx) error {
+	token := strings.TrimSuffix(c.Params("token"), ".ics")
+	if token == "" {
+		return c.Status(404).SendString("Calendar not found")
+	}
+
+	user, err := userRepo.FindByCalendarToken(token)
+	if err != nil || !user.IsActive {
+		return c.Status(404).SendString("Calendar not found")
+	}
+
+	feed, err := appointmentService.BuildAgentFeed(user)
+	if err != nil {
+		return c.Status(500).SendString("Failed to build calendar")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="appointments.ics"`)
+	return c.SendString(feed)
+}
@@ -71,7 +71,7 @@ func CreateAudience(c *fiber.Ctx) error {
 	}
 
 	if len(ids) > 0 {
-		audienceRepo.AddContacts(audience.ID, ids)
+		audienceRepo.AddContacts(audience.ID, ids, &userID)
 	}
 
 	return c.Status(201).JSON(fiber.Map{
@@ -177,6 +177,7 @@ func DeleteAudience(c *fiber.Ctx) error {
 // AddContactsToAudience adds contacts to an audience
 func AddContactsToAudience(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
 	audienceID := c.Params("id")
 
 	// Verify audience exists
@@ -207,7 +208,7 @@ func AddContactsToAudience(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Some contacts not found or don't belong to your organization"})
 	}
 
-	if err := audienceRepo.AddContacts(audienceID, req.ContactIDs); err != nil {
+	if err := audienceRepo.AddContacts(audienceID, req.ContactIDs, &userID); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to add contacts to audience"})
 	}
 
@@ -220,6 +221,7 @@ func AddContactsToAudience(c *fiber.Ctx) error {
 // RemoveContactsFromAudience removes contacts from an audience
 func RemoveContactsFromAudience(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
 	audienceID := c.Params("id")
 
 	// Verify audience exists
@@ -240,7 +242,7 @@ func RemoveContactsFromAudience(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "No contact IDs provided"})
 	}
 
-	if err := audienceRepo.RemoveContacts(audienceID, req.ContactIDs); err != nil {
+	if err := audienceRepo.RemoveContacts(audienceID, req.ContactIDs, &userID); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to remove contacts from audience"})
 	}
 
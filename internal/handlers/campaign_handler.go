@@ -1,89 +1,171 @@
 package handlers
 
 import (
-	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"gorm.io/datatypes"
 )
 
 var (
-	campaignRepo    = &repository.CampaignRepository{}
-	campaignLogRepo = &repository.CampaignLogRepository{}
+	campaignRepo        = &repository.CampaignRepository{}
+	campaignLogRepo     = &repository.CampaignLogRepository{}
+	campaignVariantRepo = &repository.CampaignVariantRepository{}
+	campaignPreviewRepo = &repository.CampaignPreviewRepository{}
 )
 
-// CreateCampaign creates a new campaign
-func CreateCampaign(c *fiber.Ctx) error {
-	orgID := c.Locals("org_id").(string)
-	userID := c.Locals("user_id").(string)
+// campaignVariantRequest is one A/B variant in a campaign request
+type campaignVariantRequest struct {
+	TemplateID   string `json:"template_id"`
+	Subject      string `json:"subject"`
+	SplitPercent int    `json:"split_percent"`
+}
 
-	var req struct {
-		Name                 string                      `json:"name"`
-		TemplateID           string                      `json:"template_id"`
-		AudienceIDs          datatypes.JSONSlice[string] `json:"audience_ids"`
-		ContactID            *string                     `json:"contact_id"`
-		ScheduleType         string                      `json:"schedule_type"` // once | recurring
-		ScheduledAt          time.Time                   `json:"scheduled_at"`
-		Recurrence           *string                     `json:"recurrence"`              // daily | weekly | monthly
-		RecurrenceDayOfWeek  *int                        `json:"recurrence_day_of_week"`  // 0-6 (Sunday-Saturday)
-		RecurrenceDayOfMonth *int                        `json:"recurrence_day_of_month"` // 1-31
-		RecurrenceTime       *string                     `json:"recurrence_time"`         // HH:MM format
-	}
+// campaignRequest is the body of CreateCampaign and UpdateCampaign. An update starts from
+// the campaign's current settings, so only the fields sent change.
+type campaignRequest struct {
+	Name                 string                      `json:"name"`
+	TemplateID           string                      `json:"template_id"`
+	TemplateVersion      *int                        `json:"template_version"`
+	FollowLatestTemplate bool                        `json:"follow_latest_template"`
+	AudienceIDs          datatypes.JSONSlice[string] `json:"audience_ids"`
+	ContactID            *string                     `json:"contact_id"`
+	ExcludedAudienceIDs  datatypes.JSONSlice[string] `json:"excluded_audience_ids"`
+	ScheduleType         string                      `json:"schedule_type"` // once | recurring
+	ScheduledAt          time.Time                   `json:"scheduled_at"`
+	Recurrence           *string                     `json:"recurrence"`              // daily | weekly | monthly
+	RecurrenceDayOfWeek  *int                        `json:"recurrence_day_of_week"`  // 0-6 (Sunday-Saturday)
+	RecurrenceDayOfMonth *int                        `json:"recurrence_day_of_month"` // 1-31
+	RecurrenceTime       *string                     `json:"recurrence_time"`         // HH:MM format
+
+	// A/B testing
+	VariantMode       string                    `json:"variant_mode"` // split | test_winner
+	TestPercent       int                       `json:"test_percent"`
+	TestDurationHours int                       `json:"test_duration_hours"`
+	WinnerMetric      string                    `json:"winner_metric"` // open | click
+	Variants          *[]campaignVariantRequest `json:"variants"`      // replaces all variants when sent
+}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+// campaignRequestFrom returns a request holding a campaign's current settings
+func campaignRequestFrom(campaign *models.Campaign) campaignRequest {
+	req := campaignRequest{
+		Name:                 campaign.Name,
+		TemplateID:           campaign.TemplateID,
+		FollowLatestTemplate: campaign.FollowLatestTemplate,
+		AudienceIDs:          campaign.AudienceIDs,
+		ContactID:            campaign.ContactID,
+		ExcludedAudienceIDs:  campaign.ExcludedAudienceIDs,
+		ScheduleType:         campaign.ScheduleType,
+		ScheduledAt:          campaign.ScheduledAt,
+		Recurrence:           campaign.Recurrence,
+		RecurrenceDayOfWeek:  campaign.RecurrenceDayOfWeek,
+		RecurrenceDayOfMonth: campaign.RecurrenceDayOfMonth,
+		VariantMode:          campaign.VariantMode,
+		TestPercent:          campaign.TestPercent,
+		TestDurationHours:    campaign.TestDurationHours,
+		WinnerMetric:         campaign.WinnerMetric,
+	}
+	if campaign.RecurrenceTime != nil {
+		recurrenceTime := campaign.RecurrenceTime.Format("15:04")
+		req.RecurrenceTime = &recurrenceTime
+	}
+	return req
+}
+
+// campaignRequestError is a rejected campaign request with the status to respond with
+type campaignRequestError struct {
+	status  int
+	message string
+}
+
+func (e *campaignRequestError) Error() string {
+	return e.message
+}
+
+// applyCampaignRequest validates a campaign request and applies it to the campaign. It
+// returns the campaign's new A/B variants, or nil when an update leaves them unchanged.
+func applyCampaignRequest(orgID string, campaign *models.Campaign, req *campaignRequest) ([]models.CampaignVariant, error) {
+	// An A/B campaign's own template is its first variant's
+	if req.TemplateID == "" && req.Variants != nil && len(*req.Variants) > 0 {
+		req.TemplateID = (*req.Variants)[0].TemplateID
 	}
 
 	// Validate required fields
 	if req.Name == "" || req.TemplateID == "" || req.ScheduleType == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Name, template_id, and schedule_type are required"})
+		return nil, &campaignRequestError{400, "Name, template_id, and schedule_type are required"}
 	}
 
 	// Validate schedule type
 	if req.ScheduleType != "once" && req.ScheduleType != "recurring" {
-		return c.Status(400).JSON(fiber.Map{"error": "schedule_type must be 'once' or 'recurring'"})
+		return nil, &campaignRequestError{400, "schedule_type must be 'once' or 'recurring'"}
 	}
 
 	// Validate recipients (must have either audience_ids or contact_id, not both)
 	if (len(req.AudienceIDs) == 0 && req.ContactID == nil) || (len(req.AudienceIDs) > 0 && req.ContactID != nil) {
-		return c.Status(400).JSON(fiber.Map{"error": "Must provide either audience_ids or contact_id, not both"})
+		return nil, &campaignRequestError{400, "Must provide either audience_ids or contact_id, not both"}
 	}
 
 	// Verify template exists
-	_, err := templateRepo.FindByID(req.TemplateID, orgID)
+	template, err := templateRepo.FindByID(req.TemplateID, orgID)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+		return nil, &campaignRequestError{404, "Email template not found"}
+	}
+
+	// Pin the template version so later edits don't change what this campaign sends. An
+	// update keeps its pinned version unless the template changes.
+	var templateVersion *int
+	switch {
+	case req.FollowLatestTemplate:
+	case req.TemplateVersion != nil:
+		if _, err := templateVersionRepo.FindByVersion(template.ID, orgID, *req.TemplateVersion); err != nil {
+			return nil, &campaignRequestError{404, "Template version not found"}
+		}
+		templateVersion = req.TemplateVersion
+	case campaign.TemplateVersion != nil && campaign.TemplateID == template.ID:
+		templateVersion = campaign.TemplateVersion
+	default:
+		version, err := templateService.EnsureVersioned(template)
+		if err != nil {
+			return nil, &campaignRequestError{500, "Failed to pin template version"}
+		}
+		templateVersion = &version
 	}
 
 	// Verify audiences exist if provided
-	if len(req.AudienceIDs) > 0 {
-		for _, audienceID := range req.AudienceIDs {
-			_, err := audienceRepo.FindByID(audienceID, orgID)
-			if err != nil {
-				return c.Status(404).JSON(fiber.Map{"error": "Audience not found: " + audienceID})
-			}
+	for _, audienceID := range req.AudienceIDs {
+		if _, err := audienceRepo.FindByID(audienceID, orgID); err != nil {
+			return nil, &campaignRequestError{404, "Audience not found: " + audienceID}
+		}
+	}
+	if req.ExcludedAudienceIDs == nil {
+		req.ExcludedAudienceIDs = datatypes.JSONSlice[string]{}
+	}
+	for _, audienceID := range req.ExcludedAudienceIDs {
+		if _, err := audienceRepo.FindByID(audienceID, orgID); err != nil {
+			return nil, &campaignRequestError{404, "Excluded audience not found: " + audienceID}
 		}
 	}
 
 	// Verify contact exists if provided
 	if req.ContactID != nil {
-		_, err := contactRepo.FindByID(*req.ContactID, orgID)
-		if err != nil {
-			return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+		if _, err := contactRepo.FindByID(*req.ContactID, orgID); err != nil {
+			return nil, &campaignRequestError{404, "Contact not found"}
 		}
 	}
 
 	// Validate recurring settings
 	if req.ScheduleType == "recurring" {
 		if req.Recurrence == nil {
-			return c.Status(400).JSON(fiber.Map{"error": "recurrence is required for recurring campaigns"})
+			return nil, &campaignRequestError{400, "recurrence is required for recurring campaigns"}
 		}
 		if *req.Recurrence != "daily" && *req.Recurrence != "weekly" && *req.Recurrence != "monthly" {
-			return c.Status(400).JSON(fiber.Map{"error": "recurrence must be 'daily', 'weekly', or 'monthly'"})
+			return nil, &campaignRequestError{400, "recurrence must be 'daily', 'weekly', or 'monthly'"}
 		}
 	}
 
@@ -92,28 +174,101 @@ func CreateCampaign(c *fiber.Ctx) error {
 	if req.RecurrenceTime != nil {
 		parsedTime, err := time.Parse("15:04", *req.RecurrenceTime)
 		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid recurrence_time format. Use HH:MM"})
+			return nil, &campaignRequestError{400, "Invalid recurrence_time format. Use HH:MM"}
 		}
 		recurrenceTime = &parsedTime
 	}
 
+	// Variants pin their templates the same way the campaign does
+	var variants []models.CampaignVariant
+	if req.Variants != nil {
+		variants = make([]models.CampaignVariant, 0, len(*req.Variants))
+		for _, v := range *req.Variants {
+			variantTemplate, err := templateRepo.FindByID(v.TemplateID, orgID)
+			if err != nil {
+				return nil, &campaignRequestError{404, "Email template not found: " + v.TemplateID}
+			}
+			if err := services.ValidateTemplate(v.Subject); err != nil {
+				return nil, &campaignRequestError{400, "Invalid variant subject: " + err.Error()}
+			}
+			variant := models.CampaignVariant{
+				OrganizationID: orgID,
+				TemplateID:     v.TemplateID,
+				Subject:        v.Subject,
+				SplitPercent:   v.SplitPercent,
+			}
+			if !req.FollowLatestTemplate {
+				version, err := templateService.EnsureVersioned(variantTemplate)
+				if err != nil {
+					return nil, &campaignRequestError{500, "Failed to pin template version"}
+				}
+				variant.TemplateVersion = &version
+			}
+			variants = append(variants, variant)
+		}
+	}
+
+	campaign.Name = req.Name
+	campaign.TemplateID = req.TemplateID
+	campaign.TemplateVersion = templateVersion
+	campaign.FollowLatestTemplate = req.FollowLatestTemplate
+	campaign.AudienceIDs = req.AudienceIDs
+	campaign.ContactID = req.ContactID
+	campaign.ExcludedAudienceIDs = req.ExcludedAudienceIDs
+	campaign.ScheduleType = req.ScheduleType
+	campaign.ScheduledAt = req.ScheduledAt
+	campaign.Recurrence = req.Recurrence
+	campaign.RecurrenceDayOfWeek = req.RecurrenceDayOfWeek
+	campaign.RecurrenceDayOfMonth = req.RecurrenceDayOfMonth
+	campaign.RecurrenceTime = recurrenceTime
+	campaign.VariantMode = req.VariantMode
+	campaign.TestPercent = req.TestPercent
+	campaign.TestDurationHours = req.TestDurationHours
+	campaign.WinnerMetric = req.WinnerMetric
+
+	// Variants an update leaves alone are still checked against the new settings
+	checked := variants
+	if req.Variants == nil && campaign.ID != "" {
+		checked, err = campaignVariantRepo.FindByCampaign(campaign.ID)
+		if err != nil {
+			return nil, &campaignRequestError{500, "Failed to fetch campaign variants"}
+		}
+	}
+	if err := services.ValidateCampaignVariants(campaign, checked); err != nil {
+		return nil, &campaignRequestError{400, err.Error()}
+	}
+
+	return variants, nil
+}
+
+// campaignErrorResponse responds with a campaignRequestError's status and message
+func campaignErrorResponse(c *fiber.Ctx, err error) error {
+	if reqErr, ok := err.(*campaignRequestError); ok {
+		return c.Status(reqErr.status).JSON(fiber.Map{"error": reqErr.message})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
+
+// CreateCampaign creates a new draft campaign. Nothing is sent until it is scheduled.
+func CreateCampaign(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	var req campaignRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
 	campaign := models.Campaign{
-		OrganizationID:       orgID,
-		Name:                 req.Name,
-		TemplateID:           req.TemplateID,
-		AudienceIDs:          req.AudienceIDs,
-		ContactID:            req.ContactID,
-		ScheduleType:         req.ScheduleType,
-		ScheduledAt:          req.ScheduledAt,
-		Recurrence:           req.Recurrence,
-		RecurrenceDayOfWeek:  req.RecurrenceDayOfWeek,
-		RecurrenceDayOfMonth: req.RecurrenceDayOfMonth,
-		RecurrenceTime:       recurrenceTime,
-		Status:               "scheduled",
-		CreatedBy:            userID,
+		OrganizationID: orgID,
+		Status:         "draft",
+		CreatedBy:      userID,
+	}
+	variants, err := applyCampaignRequest(orgID, &campaign, &req)
+	if err != nil {
+		return campaignErrorResponse(c, err)
 	}
 
-	fmt.Printf("DEBUG: Creating campaign with AudienceIDs: %+v\n", campaign.AudienceIDs)
 	if err := campaignRepo.Create(&campaign); err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error":   "Failed to create campaign",
@@ -121,9 +276,21 @@ func CreateCampaign(c *fiber.Ctx) error {
 		})
 	}
 
+	for i := range variants {
+		variants[i].CampaignID = campaign.ID
+	}
+	if err := campaignVariantRepo.CreateAll(variants); err != nil {
+		campaignRepo.Delete(campaign.ID, orgID)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create campaign variants"})
+	}
+	if variants == nil {
+		variants = []models.CampaignVariant{}
+	}
+
 	return c.Status(201).JSON(fiber.Map{
 		"message":  "Campaign created successfully",
 		"campaign": campaign,
+		"variants": variants,
 	})
 }
 
@@ -168,7 +335,8 @@ func GetCampaignByID(c *fiber.Ctx) error {
 	return c.JSON(campaign)
 }
 
-// UpdateCampaign updates a campaign
+// UpdateCampaign updates any setting of a campaign that has not started sending, with the
+// same validation as CreateCampaign
 func UpdateCampaign(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
 	campaignID := c.Params("id")
@@ -178,34 +346,71 @@ func UpdateCampaign(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "Campaign not found"})
 	}
 
-	// Only allow updates to draft or scheduled campaigns
-	if campaign.Status != "draft" && campaign.Status != "scheduled" {
+	// Only allow updates to campaigns that have not started sending
+	switch campaign.Status {
+	case "draft", "scheduled", "pending_approval", "rejected":
+	default:
 		return c.Status(400).JSON(fiber.Map{"error": "Cannot update a campaign that is running, paused, or completed"})
 	}
-
-	var req struct {
-		Name        *string    `json:"name"`
-		ScheduledAt *time.Time `json:"scheduled_at"`
+	// A recurring campaign stays scheduled between runs, but its past runs were sent from
+	// what it holds now
+	if campaign.LastRunAt != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Cannot update a campaign that has already run; clone it instead"})
 	}
 
+	req := campaignRequestFrom(campaign)
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	if req.Name != nil {
-		campaign.Name = *req.Name
+	variants, err := applyCampaignRequest(orgID, campaign, &req)
+	if err != nil {
+		return campaignErrorResponse(c, err)
 	}
-	if req.ScheduledAt != nil {
-		campaign.ScheduledAt = *req.ScheduledAt
+
+	// A rejected campaign goes back to draft to be scheduled again, and changes to a
+	// scheduled campaign are reviewed again when the organization requires approval
+	resubmitted := false
+	switch campaign.Status {
+	case "rejected":
+		campaign.Status = "draft"
+	case "scheduled":
+		needsApproval, err := campaignNeedsApproval(c, orgID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+		}
+		if needsApproval {
+			campaign.Status = "pending_approval"
+			resubmitted = true
+		}
 	}
 
 	if err := campaignRepo.Update(campaign); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to update campaign"})
 	}
 
+	if req.Variants != nil {
+		for i := range variants {
+			variants[i].CampaignID = campaign.ID
+		}
+		if err := campaignVariantRepo.DeleteByCampaign(campaign.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to update campaign variants"})
+		}
+		if err := campaignVariantRepo.CreateAll(variants); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to update campaign variants"})
+		}
+	} else {
+		variants, _ = campaignVariantRepo.FindByCampaign(campaign.ID)
+	}
+
+	if resubmitted {
+		requestCampaignApproval(campaign)
+	}
+
 	return c.JSON(fiber.Map{
 		"message":  "Campaign updated successfully",
 		"campaign": campaign,
+		"variants": variants,
 	})
 }
 
@@ -224,6 +429,13 @@ func DeleteCampaign(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Cannot delete a running campaign. Pause it first."})
 	}
 
+	if err := campaignVariantRepo.DeleteByCampaign(campaignID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete campaign variants"})
+	}
+	if err := campaignPreviewRepo.DeleteByCampaign(campaignID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete campaign previews"})
+	}
+
 	if err := campaignRepo.Delete(campaignID, orgID); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete campaign"})
 	}
@@ -273,13 +485,206 @@ func ResumeCampaign(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Campaign resumed successfully"})
 }
 
+// ScheduleCampaign schedules a draft campaign, or submits it for approval when the
+// organization requires an org_admin to approve it first
+func ScheduleCampaign(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	campaignID := c.Params("id")
+
+	campaign, err := campaignRepo.FindByID(campaignID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Campaign not found"})
+	}
+
+	if campaign.Status != "draft" {
+		return c.Status(400).JSON(fiber.Map{"error": "Can only schedule draft campaigns"})
+	}
+
+	var req struct {
+		ScheduledAt *time.Time `json:"scheduled_at"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	if req.ScheduledAt != nil {
+		campaign.ScheduledAt = *req.ScheduledAt
+	}
+	if campaign.ScheduleType == "once" && campaign.ScheduledAt.IsZero() {
+		return c.Status(400).JSON(fiber.Map{"error": "scheduled_at is required for one-time campaigns"})
+	}
+
+	needsApproval, err := campaignNeedsApproval(c, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+	}
+
+	message := "Campaign scheduled successfully"
+	campaign.Status = "scheduled"
+	if needsApproval {
+		message = "Campaign submitted for approval"
+		campaign.Status = "pending_approval"
+	}
+
+	if err := campaignRepo.Update(campaign); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to schedule campaign"})
+	}
+
+	if needsApproval {
+		requestCampaignApproval(campaign)
+	}
+
+	return c.JSON(fiber.Map{"message": message, "campaign": campaign})
+}
+
+// SendCampaignNow sends a one-time draft or scheduled campaign right away. When approval is
+// required it is submitted instead, and sent as soon as it is approved.
+func SendCampaignNow(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	campaignID := c.Params("id")
+
+	campaign, err := campaignRepo.FindByID(campaignID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Campaign not found"})
+	}
+
+	if campaign.Status != "draft" && campaign.Status != "scheduled" {
+		return c.Status(400).JSON(fiber.Map{"error": "Can only send draft or scheduled campaigns"})
+	}
+	if campaign.ScheduleType != "once" {
+		return c.Status(400).JSON(fiber.Map{"error": "Only one-time campaigns can be sent now"})
+	}
+
+	needsApproval, err := campaignNeedsApproval(c, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+	}
+
+	campaign.ScheduledAt = time.Now()
+	if needsApproval {
+		campaign.Status = "pending_approval"
+		if err := campaignRepo.Update(campaign); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to send campaign"})
+		}
+		requestCampaignApproval(campaign)
+		return c.JSON(fiber.Map{"message": "Campaign submitted for approval", "campaign": campaign})
+	}
+
+	// Claim the campaign so a second request or the scheduler doesn't queue it as well
+	claimed, err := campaignRepo.ClaimForRun(campaign.ID, "draft", "scheduled")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to send campaign"})
+	}
+	if !claimed {
+		return c.Status(409).JSON(fiber.Map{"error": "Campaign is already being sent"})
+	}
+	campaign.Status = "running"
+	if err := campaignRepo.Update(campaign); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to send campaign"})
+	}
+
+	if err := bgJobService.QueueCampaignRun(campaign); err != nil {
+		// Due now, so the scheduler sends it on its next run
+		campaignRepo.UpdateStatus(campaign.ID, "scheduled")
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to queue campaign"})
+	}
+
+	return c.Status(202).JSON(fiber.Map{"message": "Campaign is being sent", "campaign": campaign})
+}
+
+// CloneCampaign copies a campaign's settings and A/B variants into a new draft
+func CloneCampaign(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	campaignID := c.Params("id")
+
+	source, err := campaignRepo.FindByID(campaignID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Campaign not found"})
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Copy of " + source.Name
+	}
+
+	campaign := models.Campaign{
+		OrganizationID:       orgID,
+		Name:                 name,
+		TemplateID:           source.TemplateID,
+		TemplateVersion:      source.TemplateVersion,
+		FollowLatestTemplate: source.FollowLatestTemplate,
+		AudienceIDs:          append(datatypes.JSONSlice[string]{}, source.AudienceIDs...),
+		ContactID:            source.ContactID,
+		ExcludedAudienceIDs:  append(datatypes.JSONSlice[string]{}, source.ExcludedAudienceIDs...),
+		ScheduleType:         source.ScheduleType,
+		ScheduledAt:          source.ScheduledAt,
+		Recurrence:           source.Recurrence,
+		RecurrenceDayOfWeek:  source.RecurrenceDayOfWeek,
+		RecurrenceDayOfMonth: source.RecurrenceDayOfMonth,
+		RecurrenceTime:       source.RecurrenceTime,
+		VariantMode:          source.VariantMode,
+		TestPercent:          source.TestPercent,
+		TestDurationHours:    source.TestDurationHours,
+		WinnerMetric:         source.WinnerMetric,
+		Status:               "draft",
+		CreatedBy:            userID,
+	}
+
+	sourceVariants, err := campaignVariantRepo.FindByCampaign(source.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch campaign variants"})
+	}
+
+	if err := campaignRepo.Create(&campaign); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to clone campaign"})
+	}
+
+	// Variants keep their pinned template versions
+	variants := make([]models.CampaignVariant, 0, len(sourceVariants))
+	for _, v := range sourceVariants {
+		variants = append(variants, models.CampaignVariant{
+			CampaignID:      campaign.ID,
+			OrganizationID:  orgID,
+			Label:           v.Label,
+			TemplateID:      v.TemplateID,
+			TemplateVersion: v.TemplateVersion,
+			Subject:         v.Subject,
+			SplitPercent:    v.SplitPercent,
+		})
+	}
+	if err := campaignVariantRepo.CreateAll(variants); err != nil {
+		campaignRepo.Delete(campaign.ID, orgID)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to clone campaign variants"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":  "Campaign cloned successfully",
+		"campaign": campaign,
+		"variants": variants,
+	})
+}
+
 // GetCampaignLogs returns paginated logs for a campaign
 func GetCampaignLogs(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
 	campaignID := c.Params("id")
 
 	// Verify campaign exists and belongs to org
-	_, err := campaignRepo.FindByID(campaignID, orgID)
+	campaign, err := campaignRepo.FindByID(campaignID, orgID)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Campaign not found"})
 	}
@@ -301,12 +706,208 @@ func GetCampaignLogs(c *fiber.Ctx) error {
 
 	// Get statistics
 	stats, _ := campaignLogRepo.GetStatsByCampaign(campaignID)
+	localeStats, _ := campaignLogRepo.GetLocaleStatsByCampaign(campaignID)
+
+	// A/B results per variant, in label order
+	variantStats := []fiber.Map{}
+	if campaign.VariantMode != "" {
+		variants, _ := campaignVariantRepo.FindByCampaign(campaignID)
+		counts, _ := campaignLogRepo.GetVariantStatsByCampaign(campaignID)
+		for _, variant := range variants {
+			result := counts[variant.ID]
+			variantStats = append(variantStats, fiber.Map{
+				"variant_id":    variant.ID,
+				"label":         variant.Label,
+				"template_id":   variant.TemplateID,
+				"subject":       variant.Subject,
+				"split_percent": variant.SplitPercent,
+				"is_winner":     variant.IsWinner,
+				"sent":          result.Sent,
+				"failed":        result.Failed,
+				"opened":        result.Opened,
+				"clicked":       result.Clicked,
+				"open_rate":     services.VariantRate(result, "open"),
+				"click_rate":    services.VariantRate(result, "click"),
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"logs":          logs,
+		"total":         total,
+		"page":          page,
+		"limit":         limit,
+		"stats":         stats,
+		"locale_stats":  localeStats,
+		"variant_stats": variantStats,
+	})
+}
+
+// GetCampaignRecipients returns a paginated list of who the campaign would email if it ran
+// now, with counts of the contacts it would skip
+func GetCampaignRecipients(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	campaignID := c.Params("id")
+
+	campaign, err := campaignRepo.FindByID(campaignID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Campaign not found"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	counts, recipients, err := bgJobService.PreviewCampaignRecipients(campaign)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load campaign recipients"})
+	}
+
+	offset := min((page-1)*limit, len(recipients))
+	end := min(offset+limit, len(recipients))
+
+	return c.JSON(fiber.Map{
+		"recipients": recipients[offset:end],
+		"total":      counts.Recipients,
+		"page":       page,
+		"limit":      limit,
+		"counts": fiber.Map{
+			"total":            counts.Total,
+			"no_email":         counts.NoEmail,
+			"suppressed":       counts.Suppressed,
+			"frequency_capped": counts.FrequencyCapped,
+			"excluded":         counts.Excluded,
+			"duplicate":        counts.Duplicate,
+			"recipients":       counts.Recipients,
+		},
+	})
+}
+
+// DryRunCampaign starts a dry run that renders every message the campaign would send now
+// without sending it. The results are written to a preview report.
+func DryRunCampaign(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	campaignID := c.Params("id")
+
+	campaign, err := campaignRepo.FindByID(campaignID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Campaign not found"})
+	}
+
+	preview, err := bgJobService.QueueCampaignDryRun(campaign, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to start dry run"})
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"message": "Dry run started",
+		"preview": preview,
+	})
+}
+
+// GetCampaignPreview returns the report of a campaign dry run
+func GetCampaignPreview(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	campaignID := c.Params("id")
+	previewID := c.Params("previewId")
+
+	preview, err := campaignPreviewRepo.FindByID(previewID, campaignID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Campaign preview not found"})
+	}
+
+	return c.JSON(fiber.Map{"preview": preview})
+}
+
+// ApproveCampaign approves a campaign waiting for review and schedules it
+func ApproveCampaign(c *fiber.Ctx) error {
+	return reviewCampaign(c, "approved")
+}
+
+// RejectCampaign rejects a campaign waiting for review. A comment telling the creator what
+// to change is required.
+func RejectCampaign(c *fiber.Ctx) error {
+	return reviewCampaign(c, "rejected")
+}
+
+func reviewCampaign(c *fiber.Ctx, decision string) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	campaignID := c.Params("id")
+
+	campaign, err := campaignRepo.FindByID(campaignID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Campaign not found"})
+	}
+
+	if campaign.Status != "pending_approval" {
+		return c.Status(400).JSON(fiber.Map{"error": "Campaign is not awaiting approval"})
+	}
+
+	var req struct {
+		Comment string `json:"comment"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	comment := strings.TrimSpace(req.Comment)
+	if decision == "rejected" && comment == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "comment is required when rejecting a campaign"})
+	}
+
+	now := time.Now()
+	campaign.ReviewDecision = decision
+	campaign.ReviewComment = comment
+	campaign.ReviewedBy = &userID
+	campaign.ReviewedAt = &now
+	campaign.Status = "rejected"
+	if decision == "approved" {
+		campaign.Status = "scheduled"
+	}
+
+	if err := campaignRepo.Update(campaign); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to review campaign"})
+	}
+
+	notifService.NotifyCampaignReviewed(campaign)
 
 	return c.JSON(fiber.Map{
-		"logs":  logs,
-		"total": total,
-		"page":  page,
-		"limit": limit,
-		"stats": stats,
+		"message":  "Campaign " + decision,
+		"campaign": campaign,
 	})
 }
+
+// campaignNeedsApproval reports whether campaigns from the current user must be approved by
+// an org_admin before they are scheduled
+func campaignNeedsApproval(c *fiber.Ctx, orgID string) (bool, error) {
+	if c.Locals("role") != "org_user" {
+		return false, nil
+	}
+	settings, err := settingsService.GetSettings(orgID)
+	if err != nil {
+		return false, err
+	}
+	return settings.RequireCampaignApproval, nil
+}
+
+// requestCampaignApproval notifies the org admins that a campaign is waiting for review
+func requestCampaignApproval(campaign *models.Campaign) {
+	submitter := "An agent"
+	if creatorID, err := uuid.Parse(campaign.CreatedBy); err == nil {
+		if creator, err := userRepo.FindByID(creatorID); err == nil {
+			submitter = creator.Name
+		}
+	}
+	notifService.NotifyCampaignApprovalRequested(campaign, submitter)
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+)
+
+var commissionRuleRepo = &repository.CommissionRuleRepository{}
+
+// CreateCommissionRule creates a commission rule
+func CreateCommissionRule(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Name               string                  `json:"name"`
+		Type               string                  `json:"type"` // percentage | tiered
+		Rate               float64                 `json:"rate"`
+		Tiers              []models.CommissionTier `json:"tiers"`
+		ListingSidePercent *float64                `json:"listing_side_percent"`
+		AgentSharePercent  *float64                `json:"agent_share_percent"`
+		IsDefault          bool                    `json:"is_default"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Name is required"})
+	}
+
+	rule := models.CommissionRule{
+		OrganizationID:     orgID,
+		Name:               req.Name,
+		Type:               req.Type,
+		Rate:               req.Rate,
+		Tiers:              datatypes.JSONSlice[models.CommissionTier](req.Tiers),
+		ListingSidePercent: 50,
+		AgentSharePercent:  70,
+		IsDefault:          req.IsDefault,
+		CreatedBy:          userID,
+	}
+	if rule.Tiers == nil {
+		rule.Tiers = datatypes.JSONSlice[models.CommissionTier]{}
+	}
+	if req.ListingSidePercent != nil {
+		rule.ListingSidePercent = *req.ListingSidePercent
+	}
+	if req.AgentSharePercent != nil {
+		rule.AgentSharePercent = *req.AgentSharePercent
+	}
+
+	// The first rule becomes the default so deals can be paid out straight away
+	if existing, err := commissionRuleRepo.FindAllByOrg(orgID); err == nil && len(existing) == 0 {
+		rule.IsDefault = true
+	}
+
+	if err := services.ValidateCommissionRule(&rule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := commissionRuleRepo.Create(&rule); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create commission rule"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Commission rule created successfully",
+		"rule":    rule,
+	})
+}
+
+// GetCommissionRules returns the organization's commission rules
+func GetCommissionRules(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	rules, err := commissionRuleRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch commission rules"})
+	}
+
+	return c.JSON(rules)
+}
+
+// UpdateCommissionRule updates a commission rule
+func UpdateCommissionRule(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	ruleID := c.Params("id")
+
+	rule, err := commissionRuleRepo.FindByID(ruleID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Commission rule not found"})
+	}
+
+	var req struct {
+		Name               *string                  `json:"name"`
+		Type               *string                  `json:"type"`
+		Rate               *float64                 `json:"rate"`
+		Tiers              *[]models.CommissionTier `json:"tiers"`
+		ListingSidePercent *float64                 `json:"listing_side_percent"`
+		AgentSharePercent  *float64                 `json:"agent_share_percent"`
+		IsDefault          *bool                    `json:"is_default"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Name cannot be empty"})
+		}
+		rule.Name = name
+	}
+	if req.Type != nil {
+		rule.Type = *req.Type
+	}
+	if req.Rate != nil {
+		rule.Rate = *req.Rate
+	}
+	if req.Tiers != nil {
+		rule.Tiers = datatypes.JSONSlice[models.CommissionTier](*req.Tiers)
+	}
+	if req.ListingSidePercent != nil {
+		rule.ListingSidePercent = *req.ListingSidePercent
+	}
+	if req.AgentSharePercent != nil {
+		rule.AgentSharePercent = *req.AgentSharePercent
+	}
+	if req.IsDefault != nil {
+		// Unsetting the default would leave unpinned deals without a rule; pick another default instead
+		if !*req.IsDefault && rule.IsDefault {
+			return c.Status(400).JSON(fiber.Map{"error": "Make another rule the default instead"})
+		}
+		rule.IsDefault = *req.IsDefault
+	}
+
+	if err := services.ValidateCommissionRule(rule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := commissionRuleRepo.Update(rule); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update commission rule"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Commission rule updated successfully",
+		"rule":    rule,
+	})
+}
+
+// DeleteCommissionRule deletes a rule that is neither the default nor pinned by a deal
+func DeleteCommissionRule(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	ruleID := c.Params("id")
+
+	rule, err := commissionRuleRepo.FindByID(ruleID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Commission rule not found"})
+	}
+
+	if rule.IsDefault {
+		return c.Status(400).JSON(fiber.Map{"error": "Cannot delete the default commission rule"})
+	}
+
+	count, err := commissionRuleRepo.CountDeals(rule.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check deals"})
+	}
+	if count > 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Cannot delete a commission rule that deals still use"})
+	}
+
+	if err := commissionRuleRepo.Delete(rule.ID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete commission rule"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Commission rule deleted successfully"})
+}
+
+// GetCommissionPayouts returns commission per agent for deals won in a period.
+// ?from and ?to are dates (YYYY-MM-DD, both inclusive) and default to the current month.
+func GetCommissionPayouts(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+
+	if fromStr := c.Query("from", ""); fromStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", fromStr, now.Location())
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "from must be a date (YYYY-MM-DD)"})
+		}
+		from = t
+	}
+	if toStr := c.Query("to", ""); toStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", toStr, now.Location())
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "to must be a date (YYYY-MM-DD)"})
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+
+	if !to.After(from) {
+		return c.Status(400).JSON(fiber.Map{"error": "to must not be before from"})
+	}
+
+	report, err := commissionService.PayoutsForPeriod(orgID, from, to)
+	if errors.Is(err, services.ErrNoCommissionRule) {
+		return c.Status(400).JSON(fiber.Map{"error": "Configure a default commission rule first"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to compute payouts"})
+	}
+
+	return c.JSON(report)
+}
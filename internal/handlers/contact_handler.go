@@ -8,6 +8,7 @@ import (
 	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 var (
@@ -35,16 +36,29 @@ func CreateContact(c *fiber.Ctx) error {
 		Bathrooms         int     `json:"bathrooms"`
 		SquareFeet        int     `json:"square_feet"`
 		PreferredLocation string  `json:"preferred_location"`
+		PreferredLanguage string  `json:"preferred_language"`
+		Timezone          string  `json:"timezone"`
 		Notes             string  `json:"notes"`
+		AssignedTo        *string `json:"assigned_to"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
+	// Contacts entered by hand belong to their creator unless another agent is chosen
+	assignedTo := userID
+	if req.AssignedTo != nil && *req.AssignedTo != "" && *req.AssignedTo != userID {
+		if err := contactService.ValidateAssignee(orgID, *req.AssignedTo); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		assignedTo = *req.AssignedTo
+	}
+
 	contact := models.Contact{
 		OrganizationID:    orgID,
 		CreatedBy:         userID,
+		AssignedTo:        &assignedTo,
 		FirstName:         req.FirstName,
 		LastName:          req.LastName,
 		Email:             req.Email,
@@ -56,6 +70,8 @@ func CreateContact(c *fiber.Ctx) error {
 		Bathrooms:         req.Bathrooms,
 		SquareFeet:        req.SquareFeet,
 		PreferredLocation: req.PreferredLocation,
+		PreferredLanguage: req.PreferredLanguage,
+		Timezone:          req.Timezone,
 		Notes:             req.Notes,
 	}
 
@@ -69,14 +85,25 @@ func CreateContact(c *fiber.Ctx) error {
 	})
 }
 
-// GetContacts returns paginated contacts with optional search
+// GetContacts returns paginated contacts with optional search and assignee filter
 func GetContacts(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 	search := c.Query("search", "")
 
+	// assigned_to accepts "me", "unassigned" or an agent ID; mine=true is shorthand for "me"
+	assignedTo := c.Query("assigned_to", "")
+	if c.QueryBool("mine") || assignedTo == "me" {
+		assignedTo = userID
+	} else if assignedTo != "" && assignedTo != "unassigned" {
+		if _, err := uuid.Parse(assignedTo); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "assigned_to must be me, unassigned or an agent ID"})
+		}
+	}
+
 	if page < 1 {
 		page = 1
 	}
@@ -84,7 +111,7 @@ func GetContacts(c *fiber.Ctx) error {
 		limit = 20
 	}
 
-	contacts, total, err := contactRepo.FindAllByOrg(orgID, page, limit, search)
+	contacts, total, err := contactRepo.FindAllByOrgAndAssignee(orgID, page, limit, search, assignedTo)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch contacts"})
 	}
@@ -132,6 +159,8 @@ func UpdateContact(c *fiber.Ctx) error {
 		Bathrooms         *int     `json:"bathrooms"`
 		SquareFeet        *int     `json:"square_feet"`
 		PreferredLocation *string  `json:"preferred_location"`
+		PreferredLanguage *string  `json:"preferred_language"`
+		Timezone          *string  `json:"timezone"`
 		Notes             *string  `json:"notes"`
 	}
 
@@ -173,6 +202,20 @@ func UpdateContact(c *fiber.Ctx) error {
 	if req.PreferredLocation != nil {
 		contact.PreferredLocation = *req.PreferredLocation
 	}
+	if req.PreferredLanguage != nil {
+		language, err := services.NormalizeLocale(*req.PreferredLanguage)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		contact.PreferredLanguage = language
+	}
+	if req.Timezone != nil {
+		timezone, err := services.NormalizeTimezone(*req.Timezone)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		contact.Timezone = timezone
+	}
 	if req.Notes != nil {
 		contact.Notes = *req.Notes
 	}
@@ -187,6 +230,111 @@ func UpdateContact(c *fiber.Ctx) error {
 	})
 }
 
+// canReassignContact reports whether the caller may change a contact's assignee.
+// Org admins may reassign any contact; agents only their own or unassigned contacts.
+func canReassignContact(role, userID string, contact *models.Contact) bool {
+	if role == "org_admin" {
+		return true
+	}
+	return contact.AssignedTo == nil || *contact.AssignedTo == userID
+}
+
+// AssignContact assigns a contact to an agent (empty agent_id unassigns it)
+func AssignContact(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	contactID := c.Params("id")
+
+	contact, err := contactRepo.FindByID(contactID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	var req struct {
+		AgentID string `json:"agent_id"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if !canReassignContact(role, userID, contact) {
+		return c.Status(403).JSON(fiber.Map{"error": "You can only reassign contacts assigned to you"})
+	}
+
+	var agentID *string
+	if req.AgentID != "" {
+		if err := contactService.ValidateAssignee(orgID, req.AgentID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		agentID = &req.AgentID
+	}
+
+	if _, err := contactRepo.UpdateAssignee([]string{contact.ID}, orgID, agentID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to assign contact"})
+	}
+	contact.AssignedTo = agentID
+
+	return c.JSON(fiber.Map{
+		"message": "Contact assigned successfully",
+		"contact": contact,
+	})
+}
+
+// BulkAssignContacts assigns several contacts to the same agent
+func BulkAssignContacts(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+
+	var req struct {
+		ContactIDs []string `json:"contact_ids"`
+		AgentID    string   `json:"agent_id"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if len(req.ContactIDs) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "No contact IDs provided"})
+	}
+
+	contacts, err := contactRepo.FindByIDs(req.ContactIDs, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to verify contacts"})
+	}
+
+	if len(contacts) != len(req.ContactIDs) {
+		return c.Status(400).JSON(fiber.Map{"error": "Some contacts not found or don't belong to your organization"})
+	}
+
+	for i := range contacts {
+		if !canReassignContact(role, userID, &contacts[i]) {
+			return c.Status(403).JSON(fiber.Map{"error": "You can only reassign contacts assigned to you"})
+		}
+	}
+
+	var agentID *string
+	if req.AgentID != "" {
+		if err := contactService.ValidateAssignee(orgID, req.AgentID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		agentID = &req.AgentID
+	}
+
+	count, err := contactRepo.UpdateAssignee(req.ContactIDs, orgID, agentID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to assign contacts"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Contacts assigned successfully",
+		"count":   count,
+	})
+}
+
 // DeleteContact soft deletes a contact
 func DeleteContact(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	dealRepo          = &repository.DealRepository{}
+	commissionService = services.NewCommissionService()
+)
+
+// isValidDealStage checks a deal stage value
+func isValidDealStage(stage string) bool {
+	switch stage {
+	case "offer", "under_contract", "closed_won", "closed_lost":
+		return true
+	}
+	return false
+}
+
+// canAccessDeal reports whether a user may view or change a deal.
+// Org admins see every deal; agents only deals they work on or created.
+func canAccessDeal(role, userID string, deal *models.Deal) bool {
+	if role == "org_admin" || deal.CreatedBy == userID {
+		return true
+	}
+	return (deal.ListingAgentID != nil && *deal.ListingAgentID == userID) ||
+		(deal.BuyerAgentID != nil && *deal.BuyerAgentID == userID)
+}
+
+// dealPayoutFieldsError is returned when an agent tries to set a field that decides commission
+// payouts. The accepted price, the agents on each side and the commission rule are set by org
+// admins only, so agents can't choose their own payout.
+const dealPayoutFieldsError = "Only org admins can set the accepted price, deal agents or commission rule"
+
+// validateDealAgent checks an optional agent reference on a deal
+func validateDealAgent(orgID string, agentID *string) (*string, error) {
+	if agentID == nil || *agentID == "" {
+		return nil, nil
+	}
+	if err := contactService.ValidateAssignee(orgID, *agentID); err != nil {
+		return nil, err
+	}
+	return agentID, nil
+}
+
+// applyDealStage moves a deal to a stage, stamping or clearing the closing date
+func applyDealStage(deal *models.Deal, stage string, closedAt *time.Time) error {
+	if stage == "closed_won" && deal.AcceptedPrice == nil {
+		return errors.New("accepted_price is required to close a deal as won")
+	}
+
+	if stage == "closed_won" || stage == "closed_lost" {
+		if closedAt != nil {
+			deal.ClosedAt = closedAt
+		} else if deal.ClosedAt == nil || deal.Stage != stage {
+			now := time.Now()
+			deal.ClosedAt = &now
+		}
+	} else {
+		deal.ClosedAt = nil
+	}
+
+	deal.Stage = stage
+	return nil
+}
+
+// CreateDeal opens a deal for a contact
+func CreateDeal(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+
+	var req struct {
+		ContactID         string     `json:"contact_id"`
+		PropertyAddress   string     `json:"property_address"`
+		Title             string     `json:"title"`
+		ListingAgentID    *string    `json:"listing_agent_id"`
+		BuyerAgentID      *string    `json:"buyer_agent_id"`
+		OfferPrice        *float64   `json:"offer_price"`
+		AcceptedPrice     *float64   `json:"accepted_price"`
+		Stage             string     `json:"stage"`
+		ExpectedCloseDate *time.Time `json:"expected_close_date"`
+		ClosedAt          *time.Time `json:"closed_at"`
+		CommissionRuleID  *string    `json:"commission_rule_id"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if role != "org_admin" && (req.ListingAgentID != nil || req.BuyerAgentID != nil ||
+		req.AcceptedPrice != nil || req.CommissionRuleID != nil) {
+		return c.Status(403).JSON(fiber.Map{"error": dealPayoutFieldsError})
+	}
+
+	if req.ContactID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "contact_id is required"})
+	}
+	if _, err := contactRepo.FindByID(req.ContactID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	if req.Stage == "" {
+		req.Stage = "offer"
+	}
+	if !isValidDealStage(req.Stage) {
+		return c.Status(400).JSON(fiber.Map{"error": "stage must be 'offer', 'under_contract', 'closed_won', or 'closed_lost'"})
+	}
+
+	if (req.OfferPrice != nil && *req.OfferPrice < 0) || (req.AcceptedPrice != nil && *req.AcceptedPrice < 0) {
+		return c.Status(400).JSON(fiber.Map{"error": "Prices cannot be negative"})
+	}
+
+	listingAgentID, err := validateDealAgent(orgID, req.ListingAgentID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	buyerAgentID, err := validateDealAgent(orgID, req.BuyerAgentID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Contacts are buyers, so an agent who records a deal without naming sides represents the buyer
+	if listingAgentID == nil && buyerAgentID == nil {
+		buyerAgentID = &userID
+	}
+
+	if req.CommissionRuleID != nil && *req.CommissionRuleID != "" {
+		if _, err := commissionRuleRepo.FindByID(*req.CommissionRuleID, orgID); err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Commission rule not found"})
+		}
+	} else {
+		req.CommissionRuleID = nil
+	}
+
+	deal := models.Deal{
+		OrganizationID:    orgID,
+		ContactID:         req.ContactID,
+		PropertyAddress:   strings.TrimSpace(req.PropertyAddress),
+		Title:             strings.TrimSpace(req.Title),
+		ListingAgentID:    listingAgentID,
+		BuyerAgentID:      buyerAgentID,
+		OfferPrice:        req.OfferPrice,
+		AcceptedPrice:     req.AcceptedPrice,
+		ExpectedCloseDate: req.ExpectedCloseDate,
+		CommissionRuleID:  req.CommissionRuleID,
+		CreatedBy:         userID,
+	}
+
+	if err := applyDealStage(&deal, req.Stage, req.ClosedAt); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := dealRepo.Create(&deal); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create deal"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Deal created successfully",
+		"deal":    deal,
+	})
+}
+
+// GetDeals returns paginated deals, newest first
+func GetDeals(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := repository.DealFilter{
+		AgentID:   c.Query("agent_id", ""),
+		ContactID: c.Query("contact_id", ""),
+		Stage:     c.Query("stage", ""),
+	}
+
+	// Agents only see deals they work on; admins may filter by agent
+	if role != "org_admin" || filter.AgentID == "me" {
+		filter.AgentID = userID
+	}
+
+	deals, total, err := dealRepo.FindAllByOrg(orgID, filter, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch deals"})
+	}
+
+	return c.JSON(fiber.Map{
+		"deals": deals,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetDealByID returns a deal together with its commission breakdown when it has a price
+func GetDealByID(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	dealID := c.Params("id")
+
+	deal, err := dealRepo.FindByID(dealID, orgID)
+	if err != nil || !canAccessDeal(role, userID, deal) {
+		return c.Status(404).JSON(fiber.Map{"error": "Deal not found"})
+	}
+
+	response := fiber.Map{"deal": deal}
+	if gross, payouts, err := commissionService.DealPayouts(deal); err == nil {
+		response["gross_commission"] = gross
+		response["payouts"] = payouts
+	}
+
+	return c.JSON(response)
+}
+
+// UpdateDeal updates a deal's prices, agents, dates or stage
+func UpdateDeal(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	dealID := c.Params("id")
+
+	deal, err := dealRepo.FindByID(dealID, orgID)
+	if err != nil || !canAccessDeal(role, userID, deal) {
+		return c.Status(404).JSON(fiber.Map{"error": "Deal not found"})
+	}
+
+	var req struct {
+		PropertyAddress   *string    `json:"property_address"`
+		Title             *string    `json:"title"`
+		ListingAgentID    *string    `json:"listing_agent_id"` // "" clears
+		BuyerAgentID      *string    `json:"buyer_agent_id"`   // "" clears
+		OfferPrice        *float64   `json:"offer_price"`
+		AcceptedPrice     *float64   `json:"accepted_price"`
+		Stage             *string    `json:"stage"`
+		ExpectedCloseDate *time.Time `json:"expected_close_date"`
+		ClosedAt          *time.Time `json:"closed_at"`
+		CommissionRuleID  *string    `json:"commission_rule_id"` // "" falls back to the default rule
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if role != "org_admin" && (req.ListingAgentID != nil || req.BuyerAgentID != nil ||
+		req.AcceptedPrice != nil || req.CommissionRuleID != nil) {
+		return c.Status(403).JSON(fiber.Map{"error": dealPayoutFieldsError})
+	}
+
+	if req.PropertyAddress != nil {
+		deal.PropertyAddress = strings.TrimSpace(*req.PropertyAddress)
+	}
+	if req.Title != nil {
+		deal.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.ListingAgentID != nil {
+		if deal.ListingAgentID, err = validateDealAgent(orgID, req.ListingAgentID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if req.BuyerAgentID != nil {
+		if deal.BuyerAgentID, err = validateDealAgent(orgID, req.BuyerAgentID); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if req.OfferPrice != nil {
+		if *req.OfferPrice < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Prices cannot be negative"})
+		}
+		deal.OfferPrice = req.OfferPrice
+	}
+	if req.AcceptedPrice != nil {
+		if *req.AcceptedPrice < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Prices cannot be negative"})
+		}
+		deal.AcceptedPrice = req.AcceptedPrice
+	}
+	if req.ExpectedCloseDate != nil {
+		deal.ExpectedCloseDate = req.ExpectedCloseDate
+	}
+	if req.CommissionRuleID != nil {
+		if *req.CommissionRuleID == "" {
+			deal.CommissionRuleID = nil
+		} else {
+			if _, err := commissionRuleRepo.FindByID(*req.CommissionRuleID, orgID); err != nil {
+				return c.Status(404).JSON(fiber.Map{"error": "Commission rule not found"})
+			}
+			deal.CommissionRuleID = req.CommissionRuleID
+		}
+	}
+
+	stage := deal.Stage
+	if req.Stage != nil {
+		if !isValidDealStage(*req.Stage) {
+			return c.Status(400).JSON(fiber.Map{"error": "stage must be 'offer', 'under_contract', 'closed_won', or 'closed_lost'"})
+		}
+		stage = *req.Stage
+	}
+	if req.Stage != nil || req.ClosedAt != nil {
+		if err := applyDealStage(deal, stage, req.ClosedAt); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := dealRepo.Update(deal); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update deal"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Deal updated successfully",
+		"deal":    deal,
+	})
+}
+
+// DeleteDeal deletes a deal
+func DeleteDeal(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	dealID := c.Params("id")
+
+	deal, err := dealRepo.FindByID(dealID, orgID)
+	if err != nil || !canAccessDeal(role, userID, deal) {
+		return c.Status(404).JSON(fiber.Map{"error": "Deal not found"})
+	}
+
+	if err := dealRepo.Delete(deal.ID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete deal"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Deal deleted successfully"})
+}
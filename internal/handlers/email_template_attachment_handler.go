@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	attachmentRepo    = &repository.EmailTemplateAttachmentRepository{}
+	attachmentService = services.NewAttachmentService()
+)
+
+// UploadEmailTemplateAttachment attaches a file to a template; it is sent with every email built from it.
+// The change is recorded as a new template version, so campaigns pinned to older versions keep
+// their attachments.
+func UploadEmailTemplateAttachment(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	templateID := c.Params("id")
+
+	template, err := templateRepo.FindByID(templateID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "No file uploaded"})
+	}
+	if file.Size > services.MaxAttachmentSize {
+		return c.Status(413).JSON(fiber.Map{"error": services.ErrAttachmentTooLarge.Error()})
+	}
+
+	fileContent, err := file.Open()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to read file"})
+	}
+	defer fileContent.Close()
+
+	data, err := io.ReadAll(io.LimitReader(fileContent, services.MaxAttachmentSize+1))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to read file content"})
+	}
+
+	attachment, err := attachmentService.Upload(template, file.Filename, data, userID)
+	switch {
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		return c.Status(413).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrAttachmentEmpty),
+		errors.Is(err, services.ErrAttachmentTypeNotAllowed),
+		errors.Is(err, services.ErrAttachmentLimitReached):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save attachment"})
+	}
+	if _, err := templateService.SaveVersion(template, userID, nil); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save template version"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":    "Attachment uploaded successfully",
+		"attachment": attachment,
+	})
+}
+
+// GetEmailTemplateAttachments lists a template's attachments
+func GetEmailTemplateAttachments(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	templateID := c.Params("id")
+
+	if _, err := templateRepo.FindByID(templateID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	attachments, err := attachmentRepo.FindByTemplate(templateID, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch attachments"})
+	}
+
+	var totalSize int64
+	for _, attachment := range attachments {
+		totalSize += attachment.SizeBytes
+	}
+
+	return c.JSON(fiber.Map{
+		"attachments":      attachments,
+		"total_size_bytes": totalSize,
+		"max_total_bytes":  services.MaxTemplateAttachmentsSize,
+		"max_file_bytes":   services.MaxAttachmentSize,
+		"max_attachments":  services.MaxTemplateAttachments,
+	})
+}
+
+// DeleteEmailTemplateAttachment removes an attachment from a template as a new template version
+func DeleteEmailTemplateAttachment(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	templateID := c.Params("id")
+
+	template, err := templateRepo.FindByID(templateID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	attachment, err := attachmentRepo.FindByID(c.Params("attachmentId"), templateID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Attachment not found"})
+	}
+
+	if err := attachmentService.Delete(attachment); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete attachment"})
+	}
+	if _, err := templateService.SaveVersion(template, userID, nil); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save template version"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Attachment deleted successfully"})
+}
@@ -1,15 +1,28 @@
 package handlers
 
 import (
+	"encoding/json"
+
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 var (
-	templateRepo = &repository.EmailTemplateRepository{}
+	templateRepo    = &repository.EmailTemplateRepository{}
+	templateService = services.NewEmailTemplateService()
+	senderService   = services.NewSenderIdentityService()
 )
 
+// templateWarnings collects the non-blocking problems reported when a template is saved
+func templateWarnings(orgID string, template *models.EmailTemplate) []services.TemplateWarning {
+	warnings := senderService.TemplateWarnings(orgID, template)
+	partials, _ := partialRepo.FindAllByOrg(orgID)
+	return append(warnings, services.LintEmailTemplate(template, partials)...)
+}
+
 // CreateEmailTemplate creates a new email template
 func CreateEmailTemplate(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
@@ -23,14 +36,24 @@ func CreateEmailTemplate(c *fiber.Ctx) error {
 		ReplyTo       string `json:"reply_to"`
 		HtmlBody      string `json:"html_body"`
 		PlainTextBody string `json:"plain_text_body"`
+
+		// Block-built templates send builder_blocks instead of html_body
+		EditorType    string          `json:"editor_type"`
+		BuilderBlocks json.RawMessage `json:"builder_blocks"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	if req.Name == "" || req.Subject == "" || req.HtmlBody == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Name, subject, and html_body are required"})
+	if req.EditorType == "" {
+		req.EditorType = services.EditorTypeHTML
+		if len(req.BuilderBlocks) > 0 {
+			req.EditorType = services.EditorTypeBlocks
+		}
+	}
+	if req.EditorType != services.EditorTypeHTML && req.EditorType != services.EditorTypeBlocks {
+		return c.Status(400).JSON(fiber.Map{"error": "editor_type must be html or blocks"})
 	}
 
 	template := models.EmailTemplate{
@@ -40,18 +63,70 @@ func CreateEmailTemplate(c *fiber.Ctx) error {
 		Preheader:      req.Preheader,
 		FromName:       req.FromName,
 		ReplyTo:        req.ReplyTo,
-		HtmlBody:       req.HtmlBody,
-		PlainTextBody:  req.PlainTextBody,
+		EditorType:     req.EditorType,
 		CreatedBy:      userID,
 	}
 
-	if err := templateRepo.Create(&template); err != nil {
+	sanitized := services.SanitizeReport{RemovedElements: map[string]int{}, RemovedAttributes: map[string]int{}}
+	if req.EditorType == services.EditorTypeBlocks {
+		if req.Name == "" || req.Subject == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Name, subject, and builder_blocks are required"})
+		}
+		if req.HtmlBody != "" || req.PlainTextBody != "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Block templates generate html_body and plain_text_body from builder_blocks"})
+		}
+		if err := services.ApplyBlocks(&template, req.BuilderBlocks); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid builder_blocks: " + err.Error()})
+		}
+	} else {
+		if req.Name == "" || req.Subject == "" || req.HtmlBody == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Name, subject, and html_body are required"})
+		}
+		if len(req.BuilderBlocks) > 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "builder_blocks requires editor_type blocks"})
+		}
+		// Strip anything that could run script before the HTML is stored
+		template.HtmlBody, sanitized = services.SanitizeEmailHTML(req.HtmlBody)
+		template.PlainTextBody = req.PlainTextBody
+	}
+
+	if err := services.ValidateEmailTemplate(&template); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid template: " + err.Error()})
+	}
+
+	// The first save is version 1
+	if _, err := templateService.SaveVersion(&template, userID, nil); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create email template"})
 	}
 
 	return c.Status(201).JSON(fiber.Map{
-		"message":  "Email template created successfully",
-		"template": template,
+		"message":   "Email template created successfully",
+		"template":  template,
+		"warnings":  templateWarnings(orgID, &template),
+		"sanitized": sanitized,
+	})
+}
+
+// CompileEmailTemplateBlocks compiles block JSON to HTML and plain text without saving,
+// so the builder can show what it will produce
+func CompileEmailTemplateBlocks(c *fiber.Ctx) error {
+	var req struct {
+		BuilderBlocks json.RawMessage `json:"builder_blocks"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	doc, err := services.ParseBlockDocument(req.BuilderBlocks)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid builder_blocks: " + err.Error()})
+	}
+
+	htmlBody, plainTextBody := services.CompileBlocks(doc)
+	return c.JSON(fiber.Map{
+		"html_body":       htmlBody,
+		"plain_text_body": plainTextBody,
 	})
 }
 
@@ -80,9 +155,10 @@ func GetEmailTemplateByID(c *fiber.Ctx) error {
 	return c.JSON(template)
 }
 
-// UpdateEmailTemplate updates an email template
+// UpdateEmailTemplate updates an email template, recording a new version when its content changes
 func UpdateEmailTemplate(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
 	templateID := c.Params("id")
 
 	template, err := templateRepo.FindByID(templateID, orgID)
@@ -90,6 +166,12 @@ func UpdateEmailTemplate(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
 	}
 
+	// Templates saved before version history get their current content kept as version 1
+	if _, err := templateService.EnsureVersioned(template); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update email template"})
+	}
+	original := *template
+
 	var req struct {
 		Name          *string `json:"name"`
 		Subject       *string `json:"subject"`
@@ -98,12 +180,38 @@ func UpdateEmailTemplate(c *fiber.Ctx) error {
 		ReplyTo       *string `json:"reply_to"`
 		HtmlBody      *string `json:"html_body"`
 		PlainTextBody *string `json:"plain_text_body"`
+
+		// Switching to html keeps the compiled HTML as an editable starting point
+		EditorType    *string         `json:"editor_type"`
+		BuilderBlocks json.RawMessage `json:"builder_blocks"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
+	if req.EditorType != nil {
+		switch *req.EditorType {
+		case services.EditorTypeHTML:
+			template.EditorType = services.EditorTypeHTML
+			template.BuilderBlocks = nil
+		case services.EditorTypeBlocks:
+			if template.EditorType != services.EditorTypeBlocks && len(req.BuilderBlocks) == 0 {
+				return c.Status(400).JSON(fiber.Map{"error": "builder_blocks is required to switch to the block editor"})
+			}
+			template.EditorType = services.EditorTypeBlocks
+		default:
+			return c.Status(400).JSON(fiber.Map{"error": "editor_type must be html or blocks"})
+		}
+	}
+	if template.EditorType == services.EditorTypeBlocks {
+		if req.HtmlBody != nil || req.PlainTextBody != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Block templates generate html_body and plain_text_body from builder_blocks"})
+		}
+	} else if len(req.BuilderBlocks) > 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "builder_blocks requires editor_type blocks"})
+	}
+
 	if req.Name != nil {
 		template.Name = *req.Name
 	}
@@ -119,20 +227,34 @@ func UpdateEmailTemplate(c *fiber.Ctx) error {
 	if req.ReplyTo != nil {
 		template.ReplyTo = *req.ReplyTo
 	}
+	sanitized := services.SanitizeReport{RemovedElements: map[string]int{}, RemovedAttributes: map[string]int{}}
 	if req.HtmlBody != nil {
-		template.HtmlBody = *req.HtmlBody
+		template.HtmlBody, sanitized = services.SanitizeEmailHTML(*req.HtmlBody)
 	}
 	if req.PlainTextBody != nil {
 		template.PlainTextBody = *req.PlainTextBody
 	}
+	if len(req.BuilderBlocks) > 0 {
+		if err := services.ApplyBlocks(template, req.BuilderBlocks); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid builder_blocks: " + err.Error()})
+		}
+	}
 
-	if err := templateRepo.Update(template); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update email template"})
+	if err := services.ValidateEmailTemplate(template); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid template: " + err.Error()})
+	}
+
+	if services.TemplateContentChanged(&original, template) {
+		if _, err := templateService.SaveVersion(template, userID, nil); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to update email template"})
+		}
 	}
 
 	return c.JSON(fiber.Map{
-		"message":  "Email template updated successfully",
-		"template": template,
+		"message":   "Email template updated successfully",
+		"template":  template,
+		"warnings":  templateWarnings(orgID, template),
+		"sanitized": sanitized,
 	})
 }
 
@@ -141,6 +263,13 @@ func DeleteEmailTemplate(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
 	templateID := c.Params("id")
 
+	if err := attachmentService.DeleteForTemplate(templateID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete email template attachments"})
+	}
+	if err := variantRepo.DeleteByTemplate(templateID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete email template variants"})
+	}
+
 	if err := templateRepo.Delete(templateID, orgID); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete email template"})
 	}
@@ -148,9 +277,113 @@ func DeleteEmailTemplate(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Email template deleted successfully"})
 }
 
-// TestSendEmail sends a test email using a template
+// templateRenderData builds the variables for rendering a template as the current user,
+// along with the contact they describe. An empty contactID uses the sample contact.
+func templateRenderData(orgID, userID, contactID string) (map[string]interface{}, *models.Contact, error) {
+	contact := services.SampleContact()
+	if contactID != "" {
+		found, err := contactRepo.FindByID(contactID, orgID)
+		if err != nil {
+			return nil, nil, err
+		}
+		contact = found
+	}
+
+	var org *models.Organization
+	if orgUUID, err := uuid.Parse(orgID); err == nil {
+		org, _ = orgRepo.FindByID(orgUUID)
+	}
+	var agent *models.User
+	if userUUID, err := uuid.Parse(userID); err == nil {
+		agent, _ = userRepo.FindByID(userUUID)
+	}
+
+	brand, _ := settingsService.GetSettings(orgID)
+
+	return services.BuildTemplateData(contact, org, agent, brand), contact, nil
+}
+
+// localizedTemplate picks the variant for the requested locale, or for the contact's preferred
+// language when none is requested. The returned locale is empty for the template default.
+func localizedTemplate(template *models.EmailTemplate, orgID, locale string, contact *models.Contact) (*models.EmailTemplate, string, error) {
+	locale, _ = services.NormalizeLocale(locale)
+	if locale == "" {
+		locale = contact.PreferredLanguage
+	}
+
+	variants, err := variantRepo.FindByTemplate(template.ID, orgID)
+	if err != nil {
+		return nil, "", err
+	}
+	if variant := services.MatchVariant(variants, locale); variant != nil {
+		return services.ApplyVariant(template, variant), variant.Locale, nil
+	}
+	return template, "", nil
+}
+
+// PreviewEmailTemplate renders a template against a contact (or a sample contact)
+// and reports variables that could not be filled
+func PreviewEmailTemplate(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	templateID := c.Params("id")
+
+	template, err := templateRepo.FindByID(templateID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	var req struct {
+		ContactID string `json:"contact_id"`
+		Locale    string `json:"locale"` // defaults to the contact's preferred language
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	data, contact, err := templateRenderData(orgID, userID, req.ContactID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	partials, err := partialRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load template partials"})
+	}
+
+	localized, locale, err := localizedTemplate(template, orgID, req.Locale, contact)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load template variants"})
+	}
+
+	rendered, err := services.RenderEmailTemplate(localized, partials, data)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid template: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"from_name":            rendered.FromName,
+		"reply_to":             rendered.ReplyTo,
+		"subject":              rendered.Subject,
+		"preheader":            rendered.Preheader,
+		"html_body":            rendered.HtmlBody,
+		"plain_text_body":      rendered.TextBody,
+		"unknown_variables":    rendered.Report.UnknownVariables,
+		"unresolved_variables": rendered.Report.UnresolvedVariables,
+		"unknown_partials":     rendered.Report.UnknownPartials,
+		"sample_contact":       req.ContactID == "",
+		"locale":               locale,
+		"warnings":             templateWarnings(orgID, localized),
+	})
+}
+
+// TestSendEmail sends a test email rendered for a contact (or a sample contact)
 func TestSendEmail(c *fiber.Ctx) error {
 	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
 	templateID := c.Params("id")
 
 	template, err := templateRepo.FindByID(templateID, orgID)
@@ -160,6 +393,8 @@ func TestSendEmail(c *fiber.Ctx) error {
 
 	var req struct {
 		TestEmail string `json:"test_email"`
+		ContactID string `json:"contact_id"`
+		Locale    string `json:"locale"` // defaults to the contact's preferred language
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -170,20 +405,49 @@ func TestSendEmail(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "test_email is required"})
 	}
 
-	// Send test email
-	err = emailService.SendCampaignEmail(
-		req.TestEmail,
-		template.Subject,
-		template.HtmlBody,
-		template.PlainTextBody,
-	)
+	data, contact, err := templateRenderData(orgID, userID, req.ContactID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	partials, err := partialRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load template partials"})
+	}
 
+	localized, locale, err := localizedTemplate(template, orgID, req.Locale, contact)
 	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load template variants"})
+	}
+
+	rendered, err := services.RenderEmailTemplate(localized, partials, data)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid template: " + err.Error()})
+	}
+
+	verified, err := senderService.VerifiedAddresses(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load sender identities"})
+	}
+	if err := services.CheckSender(rendered.FromName, rendered.ReplyTo, verified); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Send test email
+	attachments, err := attachmentService.LoadForTemplate(template)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load attachments"})
+	}
+
+	message := rendered.Message(req.TestEmail)
+	message.Attachments = attachments
+	if err := emailService.SendMessage(message); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to send test email"})
 	}
 
 	return c.JSON(fiber.Map{
 		"message": "Test email sent successfully",
 		"to":      req.TestEmail,
+		"locale":  locale,
 	})
 }
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var variantRepo = &repository.EmailTemplateVariantRepository{}
+
+// CreateEmailTemplateVariant adds a translation of a template for contacts who prefer
+// another language
+func CreateEmailTemplateVariant(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	templateID := c.Params("id")
+
+	template, err := templateRepo.FindByID(templateID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	var req struct {
+		Locale        string `json:"locale"`
+		Subject       string `json:"subject"`
+		Preheader     string `json:"preheader"`
+		HtmlBody      string `json:"html_body"`
+		PlainTextBody string `json:"plain_text_body"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	locale, err := services.NormalizeLocale(req.Locale)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if locale == "" || req.Subject == "" || req.HtmlBody == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Locale, subject, and html_body are required"})
+	}
+	if _, err := variantRepo.FindByLocale(template.ID, locale, orgID); err == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "A variant for this locale already exists"})
+	}
+
+	htmlBody, sanitized := services.SanitizeEmailHTML(req.HtmlBody)
+	variant := models.EmailTemplateVariant{
+		EmailTemplateID: template.ID,
+		OrganizationID:  orgID,
+		Locale:          locale,
+		Subject:         req.Subject,
+		Preheader:       req.Preheader,
+		HtmlBody:        htmlBody,
+		PlainTextBody:   req.PlainTextBody,
+		CreatedBy:       userID,
+	}
+
+	localized := services.ApplyVariant(template, &variant)
+	if err := services.ValidateEmailTemplate(localized); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid template: " + err.Error()})
+	}
+
+	if err := variantRepo.Create(&variant); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create template variant"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":   "Template variant created successfully",
+		"variant":   variant,
+		"warnings":  templateWarnings(orgID, localized),
+		"sanitized": sanitized,
+	})
+}
+
+// GetEmailTemplateVariants returns a template's locale variants
+func GetEmailTemplateVariants(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	templateID := c.Params("id")
+
+	if _, err := templateRepo.FindByID(templateID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	variants, err := variantRepo.FindByTemplate(templateID, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch template variants"})
+	}
+
+	return c.JSON(variants)
+}
+
+// UpdateEmailTemplateVariant updates the translation for one locale
+func UpdateEmailTemplateVariant(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	templateID := c.Params("id")
+
+	template, err := templateRepo.FindByID(templateID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	locale, _ := services.NormalizeLocale(c.Params("locale"))
+	variant, err := variantRepo.FindByLocale(template.ID, locale, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Template variant not found"})
+	}
+
+	var req struct {
+		Subject       *string `json:"subject"`
+		Preheader     *string `json:"preheader"`
+		HtmlBody      *string `json:"html_body"`
+		PlainTextBody *string `json:"plain_text_body"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	sanitized := services.SanitizeReport{RemovedElements: map[string]int{}, RemovedAttributes: map[string]int{}}
+	if req.Subject != nil {
+		variant.Subject = *req.Subject
+	}
+	if req.Preheader != nil {
+		variant.Preheader = *req.Preheader
+	}
+	if req.HtmlBody != nil {
+		variant.HtmlBody, sanitized = services.SanitizeEmailHTML(*req.HtmlBody)
+	}
+	if req.PlainTextBody != nil {
+		variant.PlainTextBody = *req.PlainTextBody
+	}
+	if variant.Subject == "" || variant.HtmlBody == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Subject and html_body cannot be empty"})
+	}
+
+	localized := services.ApplyVariant(template, variant)
+	if err := services.ValidateEmailTemplate(localized); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid template: " + err.Error()})
+	}
+
+	if err := variantRepo.Update(variant); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update template variant"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "Template variant updated successfully",
+		"variant":   variant,
+		"warnings":  templateWarnings(orgID, localized),
+		"sanitized": sanitized,
+	})
+}
+
+// DeleteEmailTemplateVariant removes a translation; contacts in that language get the
+// template default
+func DeleteEmailTemplateVariant(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	templateID := c.Params("id")
+
+	locale, _ := services.NormalizeLocale(c.Params("locale"))
+	variant, err := variantRepo.FindByLocale(templateID, locale, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Template variant not found"})
+	}
+
+	if err := variantRepo.Delete(variant.ID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete template variant"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Template variant deleted successfully"})
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var templateVersionRepo = &repository.EmailTemplateVersionRepository{}
+
+// findVersionedTemplate loads a template, snapshotting it first if it predates version history
+func findVersionedTemplate(templateID, orgID string) (*models.EmailTemplate, error) {
+	template, err := templateRepo.FindByID(templateID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := templateService.EnsureVersioned(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// GetEmailTemplateVersions lists a template's versions, newest first
+func GetEmailTemplateVersions(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	template, err := findVersionedTemplate(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	versions, err := templateVersionRepo.FindByTemplate(template.ID, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch template versions"})
+	}
+
+	return c.JSON(fiber.Map{
+		"current_version": template.CurrentVersion,
+		"versions":        versions,
+	})
+}
+
+// GetEmailTemplateVersion returns one version of a template
+func GetEmailTemplateVersion(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	template, err := findVersionedTemplate(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	number, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "version must be a number"})
+	}
+
+	version, err := templateVersionRepo.FindByVersion(template.ID, orgID, number)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Template version not found"})
+	}
+
+	return c.JSON(version)
+}
+
+// DiffEmailTemplateVersions compares two versions of a template.
+// ?from is required; ?to defaults to the current version.
+func DiffEmailTemplateVersions(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	template, err := findVersionedTemplate(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	fromNumber, err := strconv.Atoi(c.Query("from", ""))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "from must be a version number"})
+	}
+	toNumber := template.CurrentVersion
+	if toStr := c.Query("to", ""); toStr != "" {
+		if toNumber, err = strconv.Atoi(toStr); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "to must be a version number"})
+		}
+	}
+
+	from, err := templateVersionRepo.FindByVersion(template.ID, orgID, fromNumber)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Template version not found"})
+	}
+	to, err := templateVersionRepo.FindByVersion(template.ID, orgID, toNumber)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Template version not found"})
+	}
+
+	return c.JSON(fiber.Map{
+		"from":    from.Version,
+		"to":      to.Version,
+		"changes": services.DiffVersions(from, to),
+	})
+}
+
+// RestoreEmailTemplateVersion makes an old version's content and attachments current by recording
+// it as a new version
+func RestoreEmailTemplateVersion(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	template, err := findVersionedTemplate(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Email template not found"})
+	}
+
+	number, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "version must be a number"})
+	}
+
+	version, err := templateVersionRepo.FindByVersion(template.ID, orgID, number)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Template version not found"})
+	}
+
+	if number == template.CurrentVersion {
+		return c.Status(400).JSON(fiber.Map{"error": "Version is already current"})
+	}
+
+	if err := attachmentRepo.SetCurrent(template.ID, orgID, version.AttachmentIDs); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to restore template attachments"})
+	}
+	services.ApplyVersion(template, version)
+	restored, err := templateService.SaveVersion(template, userID, &version.Version)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to restore template version"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Template version restored successfully",
+		"template": template,
+		"version":  restored,
+	})
+}
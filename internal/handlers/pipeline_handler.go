@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	stageRepo        = &repository.PipelineStageRepository{}
+	stageHistoryRepo = &repository.ContactStageHistoryRepository{}
+	pipelineService  = services.NewPipelineService()
+)
+
+// GetPipelineStages returns the organization's pipeline stages in order
+func GetPipelineStages(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	stages, err := pipelineService.GetStages(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch pipeline stages"})
+	}
+
+	return c.JSON(stages)
+}
+
+// CreatePipelineStage adds a new stage to the end of the pipeline
+func CreatePipelineStage(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	var req struct {
+		Name    string `json:"name"`
+		Outcome string `json:"outcome"` // open | won | lost
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Name is required"})
+	}
+
+	if req.Outcome == "" {
+		req.Outcome = "open"
+	}
+	if !services.IsValidStageOutcome(req.Outcome) {
+		return c.Status(400).JSON(fiber.Map{"error": "outcome must be 'open', 'won', or 'lost'"})
+	}
+
+	stages, err := pipelineService.GetStages(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch pipeline stages"})
+	}
+
+	stage := models.PipelineStage{
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Position:       stages[len(stages)-1].Position + 1,
+		Outcome:        req.Outcome,
+	}
+
+	if err := stageRepo.Create(&stage); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create pipeline stage"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Pipeline stage created successfully",
+		"stage":   stage,
+	})
+}
+
+// UpdatePipelineStage renames a stage or changes its outcome
+func UpdatePipelineStage(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	stageID := c.Params("id")
+
+	stage, err := stageRepo.FindByID(stageID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pipeline stage not found"})
+	}
+
+	var req struct {
+		Name    *string `json:"name"`
+		Outcome *string `json:"outcome"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Name cannot be empty"})
+		}
+		stage.Name = name
+	}
+	if req.Outcome != nil {
+		if !services.IsValidStageOutcome(*req.Outcome) {
+			return c.Status(400).JSON(fiber.Map{"error": "outcome must be 'open', 'won', or 'lost'"})
+		}
+		stage.Outcome = *req.Outcome
+	}
+
+	if err := stageRepo.Update(stage); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update pipeline stage"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Pipeline stage updated successfully",
+		"stage":   stage,
+	})
+}
+
+// ReorderPipelineStages sets the column order of the pipeline
+func ReorderPipelineStages(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	var req struct {
+		StageIDs []string `json:"stage_ids"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	stages, err := pipelineService.GetStages(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch pipeline stages"})
+	}
+
+	// The new order must list every stage exactly once
+	known := make(map[string]bool)
+	for _, stage := range stages {
+		known[stage.ID] = true
+	}
+	if len(req.StageIDs) != len(stages) {
+		return c.Status(400).JSON(fiber.Map{"error": "stage_ids must include every pipeline stage exactly once"})
+	}
+	for _, id := range req.StageIDs {
+		if !known[id] {
+			return c.Status(400).JSON(fiber.Map{"error": "stage_ids must include every pipeline stage exactly once"})
+		}
+		delete(known, id)
+	}
+
+	if err := stageRepo.UpdatePositions(orgID, req.StageIDs); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to reorder pipeline stages"})
+	}
+
+	stages, _ = stageRepo.FindAllByOrg(orgID)
+
+	return c.JSON(fiber.Map{
+		"message": "Pipeline stages reordered successfully",
+		"stages":  stages,
+	})
+}
+
+// DeletePipelineStage deletes an empty stage
+func DeletePipelineStage(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	stageID := c.Params("id")
+
+	if _, err := stageRepo.FindByID(stageID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pipeline stage not found"})
+	}
+
+	stages, err := stageRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch pipeline stages"})
+	}
+	if len(stages) <= 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "A pipeline must have at least one stage"})
+	}
+
+	count, err := stageRepo.CountContacts(stageID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check stage contacts"})
+	}
+	if count > 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Cannot delete a stage that still has contacts. Move them first."})
+	}
+
+	if err := stageRepo.Delete(stageID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete pipeline stage"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Pipeline stage deleted successfully"})
+}
+
+// MoveContactStage moves a single contact to another stage
+func MoveContactStage(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	contactID := c.Params("id")
+
+	contact, err := contactRepo.FindByID(contactID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	var req struct {
+		StageID string `json:"stage_id"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.StageID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "stage_id is required"})
+	}
+
+	if err := pipelineService.MoveContact(contact, req.StageID, userID); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Contact moved successfully",
+		"contact": contact,
+	})
+}
+
+// BulkMoveContactStage moves several contacts to the same stage
+func BulkMoveContactStage(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		ContactIDs []string `json:"contact_ids"`
+		StageID    string   `json:"stage_id"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if len(req.ContactIDs) == 0 || req.StageID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "contact_ids and stage_id are required"})
+	}
+
+	if _, err := stageRepo.FindByID(req.StageID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pipeline stage not found"})
+	}
+
+	contacts, err := contactRepo.FindByIDs(req.ContactIDs, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to verify contacts"})
+	}
+
+	if len(contacts) != len(req.ContactIDs) {
+		return c.Status(400).JSON(fiber.Map{"error": "Some contacts not found or don't belong to your organization"})
+	}
+
+	moved := 0
+	for i := range contacts {
+		if err := pipelineService.MoveContact(&contacts[i], req.StageID, userID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to move contacts"})
+		}
+		moved++
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Contacts moved successfully",
+		"count":   moved,
+	})
+}
+
+// GetContactStageHistory returns the stage changes of a contact, newest first
+func GetContactStageHistory(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	contactID := c.Params("id")
+
+	if _, err := contactRepo.FindByID(contactID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+	}
+
+	history, err := stageHistoryRepo.FindByContact(contactID, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch stage history"})
+	}
+
+	return c.JSON(history)
+}
+
+// GetPipelineBoard returns contacts grouped by stage with per-column counts and budget totals
+func GetPipelineBoard(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	perColumn, _ := strconv.Atoi(c.Query("limit", "20"))
+	if perColumn < 1 || perColumn > 100 {
+		perColumn = 20
+	}
+
+	stages, err := pipelineService.GetStages(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch pipeline stages"})
+	}
+
+	summaries, err := stageRepo.GetBoardSummary(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to build pipeline board"})
+	}
+
+	summaryByStage := make(map[string]repository.StageSummary)
+	var unstaged *repository.StageSummary
+	for i, summary := range summaries {
+		if summary.StageID == nil {
+			unstaged = &summaries[i]
+			continue
+		}
+		summaryByStage[*summary.StageID] = summary
+	}
+
+	type BoardColumn struct {
+		Stage          *models.PipelineStage `json:"stage"`
+		ContactCount   int64                 `json:"contact_count"`
+		TotalBudgetMin float64               `json:"total_budget_min"`
+		TotalBudgetMax float64               `json:"total_budget_max"`
+		Contacts       []models.Contact      `json:"contacts"`
+	}
+
+	var columns []BoardColumn
+	for i := range stages {
+		stage := &stages[i]
+		contacts, err := contactRepo.FindByStage(orgID, &stage.ID, perColumn)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to build pipeline board"})
+		}
+		summary := summaryByStage[stage.ID]
+		columns = append(columns, BoardColumn{
+			Stage:          stage,
+			ContactCount:   summary.ContactCount,
+			TotalBudgetMin: summary.TotalBudgetMin,
+			TotalBudgetMax: summary.TotalBudgetMax,
+			Contacts:       contacts,
+		})
+	}
+
+	// Contacts created before pipelines existed have no stage yet
+	if unstaged != nil && unstaged.ContactCount > 0 {
+		contacts, err := contactRepo.FindByStage(orgID, nil, perColumn)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to build pipeline board"})
+		}
+		columns = append(columns, BoardColumn{
+			Stage:          nil,
+			ContactCount:   unstaged.ContactCount,
+			TotalBudgetMin: unstaged.TotalBudgetMin,
+			TotalBudgetMax: unstaged.TotalBudgetMax,
+			Contacts:       contacts,
+		})
+	}
+
+	return c.JSON(fiber.Map{"columns": columns})
+}
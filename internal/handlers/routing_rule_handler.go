@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+var (
+	routingRuleRepo    = &repository.LeadRoutingRuleRepository{}
+	leadRoutingService = services.NewLeadRoutingService()
+)
+
+// validateRoutingAgents checks that every agent ID belongs to the organization
+func validateRoutingAgents(orgID string, agentIDs []string) error {
+	for _, agentID := range agentIDs {
+		agentUUID, err := uuid.Parse(agentID)
+		if err != nil {
+			return errors.New("Invalid agent ID: " + agentID)
+		}
+		agent, err := userRepo.FindByID(agentUUID)
+		if err != nil || agent.OrganizationID.String() != orgID {
+			return errors.New("Agent not found: " + agentID)
+		}
+	}
+	return nil
+}
+
+// CreateRoutingRule creates a new lead routing rule
+func CreateRoutingRule(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Name          string                      `json:"name"`
+		Priority      *int                        `json:"priority"`
+		IsActive      *bool                       `json:"is_active"`
+		Locations     datatypes.JSONSlice[string] `json:"locations"`
+		PropertyTypes datatypes.JSONSlice[string] `json:"property_types"`
+		BudgetMin     *float64                    `json:"budget_min"`
+		BudgetMax     *float64                    `json:"budget_max"`
+		AgentIDs      datatypes.JSONSlice[string] `json:"agent_ids"`
+		Strategy      string                      `json:"strategy"` // round_robin | least_loaded
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.AgentIDs) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Name and agent_ids are required"})
+	}
+
+	if req.Strategy == "" {
+		req.Strategy = "round_robin"
+	}
+	if !services.IsValidRoutingStrategy(req.Strategy) {
+		return c.Status(400).JSON(fiber.Map{"error": "strategy must be 'round_robin' or 'least_loaded'"})
+	}
+
+	if req.BudgetMin != nil && req.BudgetMax != nil && *req.BudgetMin > *req.BudgetMax {
+		return c.Status(400).JSON(fiber.Map{"error": "budget_min cannot be greater than budget_max"})
+	}
+
+	if err := validateRoutingAgents(orgID, req.AgentIDs); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// New rules go to the end of the evaluation order unless a priority is given
+	priority := 1
+	if req.Priority != nil {
+		priority = *req.Priority
+	} else if rules, err := routingRuleRepo.FindAllByOrg(orgID); err == nil && len(rules) > 0 {
+		priority = rules[len(rules)-1].Priority + 1
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	if req.Locations == nil {
+		req.Locations = datatypes.JSONSlice[string]{}
+	}
+	if req.PropertyTypes == nil {
+		req.PropertyTypes = datatypes.JSONSlice[string]{}
+	}
+
+	rule := models.LeadRoutingRule{
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Priority:       priority,
+		IsActive:       isActive,
+		Locations:      req.Locations,
+		PropertyTypes:  req.PropertyTypes,
+		BudgetMin:      req.BudgetMin,
+		BudgetMax:      req.BudgetMax,
+		AgentIDs:       req.AgentIDs,
+		Strategy:       req.Strategy,
+		CreatedBy:      userID,
+	}
+
+	if err := routingRuleRepo.Create(&rule); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create routing rule"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Routing rule created successfully",
+		"rule":    rule,
+	})
+}
+
+// GetRoutingRules returns the organization's routing rules in evaluation order
+func GetRoutingRules(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	rules, err := routingRuleRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch routing rules"})
+	}
+
+	return c.JSON(rules)
+}
+
+// UpdateRoutingRule updates a routing rule
+func UpdateRoutingRule(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	ruleID := c.Params("id")
+
+	rule, err := routingRuleRepo.FindByID(ruleID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Routing rule not found"})
+	}
+
+	var req struct {
+		Name          *string                      `json:"name"`
+		Priority      *int                         `json:"priority"`
+		IsActive      *bool                        `json:"is_active"`
+		Locations     *datatypes.JSONSlice[string] `json:"locations"`
+		PropertyTypes *datatypes.JSONSlice[string] `json:"property_types"`
+		BudgetMin     *float64                     `json:"budget_min"`
+		BudgetMax     *float64                     `json:"budget_max"`
+		ClearBudget   bool                         `json:"clear_budget"`
+		AgentIDs      *datatypes.JSONSlice[string] `json:"agent_ids"`
+		Strategy      *string                      `json:"strategy"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Name cannot be empty"})
+		}
+		rule.Name = name
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	if req.Locations != nil {
+		rule.Locations = *req.Locations
+	}
+	if req.PropertyTypes != nil {
+		rule.PropertyTypes = *req.PropertyTypes
+	}
+	if req.ClearBudget {
+		rule.BudgetMin = nil
+		rule.BudgetMax = nil
+	}
+	if req.BudgetMin != nil {
+		rule.BudgetMin = req.BudgetMin
+	}
+	if req.BudgetMax != nil {
+		rule.BudgetMax = req.BudgetMax
+	}
+	if rule.BudgetMin != nil && rule.BudgetMax != nil && *rule.BudgetMin > *rule.BudgetMax {
+		return c.Status(400).JSON(fiber.Map{"error": "budget_min cannot be greater than budget_max"})
+	}
+	if req.AgentIDs != nil {
+		if len(*req.AgentIDs) == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "agent_ids cannot be empty"})
+		}
+		if err := validateRoutingAgents(orgID, *req.AgentIDs); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		rule.AgentIDs = *req.AgentIDs
+	}
+	if req.Strategy != nil {
+		if !services.IsValidRoutingStrategy(*req.Strategy) {
+			return c.Status(400).JSON(fiber.Map{"error": "strategy must be 'round_robin' or 'least_loaded'"})
+		}
+		rule.Strategy = *req.Strategy
+	}
+
+	if err := routingRuleRepo.Update(rule); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update routing rule"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Routing rule updated successfully",
+		"rule":    rule,
+	})
+}
+
+// DeleteRoutingRule deletes a routing rule
+func DeleteRoutingRule(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	ruleID := c.Params("id")
+
+	if err := routingRuleRepo.Delete(ruleID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete routing rule"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Routing rule deleted successfully"})
+}
+
+// ApplyRoutingRules routes every currently unassigned contact through the rules
+func ApplyRoutingRules(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	assigned, err := leadRoutingService.RouteUnassigned(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to route contacts"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Routing rules applied",
+		"assigned": assigned,
+	})
+}
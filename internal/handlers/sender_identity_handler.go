@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var senderIdentityRepo = &repository.SenderIdentityRepository{}
+
+// CreateSenderIdentity registers an address and emails it a verification link
+func CreateSenderIdentity(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Email       string `json:"email"`
+		DisplayName string `json:"display_name"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	address, err := services.NormalizeEmailAddress(req.Email)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := services.ValidateFromName(req.DisplayName); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, err := senderIdentityRepo.FindByEmail(address, orgID); err == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Sender identity already exists"})
+	}
+
+	identity, err := senderService.Create(orgID, address, req.DisplayName, userID)
+	if identity == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create sender identity"})
+	}
+
+	response := fiber.Map{
+		"message":         "Sender identity created. Check the inbox to verify it.",
+		"sender_identity": identity,
+	}
+	if err != nil {
+		response["message"] = "Sender identity created, but the verification email could not be sent. Try resending it."
+	}
+	return c.Status(201).JSON(response)
+}
+
+// GetSenderIdentities returns the organization's sender identities
+func GetSenderIdentities(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	identities, err := senderIdentityRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch sender identities"})
+	}
+
+	return c.JSON(identities)
+}
+
+// ResendSenderVerification sends a new verification link for an unverified identity
+func ResendSenderVerification(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	identity, err := senderIdentityRepo.FindByID(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sender identity not found"})
+	}
+	if identity.VerifiedAt != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Sender identity is already verified"})
+	}
+
+	if err := senderService.SendVerification(identity); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to send verification email"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Verification email sent"})
+}
+
+// DeleteSenderIdentity deletes a sender identity. Templates still using it stop sending.
+func DeleteSenderIdentity(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	identity, err := senderIdentityRepo.FindByID(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sender identity not found"})
+	}
+
+	if err := senderIdentityRepo.Delete(identity.ID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete sender identity"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Sender identity deleted successfully"})
+}
+
+// VerifySenderIdentity confirms an address from the link in the verification email (public)
+func VerifySenderIdentity(c *fiber.Ctx) error {
+	identity, err := senderService.Verify(c.Params("token"))
+	if errors.Is(err, services.ErrVerificationExpired) {
+		return c.Status(400).JSON(fiber.Map{"error": "Verification link has expired. Ask your admin to resend it."})
+	}
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Invalid verification link"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Email address verified",
+		"email":   identity.Email,
+	})
+}
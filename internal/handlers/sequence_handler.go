@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+)
+
+// Sequences send without review, so when an organization requires campaign approval only
+// org admins may activate them, change active ones or enroll contacts
+const sequenceApprovalError = "Only org admins can activate, change or enroll sequences while campaigns require approval"
+
+var (
+	sequenceRepo        = &repository.SequenceRepository{}
+	enrollmentRepo      = &repository.SequenceEnrollmentRepository{}
+	sequenceMessageRepo = &repository.SequenceMessageRepository{}
+	sequenceService     = services.NewSequenceService()
+)
+
+// sequenceStepRequest is one step in a create or update request; steps run in array order
+type sequenceStepRequest struct {
+	Type        string  `json:"type"`
+	TemplateID  *string `json:"template_id"`
+	WaitDays    int     `json:"wait_days"`
+	Condition   string  `json:"condition"`
+	YesStep     int     `json:"yes_step"`
+	NoStep      int     `json:"no_step"`
+	AudienceID  *string `json:"audience_id"`
+	TaskTitle   string  `json:"task_title"`
+	TaskDueDays int     `json:"task_due_days"`
+	StageID     *string `json:"stage_id"`
+}
+
+func sequenceStepsFromRequest(req []sequenceStepRequest) []models.SequenceStep {
+	steps := make([]models.SequenceStep, len(req))
+	for i, r := range req {
+		steps[i] = models.SequenceStep{
+			Type:        r.Type,
+			TemplateID:  r.TemplateID,
+			WaitDays:    r.WaitDays,
+			Condition:   r.Condition,
+			YesStep:     r.YesStep,
+			NoStep:      r.NoStep,
+			AudienceID:  r.AudienceID,
+			TaskTitle:   r.TaskTitle,
+			TaskDueDays: r.TaskDueDays,
+			StageID:     r.StageID,
+		}
+	}
+	return steps
+}
+
+// CreateSequence creates a draft sequence with its steps
+func CreateSequence(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Name              string                `json:"name"`
+		Description       string                `json:"description"`
+		TriggerType       string                `json:"trigger_type"` // audience_added | contact_created | stage_changed
+		TriggerAudienceID *string               `json:"trigger_audience_id"`
+		TriggerStageID    *string               `json:"trigger_stage_id"`
+		ExitConditions    []string              `json:"exit_conditions"` // unsubscribed | replied | deal_closed
+		Steps             []sequenceStepRequest `json:"steps"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	sequence := models.Sequence{
+		OrganizationID:    orgID,
+		Name:              req.Name,
+		Description:       req.Description,
+		Status:            "draft",
+		TriggerType:       req.TriggerType,
+		TriggerAudienceID: req.TriggerAudienceID,
+		TriggerStageID:    req.TriggerStageID,
+		ExitConditions:    datatypes.JSONSlice[string](req.ExitConditions),
+		CreatedBy:         userID,
+	}
+	if sequence.ExitConditions == nil {
+		sequence.ExitConditions = datatypes.JSONSlice[string]{}
+	}
+	steps := sequenceStepsFromRequest(req.Steps)
+
+	if err := services.ValidateSequenceSettings(&sequence); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := services.ValidateSequenceSteps(steps); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := sequenceService.CheckReferences(orgID, &sequence, steps); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := sequenceRepo.Create(&sequence, steps); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create sequence"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":  "Sequence created successfully",
+		"sequence": sequence,
+		"steps":    steps,
+	})
+}
+
+// GetSequences returns all sequences for the organization
+func GetSequences(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	sequences, err := sequenceRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch sequences"})
+	}
+
+	return c.JSON(fiber.Map{"sequences": sequences})
+}
+
+// GetSequenceByID returns a sequence with its steps, enrollment counts and email engagement
+func GetSequenceByID(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	sequenceID := c.Params("id")
+
+	sequence, err := sequenceRepo.FindByID(sequenceID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sequence not found"})
+	}
+
+	steps, err := sequenceRepo.FindSteps(sequence.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch sequence steps"})
+	}
+
+	enrollmentStats, _ := enrollmentRepo.CountByStatus(sequence.ID)
+	emailStats, _ := sequenceMessageRepo.CountEngagementBySequence(sequence.ID)
+
+	return c.JSON(fiber.Map{
+		"sequence":         sequence,
+		"steps":            steps,
+		"enrollment_stats": enrollmentStats,
+		"email_stats":      emailStats,
+	})
+}
+
+// UpdateSequence updates a sequence. Steps can only be replaced while no contact is
+// part-way through the sequence, since enrollments track their position by step number.
+func UpdateSequence(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	sequenceID := c.Params("id")
+
+	sequence, err := sequenceRepo.FindByID(sequenceID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sequence not found"})
+	}
+
+	var req struct {
+		Name              *string                `json:"name"`
+		Description       *string                `json:"description"`
+		TriggerType       *string                `json:"trigger_type"`
+		TriggerAudienceID *string                `json:"trigger_audience_id"`
+		TriggerStageID    *string                `json:"trigger_stage_id"`
+		ExitConditions    *[]string              `json:"exit_conditions"`
+		Steps             *[]sequenceStepRequest `json:"steps"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if sequence.Status == "active" {
+		needsApproval, err := campaignNeedsApproval(c, orgID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+		}
+		if needsApproval {
+			return c.Status(403).JSON(fiber.Map{"error": sequenceApprovalError})
+		}
+	}
+
+	if req.Name != nil {
+		sequence.Name = *req.Name
+	}
+	if req.Description != nil {
+		sequence.Description = *req.Description
+	}
+	if req.TriggerType != nil {
+		sequence.TriggerType = *req.TriggerType
+	}
+	if req.TriggerAudienceID != nil {
+		sequence.TriggerAudienceID = req.TriggerAudienceID
+	}
+	if req.TriggerStageID != nil {
+		sequence.TriggerStageID = req.TriggerStageID
+	}
+	if req.ExitConditions != nil {
+		sequence.ExitConditions = datatypes.JSONSlice[string](*req.ExitConditions)
+	}
+
+	if err := services.ValidateSequenceSettings(sequence); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var steps []models.SequenceStep
+	if req.Steps != nil {
+		counts, err := enrollmentRepo.CountByStatus(sequence.ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to check enrollments"})
+		}
+		if counts["active"] > 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Cannot change steps while contacts are active in the sequence"})
+		}
+
+		steps = sequenceStepsFromRequest(*req.Steps)
+		if err := services.ValidateSequenceSteps(steps); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if err := sequenceService.CheckReferences(orgID, sequence, steps); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := sequenceRepo.Update(sequence); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update sequence"})
+	}
+	if req.Steps != nil {
+		if err := sequenceRepo.ReplaceSteps(sequence.ID, steps); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to update sequence steps"})
+		}
+	} else {
+		steps, _ = sequenceRepo.FindSteps(sequence.ID)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Sequence updated successfully",
+		"sequence": sequence,
+		"steps":    steps,
+	})
+}
+
+// DeleteSequence deletes a sequence along with its enrollments
+func DeleteSequence(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	sequenceID := c.Params("id")
+
+	if err := sequenceRepo.Delete(sequenceID, orgID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sequence not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Sequence deleted successfully"})
+}
+
+// ActivateSequence starts running a sequence. Its trigger only enrolls contacts for events
+// from now on.
+func ActivateSequence(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	sequenceID := c.Params("id")
+
+	sequence, err := sequenceRepo.FindByID(sequenceID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sequence not found"})
+	}
+
+	if sequence.Status == "active" {
+		return c.Status(400).JSON(fiber.Map{"error": "Sequence is already active"})
+	}
+	needsApproval, err := campaignNeedsApproval(c, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+	}
+	if needsApproval {
+		return c.Status(403).JSON(fiber.Map{"error": sequenceApprovalError})
+	}
+
+	steps, err := sequenceRepo.FindSteps(sequence.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch sequence steps"})
+	}
+	if err := services.ValidateSequenceSteps(steps); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	now := time.Now()
+	sequence.Status = "active"
+	sequence.TriggerCheckedAt = &now
+	if err := sequenceRepo.Update(sequence); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to activate sequence"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Sequence activated successfully", "sequence": sequence})
+}
+
+// PauseSequence stops a sequence's trigger and holds its enrollments at their current step
+func PauseSequence(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	sequenceID := c.Params("id")
+
+	sequence, err := sequenceRepo.FindByID(sequenceID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sequence not found"})
+	}
+
+	if sequence.Status != "active" {
+		return c.Status(400).JSON(fiber.Map{"error": "Can only pause active sequences"})
+	}
+
+	sequence.Status = "paused"
+	if err := sequenceRepo.Update(sequence); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to pause sequence"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Sequence paused successfully", "sequence": sequence})
+}
+
+// EnrollSequenceContacts enrolls contacts in a sequence by hand. Contacts that were enrolled
+// before are skipped.
+func EnrollSequenceContacts(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	sequenceID := c.Params("id")
+
+	sequence, err := sequenceRepo.FindByID(sequenceID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sequence not found"})
+	}
+
+	var req struct {
+		ContactIDs []string `json:"contact_ids"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if len(req.ContactIDs) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "contact_ids is required"})
+	}
+	needsApproval, err := campaignNeedsApproval(c, orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+	}
+	if needsApproval {
+		return c.Status(403).JSON(fiber.Map{"error": sequenceApprovalError})
+	}
+
+	enrolled, err := sequenceService.Enroll(sequence, req.ContactIDs)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to enroll contacts"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Contacts enrolled successfully",
+		"enrolled": enrolled,
+		"skipped":  len(req.ContactIDs) - enrolled,
+	})
+}
+
+// GetSequenceEnrollments returns a sequence's enrollments, optionally filtered by status
+func GetSequenceEnrollments(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	sequenceID := c.Params("id")
+
+	sequence, err := sequenceRepo.FindByID(sequenceID, orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Sequence not found"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	enrollments, total, err := enrollmentRepo.FindBySequence(sequence.ID, c.Query("status", ""), page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch enrollments"})
+	}
+
+	return c.JSON(fiber.Map{
+		"enrollments": enrollments,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// ExitSequenceEnrollment removes a contact from a sequence before its last step
+func ExitSequenceEnrollment(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	enrollment, err := enrollmentRepo.FindByID(c.Params("enrollmentId"), orgID)
+	if err != nil || enrollment.SequenceID != c.Params("id") {
+		return c.Status(404).JSON(fiber.Map{"error": "Enrollment not found"})
+	}
+
+	if enrollment.Status != "active" {
+		return c.Status(400).JSON(fiber.Map{"error": "Enrollment is not active"})
+	}
+
+	now := time.Now()
+	enrollment.Status = "exited"
+	enrollment.ExitReason = "removed"
+	enrollment.CompletedAt = &now
+	if err := enrollmentRepo.Update(enrollment); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to remove contact from sequence"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Contact removed from sequence", "enrollment": enrollment})
+}
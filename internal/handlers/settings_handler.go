@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var settingsService = services.NewOrganizationSettingsService()
+
+// GetOrganizationSettings returns the organization's settings
+func GetOrganizationSettings(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	settings, err := settingsService.GetSettings(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+	}
+
+	return c.JSON(settings)
+}
+
+// UpdateOrganizationSettings updates the organization's settings
+func UpdateOrganizationSettings(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	settings, err := settingsService.GetSettings(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+	}
+
+	var req struct {
+		FollowUpAfterDays       *int    `json:"follow_up_after_days"` // 0 disables automatic follow-ups
+		BrandLogoURL            *string `json:"brand_logo_url"`
+		BrandPrimaryColor       *string `json:"brand_primary_color"`
+		BrandOfficeAddress      *string `json:"brand_office_address"`
+		RequireCampaignApproval *bool   `json:"require_campaign_approval"`
+		CampaignFrequencyCap    *int    `json:"campaign_frequency_cap"` // 0 disables the cap
+		Timezone                *string `json:"timezone"`
+		QuietHoursStart         *string `json:"quiet_hours_start"` // "HH:MM"; empty turns quiet hours off
+		QuietHoursEnd           *string `json:"quiet_hours_end"`
+		CampaignSendDays        *[]int  `json:"campaign_send_days"` // 0 = Sunday; empty allows every day
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.FollowUpAfterDays != nil {
+		if *req.FollowUpAfterDays < 0 || *req.FollowUpAfterDays > 365 {
+			return c.Status(400).JSON(fiber.Map{"error": "follow_up_after_days must be between 0 and 365"})
+		}
+		settings.FollowUpAfterDays = *req.FollowUpAfterDays
+	}
+	if req.BrandLogoURL != nil {
+		logoURL := strings.TrimSpace(*req.BrandLogoURL)
+		if err := services.ValidateBrandLogoURL(logoURL); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		settings.BrandLogoURL = logoURL
+	}
+	if req.BrandPrimaryColor != nil {
+		color, err := services.NormalizeBrandColor(*req.BrandPrimaryColor)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		settings.BrandPrimaryColor = color
+	}
+	if req.BrandOfficeAddress != nil {
+		if len(*req.BrandOfficeAddress) > 500 {
+			return c.Status(400).JSON(fiber.Map{"error": "brand_office_address must be at most 500 characters"})
+		}
+		settings.BrandOfficeAddress = strings.TrimSpace(*req.BrandOfficeAddress)
+	}
+	if req.RequireCampaignApproval != nil {
+		settings.RequireCampaignApproval = *req.RequireCampaignApproval
+	}
+	if req.CampaignFrequencyCap != nil {
+		if *req.CampaignFrequencyCap < 0 || *req.CampaignFrequencyCap > 100 {
+			return c.Status(400).JSON(fiber.Map{"error": "campaign_frequency_cap must be between 0 and 100"})
+		}
+		settings.CampaignFrequencyCap = *req.CampaignFrequencyCap
+	}
+	if req.Timezone != nil {
+		settings.Timezone = *req.Timezone
+	}
+	if req.QuietHoursStart != nil {
+		settings.QuietHoursStart = *req.QuietHoursStart
+	}
+	if req.QuietHoursEnd != nil {
+		settings.QuietHoursEnd = *req.QuietHoursEnd
+	}
+	if req.CampaignSendDays != nil {
+		settings.CampaignSendDays = *req.CampaignSendDays
+	}
+	if err := services.ValidateSendWindow(settings); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := settingsService.SaveSettings(settings); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update settings"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Settings updated successfully",
+		"settings": settings,
+	})
+}
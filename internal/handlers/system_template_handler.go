@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	systemTemplateRepo    = &repository.SystemTemplateRepository{}
+	systemTemplateService = services.NewSystemTemplateService()
+)
+
+var slugUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+type systemTemplateRequest struct {
+	Slug          *string         `json:"slug"`
+	Category      *string         `json:"category"`
+	Name          *string         `json:"name"`
+	Description   *string         `json:"description"`
+	Subject       *string         `json:"subject"`
+	Preheader     *string         `json:"preheader"`
+	HtmlBody      *string         `json:"html_body"`
+	PlainTextBody *string         `json:"plain_text_body"`
+	EditorType    *string         `json:"editor_type"`
+	BuilderBlocks json.RawMessage `json:"builder_blocks"`
+	IsPublished   *bool           `json:"is_published"`
+}
+
+// applySystemTemplateRequest copies the request onto the system template, compiling blocks
+// or sanitizing HTML the same way organization templates are saved. Errors are client errors.
+func applySystemTemplateRequest(st *models.SystemTemplate, req *systemTemplateRequest) (services.SanitizeReport, error) {
+	sanitized := services.SanitizeReport{RemovedElements: map[string]int{}, RemovedAttributes: map[string]int{}}
+
+	if req.Slug != nil {
+		st.Slug = strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(*req.Slug), "-"), "-")
+	}
+	if req.Category != nil {
+		st.Category = *req.Category
+	}
+	if req.Name != nil {
+		st.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		st.Description = *req.Description
+	}
+	if req.Subject != nil {
+		st.Subject = *req.Subject
+	}
+	if req.Preheader != nil {
+		st.Preheader = *req.Preheader
+	}
+	if req.IsPublished != nil {
+		st.IsPublished = *req.IsPublished
+	}
+
+	if st.Slug == "" {
+		st.Slug = strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(st.Name), "-"), "-")
+	}
+	if st.Name == "" || st.Subject == "" || st.Slug == "" {
+		return sanitized, errors.New("Name and subject are required")
+	}
+	if !services.SystemTemplateCategories[st.Category] {
+		return sanitized, errors.New("category must be one of new_listing, price_drop, open_house, market_update, anniversary, general")
+	}
+
+	content := services.SystemTemplateContent(st)
+	if req.EditorType != nil {
+		content.EditorType = *req.EditorType
+	} else if len(req.BuilderBlocks) > 0 {
+		content.EditorType = services.EditorTypeBlocks
+	}
+	if content.EditorType == "" {
+		content.EditorType = services.EditorTypeHTML
+	}
+
+	switch content.EditorType {
+	case services.EditorTypeBlocks:
+		if req.HtmlBody != nil || req.PlainTextBody != nil {
+			return sanitized, errors.New("Block templates generate html_body and plain_text_body from builder_blocks")
+		}
+		if len(req.BuilderBlocks) > 0 {
+			if err := services.ApplyBlocks(content, req.BuilderBlocks); err != nil {
+				return sanitized, errors.New("Invalid builder_blocks: " + err.Error())
+			}
+		} else if len(content.BuilderBlocks) == 0 {
+			return sanitized, errors.New("builder_blocks is required for block templates")
+		}
+	case services.EditorTypeHTML:
+		if len(req.BuilderBlocks) > 0 {
+			return sanitized, errors.New("builder_blocks requires editor_type blocks")
+		}
+		content.BuilderBlocks = nil
+		if req.HtmlBody != nil {
+			content.HtmlBody, sanitized = services.SanitizeEmailHTML(*req.HtmlBody)
+		}
+		if req.PlainTextBody != nil {
+			content.PlainTextBody = *req.PlainTextBody
+		}
+		if content.HtmlBody == "" {
+			return sanitized, errors.New("html_body is required")
+		}
+	default:
+		return sanitized, errors.New("editor_type must be html or blocks")
+	}
+
+	if err := services.ValidateEmailTemplate(content); err != nil {
+		return sanitized, errors.New("Invalid template: " + err.Error())
+	}
+
+	st.EditorType = content.EditorType
+	st.BuilderBlocks = content.BuilderBlocks
+	st.HtmlBody = content.HtmlBody
+	st.PlainTextBody = content.PlainTextBody
+	return sanitized, nil
+}
+
+// CreateSystemTemplate adds a template to the global library
+func CreateSystemTemplate(c *fiber.Ctx) error {
+	var req systemTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	var template models.SystemTemplate
+	sanitized, err := applySystemTemplateRequest(&template, &req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, err := systemTemplateRepo.FindBySlug(template.Slug); err == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "A system template with this slug already exists"})
+	}
+
+	if err := systemTemplateRepo.Create(&template); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create system template"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":   "System template created successfully",
+		"template":  template,
+		"warnings":  services.LintEmailTemplate(services.SystemTemplateContent(&template), nil),
+		"sanitized": sanitized,
+	})
+}
+
+// GetSystemTemplates returns every system template, published or not
+func GetSystemTemplates(c *fiber.Ctx) error {
+	templates, err := systemTemplateRepo.FindAll(false, c.Query("category"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch system templates"})
+	}
+	return c.JSON(templates)
+}
+
+// GetSystemTemplateByID returns a single system template
+func GetSystemTemplateByID(c *fiber.Ctx) error {
+	template, err := systemTemplateRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "System template not found"})
+	}
+	return c.JSON(template)
+}
+
+// UpdateSystemTemplate updates a system template. Organizations that already cloned it keep
+// their copy.
+func UpdateSystemTemplate(c *fiber.Ctx) error {
+	template, err := systemTemplateRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "System template not found"})
+	}
+
+	var req systemTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	previousSlug := template.Slug
+	sanitized, err := applySystemTemplateRequest(template, &req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if template.Slug != previousSlug {
+		if _, err := systemTemplateRepo.FindBySlug(template.Slug); err == nil {
+			return c.Status(400).JSON(fiber.Map{"error": "A system template with this slug already exists"})
+		}
+	}
+
+	if err := systemTemplateRepo.Update(template); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update system template"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "System template updated successfully",
+		"template":  template,
+		"warnings":  services.LintEmailTemplate(services.SystemTemplateContent(template), nil),
+		"sanitized": sanitized,
+	})
+}
+
+// DeleteSystemTemplate removes a template from the library
+func DeleteSystemTemplate(c *fiber.Ctx) error {
+	if _, err := systemTemplateRepo.FindByID(c.Params("id")); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "System template not found"})
+	}
+
+	if err := systemTemplateRepo.Delete(c.Params("id")); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete system template"})
+	}
+
+	return c.JSON(fiber.Map{"message": "System template deleted successfully"})
+}
+
+// GetTemplateLibrary returns the published system templates organizations can clone
+func GetTemplateLibrary(c *fiber.Ctx) error {
+	templates, err := systemTemplateRepo.FindAll(true, c.Query("category"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch template library"})
+	}
+	return c.JSON(templates)
+}
+
+// GetTemplateLibraryItem returns a published system template
+func GetTemplateLibraryItem(c *fiber.Ctx) error {
+	template, err := systemTemplateRepo.FindPublishedByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Template not found in library"})
+	}
+	return c.JSON(template)
+}
+
+// CloneTemplateLibraryItem copies a published system template into the organization's
+// email templates
+func CloneTemplateLibraryItem(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	source, err := systemTemplateRepo.FindPublishedByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Template not found in library"})
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	template, err := systemTemplateService.Clone(source, orgID, userID, strings.TrimSpace(req.Name))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to clone template"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":  "Template cloned successfully",
+		"template": template,
+		"warnings": templateWarnings(orgID, template),
+	})
+}
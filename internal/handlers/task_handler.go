@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+var taskRepo = &repository.TaskRepository{}
+
+// isValidTaskPriority checks a task priority value
+func isValidTaskPriority(priority string) bool {
+	return priority == "low" || priority == "medium" || priority == "high"
+}
+
+// isValidTaskStatus checks a task status value
+func isValidTaskStatus(status string) bool {
+	return status == "open" || status == "completed" || status == "cancelled"
+}
+
+// canAccessTask reports whether a user may view or change a task.
+// Org admins see every task; agents only tasks assigned to or created by them.
+func canAccessTask(role, userID string, task *models.Task) bool {
+	return role == "org_admin" || task.AssignedTo == userID || task.CreatedBy == userID
+}
+
+// setTaskStatus changes a task's status and keeps CompletedAt in step
+func setTaskStatus(task *models.Task, status string) {
+	if status == "completed" && task.Status != "completed" {
+		now := time.Now()
+		task.CompletedAt = &now
+	} else if status != "completed" {
+		task.CompletedAt = nil
+	}
+	task.Status = status
+}
+
+// CreateTask creates a new task
+func CreateTask(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Title           string    `json:"title"`
+		Description     string    `json:"description"`
+		DueAt           time.Time `json:"due_at"`
+		AssignedTo      string    `json:"assigned_to"`
+		ContactID       *string   `json:"contact_id"`
+		PropertyAddress string    `json:"property_address"`
+		Priority        string    `json:"priority"` // low | medium | high
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" || req.DueAt.IsZero() {
+		return c.Status(400).JSON(fiber.Map{"error": "Title and due_at are required"})
+	}
+
+	if req.Priority == "" {
+		req.Priority = "medium"
+	}
+	if !isValidTaskPriority(req.Priority) {
+		return c.Status(400).JSON(fiber.Map{"error": "priority must be 'low', 'medium', or 'high'"})
+	}
+
+	// Tasks default to the creator
+	if req.AssignedTo == "" {
+		req.AssignedTo = userID
+	} else if req.AssignedTo != userID {
+		if err := contactService.ValidateAssignee(orgID, req.AssignedTo); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if req.ContactID != nil && *req.ContactID != "" {
+		if _, err := contactRepo.FindByID(*req.ContactID, orgID); err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+		}
+	} else {
+		req.ContactID = nil
+	}
+
+	task := models.Task{
+		OrganizationID:  orgID,
+		AssignedTo:      req.AssignedTo,
+		ContactID:       req.ContactID,
+		PropertyAddress: req.PropertyAddress,
+		Title:           req.Title,
+		Description:     req.Description,
+		DueAt:           req.DueAt,
+		Priority:        req.Priority,
+		Status:          "open",
+		CreatedBy:       userID,
+	}
+
+	if err := taskRepo.Create(&task); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create task"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Task created successfully",
+		"task":    task,
+	})
+}
+
+// GetTasks returns paginated tasks, soonest due first
+func GetTasks(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := repository.TaskFilter{
+		AssignedTo: c.Query("assigned_to", ""),
+		ContactID:  c.Query("contact_id", ""),
+		Status:     c.Query("status", ""),
+	}
+
+	// Agents only see their own tasks; admins may filter by agent
+	if role != "org_admin" || filter.AssignedTo == "me" {
+		filter.AssignedTo = userID
+	}
+
+	if dueBefore := c.Query("due_before", ""); dueBefore != "" {
+		t, err := time.Parse(time.RFC3339, dueBefore)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "due_before must be an RFC3339 timestamp"})
+		}
+		filter.DueBefore = &t
+	}
+	if dueAfter := c.Query("due_after", ""); dueAfter != "" {
+		t, err := time.Parse(time.RFC3339, dueAfter)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "due_after must be an RFC3339 timestamp"})
+		}
+		filter.DueAfter = &t
+	}
+
+	tasks, total, err := taskRepo.FindAllByOrg(orgID, filter, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tasks"})
+	}
+
+	return c.JSON(fiber.Map{
+		"tasks": tasks,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetTaskByID returns a single task
+func GetTaskByID(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	taskID := c.Params("id")
+
+	task, err := taskRepo.FindByID(taskID, orgID)
+	if err != nil || !canAccessTask(role, userID, task) {
+		return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	return c.JSON(task)
+}
+
+// UpdateTask updates a task
+func UpdateTask(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	taskID := c.Params("id")
+
+	task, err := taskRepo.FindByID(taskID, orgID)
+	if err != nil || !canAccessTask(role, userID, task) {
+		return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	var req struct {
+		Title           *string    `json:"title"`
+		Description     *string    `json:"description"`
+		DueAt           *time.Time `json:"due_at"`
+		AssignedTo      *string    `json:"assigned_to"`
+		ContactID       *string    `json:"contact_id"`
+		PropertyAddress *string    `json:"property_address"`
+		Priority        *string    `json:"priority"`
+		Status          *string    `json:"status"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Title cannot be empty"})
+		}
+		task.Title = title
+	}
+	if req.Description != nil {
+		task.Description = *req.Description
+	}
+	if req.DueAt != nil && !req.DueAt.Equal(task.DueAt) {
+		task.DueAt = *req.DueAt
+		// A rescheduled task gets fresh reminders
+		task.DueNotifiedAt = nil
+		task.OverdueNotifiedAt = nil
+	}
+	if req.AssignedTo != nil && *req.AssignedTo != task.AssignedTo {
+		if err := contactService.ValidateAssignee(orgID, *req.AssignedTo); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		task.AssignedTo = *req.AssignedTo
+	}
+	if req.ContactID != nil {
+		if *req.ContactID == "" {
+			task.ContactID = nil
+		} else {
+			if _, err := contactRepo.FindByID(*req.ContactID, orgID); err != nil {
+				return c.Status(404).JSON(fiber.Map{"error": "Contact not found"})
+			}
+			task.ContactID = req.ContactID
+		}
+	}
+	if req.PropertyAddress != nil {
+		task.PropertyAddress = *req.PropertyAddress
+	}
+	if req.Priority != nil {
+		if !isValidTaskPriority(*req.Priority) {
+			return c.Status(400).JSON(fiber.Map{"error": "priority must be 'low', 'medium', or 'high'"})
+		}
+		task.Priority = *req.Priority
+	}
+	if req.Status != nil {
+		if !isValidTaskStatus(*req.Status) {
+			return c.Status(400).JSON(fiber.Map{"error": "status must be 'open', 'completed', or 'cancelled'"})
+		}
+		setTaskStatus(task, *req.Status)
+	}
+
+	if err := taskRepo.Update(task); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update task"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Task updated successfully",
+		"task":    task,
+	})
+}
+
+// CompleteTask marks a task as completed
+func CompleteTask(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	taskID := c.Params("id")
+
+	task, err := taskRepo.FindByID(taskID, orgID)
+	if err != nil || !canAccessTask(role, userID, task) {
+		return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	setTaskStatus(task, "completed")
+
+	if err := taskRepo.Update(task); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to complete task"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Task completed successfully",
+		"task":    task,
+	})
+}
+
+// DeleteTask deletes a task
+func DeleteTask(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+	role := c.Locals("role").(string)
+	taskID := c.Params("id")
+
+	task, err := taskRepo.FindByID(taskID, orgID)
+	if err != nil || !canAccessTask(role, userID, task) {
+		return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	if err := taskRepo.Delete(task.ID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete task"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Task deleted successfully"})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	partialRepo    = &repository.TemplatePartialRepository{}
+	partialService = services.NewTemplatePartialService()
+)
+
+// CreateTemplatePartial creates a named partial that templates include with {{> name}}
+func CreateTemplatePartial(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Name          string `json:"name"`
+		Description   string `json:"description"`
+		HtmlBody      string `json:"html_body"`
+		PlainTextBody string `json:"plain_text_body"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || req.HtmlBody == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Name and html_body are required"})
+	}
+	if _, err := partialRepo.FindByName(req.Name, orgID); err == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "A partial with this name already exists"})
+	}
+
+	htmlBody, sanitized := services.SanitizeEmailHTML(req.HtmlBody)
+	partial := models.TemplatePartial{
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Description:    req.Description,
+		HtmlBody:       htmlBody,
+		PlainTextBody:  req.PlainTextBody,
+		CreatedBy:      userID,
+	}
+
+	if err := partialService.ValidateChange(&partial); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid partial: " + err.Error()})
+	}
+
+	if err := partialRepo.Create(&partial); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create partial"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":   "Partial created successfully",
+		"partial":   partial,
+		"sanitized": sanitized,
+	})
+}
+
+// GetTemplatePartials returns the organization's partials
+func GetTemplatePartials(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	partials, err := partialRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch partials"})
+	}
+
+	return c.JSON(partials)
+}
+
+// GetTemplatePartialByID returns a single partial
+func GetTemplatePartialByID(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	partial, err := partialRepo.FindByID(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Partial not found"})
+	}
+
+	return c.JSON(partial)
+}
+
+// UpdateTemplatePartial updates a partial. Every template that includes it picks up the
+// change the next time it renders.
+func UpdateTemplatePartial(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	partial, err := partialRepo.FindByID(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Partial not found"})
+	}
+
+	var req struct {
+		Name          *string `json:"name"`
+		Description   *string `json:"description"`
+		HtmlBody      *string `json:"html_body"`
+		PlainTextBody *string `json:"plain_text_body"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Name != nil && strings.TrimSpace(*req.Name) != partial.Name {
+		name := strings.TrimSpace(*req.Name)
+		if _, err := partialRepo.FindByName(name, orgID); err == nil {
+			return c.Status(400).JSON(fiber.Map{"error": "A partial with this name already exists"})
+		}
+		// Renaming would silently empty the include in every template that uses it
+		usedBy, err := partialService.UsedBy(orgID, partial.Name)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to check partial usage"})
+		}
+		if len(usedBy) > 0 {
+			return c.Status(409).JSON(fiber.Map{"error": "Partial is in use and cannot be renamed", "used_by": usedBy})
+		}
+		partial.Name = name
+	}
+	if req.Description != nil {
+		partial.Description = *req.Description
+	}
+	sanitized := services.SanitizeReport{RemovedElements: map[string]int{}, RemovedAttributes: map[string]int{}}
+	if req.HtmlBody != nil {
+		if *req.HtmlBody == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "html_body cannot be empty"})
+		}
+		partial.HtmlBody, sanitized = services.SanitizeEmailHTML(*req.HtmlBody)
+	}
+	if req.PlainTextBody != nil {
+		partial.PlainTextBody = *req.PlainTextBody
+	}
+
+	if err := partialService.ValidateChange(partial); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid partial: " + err.Error()})
+	}
+
+	if err := partialRepo.Update(partial); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update partial"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "Partial updated successfully",
+		"partial":   partial,
+		"sanitized": sanitized,
+	})
+}
+
+// DeleteTemplatePartial deletes a partial that no template or partial includes
+func DeleteTemplatePartial(c *fiber.Ctx) error {
+	orgID := c.Locals("org_id").(string)
+
+	partial, err := partialRepo.FindByID(c.Params("id"), orgID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Partial not found"})
+	}
+
+	usedBy, err := partialService.UsedBy(orgID, partial.Name)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check partial usage"})
+	}
+	if len(usedBy) > 0 {
+		return c.Status(409).JSON(fiber.Map{"error": "Partial is in use and cannot be deleted", "used_by": usedBy})
+	}
+
+	if err := partialRepo.Delete(partial.ID, orgID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete partial"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Partial deleted successfully"})
+}
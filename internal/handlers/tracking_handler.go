@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// trackingPixel is a transparent 1x1 GIF
+var trackingPixel, _ = base64.StdEncoding.DecodeString("R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7")
+
+// TrackCampaignOpen records that a campaign or sequence email was opened and serves the
+// tracking pixel. Invalid tokens still get the pixel so mail clients don't show a broken image.
+func TrackCampaignOpen(c *fiber.Ctx) error {
+	if logID, ok := services.ParseOpenTrackingToken(c.Params("token")); ok {
+		// The token carries a campaign log or sequence message ID; only one of them matches
+		campaignLogRepo.MarkOpened(logID, time.Now())
+		sequenceMessageRepo.MarkOpened(logID, time.Now())
+	}
+
+	c.Set("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.Type("gif")
+	return c.Send(trackingPixel)
+}
+
+// TrackCampaignClick records a link click in a campaign or sequence email and redirects to
+// the link
+func TrackCampaignClick(c *fiber.Ctx) error {
+	logID, target, ok := services.ParseClickTrackingToken(c.Params("token"))
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Link not valid"})
+	}
+
+	campaignLogRepo.MarkClicked(logID, time.Now())
+	sequenceMessageRepo.MarkClicked(logID, time.Now())
+
+	return c.Redirect(target, fiber.StatusFound)
+}
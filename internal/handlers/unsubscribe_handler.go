@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func unsubscribePage(title, body string) string {
+	return fmt.Sprintf(`<!DOCTYPE html><html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>%s</title></head>`+
+		`<body style="font-family:sans-serif;max-width:480px;margin:60px auto;padding:0 16px;text-align:center;"><h2>%s</h2>%s</body></html>`,
+		html.EscapeString(title), html.EscapeString(title), body)
+}
+
+// GetUnsubscribePage asks the recipient to confirm. Link scanners follow GET links in emails,
+// so only the POST below actually unsubscribes.
+func GetUnsubscribePage(c *fiber.Ctx) error {
+	token := c.Params("token")
+	if _, ok := services.ParseUnsubscribeToken(token); !ok {
+		c.Type("html")
+		return c.Status(404).SendString(unsubscribePage("Link not valid", "<p>This unsubscribe link is not valid.</p>"))
+	}
+
+	form := fmt.Sprintf(`<p>Stop receiving marketing emails from us?</p><form method="POST" action="%s"><button type="submit" style="padding:10px 24px;">Unsubscribe</button></form>`,
+		html.EscapeString("/public/unsubscribe/"+token))
+
+	c.Type("html")
+	return c.SendString(unsubscribePage("Unsubscribe", form))
+}
+
+// Unsubscribe opts a contact out of campaign emails. Also serves one-click
+// unsubscribes (List-Unsubscribe-Post) from inbox providers.
+func Unsubscribe(c *fiber.Ctx) error {
+	contactID, ok := services.ParseUnsubscribeToken(c.Params("token"))
+	if !ok {
+		c.Type("html")
+		return c.Status(404).SendString(unsubscribePage("Link not valid", "<p>This unsubscribe link is not valid.</p>"))
+	}
+
+	if _, err := contactRepo.Unsubscribe(contactID); err != nil {
+		c.Type("html")
+		return c.Status(404).SendString(unsubscribePage("Link not valid", "<p>This unsubscribe link is not valid.</p>"))
+	}
+
+	c.Type("html")
+	return c.SendString(unsubscribePage("You're unsubscribed", "<p>You will no longer receive marketing emails from us.</p>"))
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// UploadBodyLimit is the largest body a template attachment upload may send: the largest
+// attachment plus multipart overhead
+const UploadBodyLimit = services.MaxAttachmentSize + 1024*1024
+
+// BodyConfig streams request bodies, so nothing reads a body until a handler asks for it:
+// BodyLimit and each route's authentication run first. Multipart forms are not parsed ahead
+// of the handlers either, which would read the whole upload.
+var BodyConfig = fiber.Config{
+	StreamRequestBody:            true,
+	DisablePreParseMultipartForm: true,
+}
+
+// LargeBodyRoute is a route allowed a bigger request body than BodyLimit's default
+type LargeBodyRoute struct {
+	Method string
+	Path   string // a route pattern such as "/agent/templates/:id/attachments"
+	Limit  int
+}
+
+// BodyLimit turns away requests whose Content-Length is over limit, or over the limit of the
+// matching route in routes, before their body is read. Chunked bodies have no length to
+// check, so they are refused. A body left unread - say by a request that failed
+// authentication - can't be skipped to reach the next request, so the connection is closed
+// after replying.
+func BodyLimit(limit int, routes ...LargeBodyRoute) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		length := c.Request().Header.ContentLength()
+		if length == -1 {
+			c.Context().SetConnectionClose()
+			return c.Status(411).JSON(fiber.Map{"error": "Content-Length required"})
+		}
+
+		max := limit
+		for _, route := range routes {
+			if c.Method() == route.Method && matchesRoute(route.Path, c.Path()) {
+				max = route.Limit
+				break
+			}
+		}
+		if length > max {
+			c.Context().SetConnectionClose()
+			return c.Status(413).JSON(fiber.Map{"error": "Request body too large"})
+		}
+
+		err := c.Next()
+		if c.Request().IsBodyStream() {
+			c.Context().SetConnectionClose()
+		}
+		return err
+	}
+}
+
+// matchesRoute reports whether path matches a route pattern, where each :param matches one segment
+func matchesRoute(pattern, path string) bool {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i, segment := range want {
+		if strings.HasPrefix(segment, ":") {
+			if got[i] == "" {
+				return false
+			}
+			continue
+		}
+		if !strings.EqualFold(segment, got[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type Activity struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid"`
+	ContactID      string `gorm:"type:uuid;index"`
+	AuthorID       string `gorm:"type:uuid"`
+
+	Type    string // call | email | meeting | showing | note
+	Body    string
+	Outcome string
+
+	OccurredAt time.Time `gorm:"index"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (Activity) TableName() string {
+	return "activity"
+}
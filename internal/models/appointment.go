@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type Appointment struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid"`
+	AgentID        string `gorm:"type:uuid;index"`
+	ContactID      string `gorm:"type:uuid;index"`
+
+	// Free-text property reference until listings are modelled
+	PropertyAddress string
+
+	Title    string
+	Notes    string
+	Location string
+
+	StartAt time.Time `gorm:"index"`
+	EndAt   time.Time `gorm:"index"`
+
+	Status string `gorm:"default:'scheduled'"` // scheduled | confirmed | cancelled | no_show
+
+	ConfirmationSentAt *time.Time
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Appointment) TableName() string {
+	return "appointment"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type AudienceMembershipLog struct {
+	ID         string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	AudienceID string `gorm:"type:uuid;index"`
+	ContactID  string `gorm:"type:uuid;index"`
+
+	Action    string  // added | removed
+	ChangedBy *string `gorm:"type:uuid"`
+	CreatedAt time.Time
+}
+
+func (AudienceMembershipLog) TableName() string {
+	return "audience_membership_log"
+}
@@ -5,7 +5,7 @@ import "time"
 type BackgroundJobLog struct {
 	ID string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 
-	JobType        string  // csv_import | campaign_run | campaign_scheduler
+	JobType        string  // csv_import | campaign_run | campaign_winner | campaign_deferred | campaign_dry_run | campaign_scheduler
 	OrganizationID string  `gorm:"type:uuid"`
 	ReferenceID    *string `gorm:"type:uuid"`
 
@@ -13,9 +13,16 @@ type Campaign struct {
 	Name       string
 	TemplateID string `gorm:"type:uuid"`
 
+	// TemplateVersion pins the template content sent; FollowLatestTemplate sends the current content instead
+	TemplateVersion      *int
+	FollowLatestTemplate bool
+
 	AudienceIDs datatypes.JSONSlice[string] `gorm:"type:jsonb;default:'[]'"`
 	ContactID   *string                     `gorm:"type:uuid"`
 
+	// Contacts in these audiences are never sent the campaign, even if an included audience has them
+	ExcludedAudienceIDs datatypes.JSONSlice[string] `gorm:"type:jsonb;default:'[]'"`
+
 	ScheduleType string // once | recurring
 	ScheduledAt  time.Time
 
@@ -25,7 +32,23 @@ type Campaign struct {
 	RecurrenceTime       *time.Time
 	LastRunAt            *time.Time `gorm:"column:last_run_at"`
 
-	Status    string
+	// A/B testing: VariantMode is empty for a single template, "split" to divide recipients
+	// between CampaignVariants, or "test_winner" to send the variants to a TestPercent sample,
+	// wait TestDurationHours, then send the variant with the best WinnerMetric to the rest
+	VariantMode       string
+	TestPercent       int
+	TestDurationHours int
+	WinnerMetric      string // open | click
+	TestSentAt        *time.Time
+	WinnerVariantID   *string `gorm:"type:uuid"`
+
+	// Approval review by an org_admin, when the organization requires it
+	ReviewDecision string // approved | rejected
+	ReviewComment  string
+	ReviewedBy     *string `gorm:"type:uuid"`
+	ReviewedAt     *time.Time
+
+	Status    string // draft | pending_approval | rejected | scheduled | running | testing | paused | completed | failed
 	CreatedBy string `gorm:"type:uuid"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -9,11 +9,18 @@ type CampaignLog struct {
 
 	RecipientEmail string
 	Subject        string
+	Locale         string // locale variant sent; empty for the template default
 
-	Status       string // queued, sent, failed
-	ErrorMessage string
+	VariantID *string `gorm:"type:uuid;index"` // A/B variant sent, nil without variants
+
+	Status        string // queued, deferred, sent, failed, skipped
+	ErrorMessage  string
+	SkipReason    string     // frequency_cap
+	DeferredUntil *time.Time `gorm:"index"` // when an email held back by the send window goes out
 
 	SentAt    *time.Time
+	OpenedAt  *time.Time // first open seen by the tracking pixel
+	ClickedAt *time.Time // first tracked link click
 	CreatedAt time.Time
 }
 
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// CampaignPreviewMessage is one message of a campaign dry run
+type CampaignPreviewMessage struct {
+	ContactID      string     `json:"contact_id"`
+	RecipientEmail string     `json:"recipient_email"`
+	Subject        string     `json:"subject"`
+	FromName       string     `json:"from_name"`
+	ReplyTo        string     `json:"reply_to"`
+	Locale         string     `json:"locale"`
+	VariantID      *string    `json:"variant_id"`
+	Stage          string     `json:"stage"`  // test | winner for test-then-winner campaigns
+	Status         string     `json:"status"` // rendered, failed, skipped
+	SkipReason     string     `json:"skip_reason"`
+	ErrorMessage   string     `json:"error_message"`
+	DeferredUntil  *time.Time `json:"deferred_until"` // set when the send window would hold the email back
+}
+
+// CampaignPreview is the report of a campaign dry run: the messages the campaign would send
+// right now, rendered but not sent or written to campaign_log
+type CampaignPreview struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	CampaignID     string `gorm:"type:uuid;index"`
+	OrganizationID string `gorm:"type:uuid;index"`
+
+	Status       string // running, completed, failed
+	ErrorMessage string
+
+	Recipients int // contacts that would be emailed
+	Rendered   int
+	Failed     int
+	Skipped    int
+
+	Messages          datatypes.JSONSlice[CampaignPreviewMessage] `gorm:"type:jsonb;default:'[]'"`
+	MessagesTruncated bool                                        // more messages than were stored; the counts cover all of them
+
+	CreatedBy   string `gorm:"type:uuid"`
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+func (CampaignPreview) TableName() string {
+	return "campaign_preview"
+}
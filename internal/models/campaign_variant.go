@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// CampaignVariant is one template/subject combination in an A/B tested campaign
+type CampaignVariant struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	CampaignID     string `gorm:"type:uuid;index"`
+	OrganizationID string `gorm:"type:uuid"`
+	Label          string // A, B, C, D
+
+	TemplateID      string `gorm:"type:uuid"`
+	TemplateVersion *int   // pinned like Campaign.TemplateVersion; nil follows the latest
+	Subject         string // overrides the template subject when set
+
+	SplitPercent int // share of recipients (or of the test sample) that get this variant
+	IsWinner     bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (CampaignVariant) TableName() string {
+	return "campaign_variant"
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// CommissionTier charges Rate percent on the part of the price up to UpTo (nil = no cap)
+type CommissionTier struct {
+	UpTo *float64 `json:"up_to"`
+	Rate float64  `json:"rate"`
+}
+
+type CommissionRule struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid;index"`
+	Name           string
+
+	Type  string                              // percentage | tiered
+	Rate  float64                             // percent of the price for percentage rules
+	Tiers datatypes.JSONSlice[CommissionTier] `gorm:"type:jsonb;default:'[]'"`
+
+	// ListingSidePercent of the gross goes to the listing side, the rest to the buyer side.
+	// Each agent keeps AgentSharePercent of their side; the brokerage keeps the remainder.
+	ListingSidePercent float64
+	AgentSharePercent  float64
+
+	IsDefault bool `gorm:"default:false"`
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (CommissionRule) TableName() string {
+	return "commission_rule"
+}
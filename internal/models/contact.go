@@ -3,9 +3,10 @@ package models
 import "time"
 
 type Contact struct {
-	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	OrganizationID string `gorm:"type:uuid"`
-	CreatedBy      string `gorm:"type:uuid"`
+	ID             string  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string  `gorm:"type:uuid"`
+	CreatedBy      string  `gorm:"type:uuid"`
+	AssignedTo     *string `gorm:"type:uuid;index"`
 
 	FirstName string
 	LastName  string
@@ -19,11 +20,17 @@ type Contact struct {
 	Bathrooms         int
 	SquareFeet        int
 	PreferredLocation string
+	PreferredLanguage string // language tag such as "en" or "hi"; picks the template variant
+	Timezone          string // IANA time zone for campaign send windows; empty uses the organization's
 
-	IsActive  bool `gorm:"default:true"`
-	Notes     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	StageID        *string `gorm:"type:uuid;index"`
+	StageChangedAt *time.Time
+
+	IsActive       bool `gorm:"default:true"`
+	UnsubscribedAt *time.Time
+	Notes          string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 type ContactFilter struct {
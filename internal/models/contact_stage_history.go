@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type ContactStageHistory struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid"`
+	ContactID      string `gorm:"type:uuid;index"`
+
+	FromStageID *string `gorm:"type:uuid"`
+	ToStageID   string  `gorm:"type:uuid"`
+
+	ChangedBy string `gorm:"type:uuid"`
+	ChangedAt time.Time
+}
+
+func (ContactStageHistory) TableName() string {
+	return "contact_stage_history"
+}
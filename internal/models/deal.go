@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type Deal struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid;index"`
+	ContactID      string `gorm:"type:uuid;index"`
+
+	// Free-text property reference until listings are modelled
+	PropertyAddress string
+	Title           string
+
+	ListingAgentID *string `gorm:"type:uuid;index"`
+	BuyerAgentID   *string `gorm:"type:uuid;index"`
+
+	OfferPrice    *float64
+	AcceptedPrice *float64
+
+	Stage             string `gorm:"default:'offer'"` // offer | under_contract | closed_won | closed_lost
+	ExpectedCloseDate *time.Time
+	ClosedAt          *time.Time `gorm:"index"`
+
+	// CommissionRuleID overrides the organization's default rule
+	CommissionRuleID *string `gorm:"type:uuid"`
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Deal) TableName() string {
+	return "deal"
+}
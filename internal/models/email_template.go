@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
 
 type EmailTemplate struct {
 	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
@@ -14,6 +18,15 @@ type EmailTemplate struct {
 	HtmlBody      string
 	PlainTextBody string
 
+	// EditorType is "html" for hand-written HTML or "blocks" for templates built from
+	// BuilderBlocks, which HtmlBody and PlainTextBody are compiled from
+	EditorType    string         `gorm:"default:'html'"`
+	BuilderBlocks datatypes.JSON `gorm:"type:jsonb"`
+
+	CurrentVersion int // latest EmailTemplateVersion; 0 until the first snapshot
+
+	SourceSystemTemplateID *string `gorm:"type:uuid"` // library template this was cloned from
+
 	CreatedBy string `gorm:"type:uuid"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailTemplateAttachment is a file (brochure, floor plan) sent with every email built from a template.
+// Removing one from a template only soft-deletes it: template versions that recorded it keep
+// sending it until the template itself is deleted.
+type EmailTemplateAttachment struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TemplateID     string `gorm:"type:uuid;index"`
+	OrganizationID string `gorm:"type:uuid;index"`
+
+	FileName    string
+	ContentType string
+	SizeBytes   int64
+	StorageKey  string // path of the file within the storage backend
+
+	UploadedBy string `gorm:"type:uuid"`
+	CreatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (EmailTemplateAttachment) TableName() string {
+	return "email_template_attachment"
+}
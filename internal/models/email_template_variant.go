@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// EmailTemplateVariant is a translation of an email template's content for one locale.
+// Sender fields and attachments come from the template. Variants are not versioned; a
+// campaign pinned to an older template version still sends the current translations.
+type EmailTemplateVariant struct {
+	ID              string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	EmailTemplateID string `gorm:"type:uuid;uniqueIndex:idx_template_variant_locale"`
+	OrganizationID  string `gorm:"type:uuid;index"`
+	Locale          string `gorm:"uniqueIndex:idx_template_variant_locale"` // lowercase language tag, e.g. "hi" or "pt-br"
+
+	Subject       string
+	Preheader     string
+	HtmlBody      string
+	PlainTextBody string // generated from HtmlBody when empty
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (EmailTemplateVariant) TableName() string {
+	return "email_template_variant"
+}
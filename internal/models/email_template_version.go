@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// EmailTemplateVersion is an immutable snapshot of an email template's content
+type EmailTemplateVersion struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TemplateID     string `gorm:"type:uuid;uniqueIndex:idx_template_version"`
+	OrganizationID string `gorm:"type:uuid;index"`
+	Version        int    `gorm:"uniqueIndex:idx_template_version"`
+
+	Name          string
+	Subject       string
+	Preheader     string
+	FromName      string
+	ReplyTo       string
+	HtmlBody      string
+	PlainTextBody string
+	EditorType    string
+	BuilderBlocks datatypes.JSON `gorm:"type:jsonb"`
+
+	AttachmentIDs datatypes.JSONSlice[string] `gorm:"type:jsonb;default:'[]'"` // EmailTemplateAttachments sent with this version
+
+	RestoredFrom *int   // version this one was restored from, if any
+	CreatedBy    string `gorm:"type:uuid"`
+	CreatedAt    time.Time
+}
+
+func (EmailTemplateVersion) TableName() string {
+	return "email_template_version"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type LeadRoutingRule struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid;index"`
+
+	Name     string
+	Priority int  // rules are evaluated in ascending priority
+	IsActive bool `gorm:"default:true"`
+
+	// Match criteria - an empty criterion matches every contact
+	Locations     datatypes.JSONSlice[string] `gorm:"type:jsonb;default:'[]'"`
+	PropertyTypes datatypes.JSONSlice[string] `gorm:"type:jsonb;default:'[]'"`
+	BudgetMin     *float64
+	BudgetMax     *float64
+
+	AgentIDs            datatypes.JSONSlice[string] `gorm:"type:jsonb;default:'[]'"`
+	Strategy            string                      // round_robin | least_loaded
+	LastAssignedAgentID *string                     `gorm:"type:uuid"`
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (LeadRoutingRule) TableName() string {
+	return "lead_routing_rule"
+}
@@ -14,6 +14,8 @@ type Notification struct {
 
 	RelatedUserID     *string `gorm:"type:uuid"`
 	RelatedCampaignID *string `gorm:"type:uuid"`
+	RelatedContactID  *string `gorm:"type:uuid"`
+	RelatedTaskID     *string `gorm:"type:uuid"`
 
 	IsRead    bool
 	ReadAt    *time.Time
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type OrganizationSettings struct {
+	OrganizationID string `gorm:"type:uuid;primaryKey"`
+
+	// FollowUpAfterDays creates a follow-up task for contacts without activity
+	// for this many days; 0 turns the rule off
+	FollowUpAfterDays int
+
+	// Brand kit, available to email templates as brand.logo_url, brand.primary_color
+	// and brand.office_address
+	BrandLogoURL       string
+	BrandPrimaryColor  string
+	BrandOfficeAddress string
+
+	// RequireCampaignApproval holds campaigns created by org_users in pending_approval
+	// until an org_admin approves them
+	RequireCampaignApproval bool `gorm:"default:false"`
+
+	// CampaignFrequencyCap is the most campaign emails a contact receives in any 7 days;
+	// 0 turns the cap off
+	CampaignFrequencyCap int
+
+	// Campaign send window. Emails due during quiet hours or on a day missing from
+	// CampaignSendDays are deferred to the next allowed time, in the contact's Timezone
+	// when set and the organization's otherwise.
+	Timezone         string                   // IANA name such as "Asia/Kolkata"; empty is UTC
+	QuietHoursStart  string                   // "HH:MM"; set both ends or neither
+	QuietHoursEnd    string                   // may be before the start for overnight quiet hours
+	CampaignSendDays datatypes.JSONSlice[int] `gorm:"type:jsonb;default:'[]'"` // 0 = Sunday; empty allows every day
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (OrganizationSettings) TableName() string {
+	return "organization_settings"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type PipelineStage struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid;index"`
+
+	Name     string
+	Position int
+	Outcome  string `gorm:"default:'open'"` // open | won | lost
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (PipelineStage) TableName() string {
+	return "pipeline_stage"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// SenderIdentity is an address an organization has proven it controls, so templates may
+// use it as their Reply-To
+type SenderIdentity struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid;uniqueIndex:idx_sender_identity_org_email"`
+
+	Email       string `gorm:"uniqueIndex:idx_sender_identity_org_email"`
+	DisplayName string
+
+	VerificationToken  *string `gorm:"type:varchar(255);index" json:"-"`
+	VerificationSentAt *time.Time
+	VerifiedAt         *time.Time
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (SenderIdentity) TableName() string {
+	return "sender_identity"
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Sequence is a drip workflow: an ordered series of steps that each enrolled contact moves
+// through on the background scheduler
+type Sequence struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid;index"`
+
+	Name        string
+	Description string
+	Status      string `gorm:"default:'draft'"` // draft | active | paused
+
+	// Entry trigger; empty when contacts are only enrolled by hand
+	TriggerType       string  // audience_added | contact_created | stage_changed
+	TriggerAudienceID *string `gorm:"type:uuid"`
+	TriggerStageID    *string `gorm:"type:uuid"`
+	// Trigger events after this time enroll contacts; set when the sequence is activated
+	TriggerCheckedAt *time.Time
+
+	ExitConditions datatypes.JSONSlice[string] `gorm:"type:jsonb;default:'[]'"` // unsubscribed | replied | deal_closed
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Sequence) TableName() string {
+	return "sequence"
+}
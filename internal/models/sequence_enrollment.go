@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// SequenceEnrollment is a contact's progress through a sequence. A contact is enrolled in a
+// sequence at most once.
+type SequenceEnrollment struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	SequenceID     string `gorm:"type:uuid;uniqueIndex:idx_sequence_enrollment_contact"`
+	OrganizationID string `gorm:"type:uuid"`
+	ContactID      string `gorm:"type:uuid;uniqueIndex:idx_sequence_enrollment_contact"`
+
+	Status      string    `gorm:"default:'active'"` // active | completed | exited
+	CurrentStep int       // position of the next step to run
+	NextRunAt   time.Time `gorm:"index"`
+
+	LastMessageID *string `gorm:"type:uuid"` // last SequenceMessage sent, checked by branch steps
+	ExitReason    string  // unsubscribed | replied | deal_closed | removed
+
+	EnrolledAt  time.Time
+	CompletedAt *time.Time
+	UpdatedAt   time.Time
+}
+
+func (SequenceEnrollment) TableName() string {
+	return "sequence_enrollment"
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// SequenceMessage is an email sent by a sequence step, tracked like a CampaignLog
+type SequenceMessage struct {
+	ID           string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	SequenceID   string `gorm:"type:uuid;index"`
+	EnrollmentID string `gorm:"type:uuid;index"`
+	StepID       string `gorm:"type:uuid"`
+	ContactID    string `gorm:"type:uuid"`
+	TemplateID   string `gorm:"type:uuid"`
+
+	RecipientEmail string
+	Subject        string
+
+	Status       string // sent | failed
+	ErrorMessage string
+
+	SentAt    *time.Time
+	OpenedAt  *time.Time
+	ClickedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (SequenceMessage) TableName() string {
+	return "sequence_message"
+}
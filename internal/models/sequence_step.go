@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// SequenceStep is one step of a sequence. Only the fields for its Type are used.
+type SequenceStep struct {
+	ID         string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	SequenceID string `gorm:"type:uuid;index"`
+	Position   int    // 1-based order within the sequence
+	Type       string // send_email | wait | branch | add_to_audience | create_task | change_stage
+
+	TemplateID *string `gorm:"type:uuid"` // send_email: sends the template as it is at send time
+
+	WaitDays int // wait
+
+	// branch: Condition is checked against the last email this sequence sent the contact.
+	// YesStep and NoStep are the positions to continue at; 0 continues with the next step.
+	Condition string // opened | clicked
+	YesStep   int
+	NoStep    int
+
+	AudienceID *string `gorm:"type:uuid"` // add_to_audience
+
+	TaskTitle   string // create_task, assigned to the contact's agent
+	TaskDueDays int
+
+	StageID *string `gorm:"type:uuid"` // change_stage
+
+	CreatedAt time.Time
+}
+
+func (SequenceStep) TableName() string {
+	return "sequence_step"
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// SystemTemplate is a template in the global library curated by super admins. Organizations
+// clone published ones into their own email templates.
+type SystemTemplate struct {
+	ID   string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Slug string `gorm:"uniqueIndex"` // stable key for seeded templates
+
+	Category    string `gorm:"index"`
+	Name        string
+	Description string
+
+	Subject       string
+	Preheader     string
+	HtmlBody      string
+	PlainTextBody string
+	EditorType    string         `gorm:"default:'html'"`
+	BuilderBlocks datatypes.JSON `gorm:"type:jsonb"`
+
+	IsPublished bool // only published templates are visible to organizations
+	CloneCount  int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (SystemTemplate) TableName() string {
+	return "system_template"
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SystemTemplateSeed records a built-in library template that was seeded once, so a super
+// admin can delete it or change its slug without seeding bringing it back
+type SystemTemplateSeed struct {
+	Slug     string `gorm:"primaryKey"` // slug the template was seeded with
+	SeededAt time.Time
+}
+
+func (SystemTemplateSeed) TableName() string {
+	return "system_template_seed"
+}
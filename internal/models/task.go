@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type Task struct {
+	ID             string  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string  `gorm:"type:uuid"`
+	AssignedTo     string  `gorm:"type:uuid;index"`
+	ContactID      *string `gorm:"type:uuid;index"`
+
+	// Free-text property reference until listings are modelled
+	PropertyAddress string
+
+	Title       string
+	Description string
+	DueAt       time.Time `gorm:"index"`
+	Priority    string    `gorm:"default:'medium'"` // low | medium | high
+	Status      string    `gorm:"default:'open'"`   // open | completed | cancelled
+
+	// AutoGenerated marks follow-up tasks created by the scheduler
+	AutoGenerated bool `gorm:"default:false"`
+
+	CompletedAt       *time.Time
+	DueNotifiedAt     *time.Time
+	OverdueNotifiedAt *time.Time
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Task) TableName() string {
+	return "task"
+}
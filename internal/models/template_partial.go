@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TemplatePartial is a named block of template content (header, footer, signature, legal
+// disclaimer) that email templates include with {{> name}} and that is resolved at render time
+type TemplatePartial struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string `gorm:"type:uuid;uniqueIndex:idx_org_partial_name"`
+	Name           string `gorm:"uniqueIndex:idx_org_partial_name"`
+	Description    string
+
+	HtmlBody      string
+	PlainTextBody string // generated from HtmlBody when empty
+
+	CreatedBy string `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (TemplatePartial) TableName() string {
+	return "template_partial"
+}
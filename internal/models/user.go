@@ -17,6 +17,7 @@ type User struct {
 	InviteToken    *string    `gorm:"type:varchar(255);index" json:"-"`
 	TokenExpiresAt *time.Time `json:"-"`
 	IsPasswordSet  bool       `gorm:"default:false" json:"is_password_set"`
+	CalendarToken  *string    `gorm:"type:varchar(255);index" json:"-"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
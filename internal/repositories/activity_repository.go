@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type ActivityRepository struct{}
+
+// TimelineEvent is one entry of a contact's merged timeline.
+// Depending on EventType the generic columns hold:
+//   - activity: SubType is the activity type, Body and Outcome come from the activity
+//   - campaign_email: SubType is the send status, Title the campaign name, Body the subject, Outcome the error
+//   - audience_membership: SubType is added/removed, Title the audience name
+//   - stage_change: Title is the new stage name, Body the previous stage name
+type TimelineEvent struct {
+	EventType  string    `json:"event_type"`
+	SourceID   string    `json:"source_id"`
+	SubType    string    `json:"sub_type"`
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	Outcome    string    `json:"outcome"`
+	ActorID    *string   `json:"actor_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// timelineUnionSQL merges manual activities with system events for a single contact
+const timelineUnionSQL = `
+	SELECT 'activity' AS event_type, a.id::text AS source_id, a.type AS sub_type,
+		'' AS title, COALESCE(a.body, '') AS body, COALESCE(a.outcome, '') AS outcome,
+		a.author_id::text AS actor_id, a.occurred_at AS occurred_at
+	FROM activity a
+	WHERE a.contact_id = @contact AND a.organization_id = @org
+
+	UNION ALL
+
+	SELECT 'campaign_email', cl.id::text, COALESCE(cl.status, ''),
+		COALESCE(c.name, ''), COALESCE(cl.subject, ''), COALESCE(cl.error_message, ''),
+		NULL, COALESCE(cl.sent_at, cl.created_at)
+	FROM campaign_log cl
+	JOIN campaign c ON c.id = cl.campaign_id
+	WHERE cl.contact_id = @contact AND c.organization_id = @org
+
+	UNION ALL
+
+	SELECT 'audience_membership', l.id::text, l.action,
+		COALESCE(au.name, ''), '', '',
+		l.changed_by::text, l.created_at
+	FROM audience_membership_log l
+	JOIN audience au ON au.id = l.audience_id
+	WHERE l.contact_id = @contact AND au.organization_id = @org
+
+	UNION ALL
+
+	SELECT 'stage_change', h.id::text, 'moved',
+		COALESCE(ts.name, ''), COALESCE(fs.name, ''), '',
+		h.changed_by::text, h.changed_at
+	FROM contact_stage_history h
+	LEFT JOIN pipeline_stage ts ON ts.id = h.to_stage_id
+	LEFT JOIN pipeline_stage fs ON fs.id = h.from_stage_id
+	WHERE h.contact_id = @contact AND h.organization_id = @org`
+
+// Create creates a new activity
+func (r *ActivityRepository) Create(activity *models.Activity) error {
+	return database.DB.Create(activity).Error
+}
+
+// FindByID finds an activity by ID within a contact and organization
+func (r *ActivityRepository) FindByID(id, contactID, orgID string) (*models.Activity, error) {
+	var activity models.Activity
+	if err := database.DB.Where("id = ? AND contact_id = ? AND organization_id = ?", id, contactID, orgID).
+		First(&activity).Error; err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// FindByContact returns paginated activities of a contact, newest first, optionally filtered by type
+func (r *ActivityRepository) FindByContact(contactID, orgID, activityType string, page, limit int) ([]models.Activity, int64, error) {
+	var activities []models.Activity
+	var total int64
+
+	query := database.DB.Model(&models.Activity{}).
+		Where("contact_id = ? AND organization_id = ?", contactID, orgID)
+	if activityType != "" {
+		query = query.Where("type = ?", activityType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("occurred_at DESC").Offset(offset).Limit(limit).Find(&activities).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return activities, total, nil
+}
+
+// HasActivitySince reports whether a contact has an activity of a type at or after since
+func (r *ActivityRepository) HasActivitySince(contactID, activityType string, since time.Time) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Activity{}).
+		Where("contact_id = ? AND type = ? AND occurred_at >= ?", contactID, activityType, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Update updates an activity
+func (r *ActivityRepository) Update(activity *models.Activity) error {
+	return database.DB.Save(activity).Error
+}
+
+// Delete deletes an activity
+func (r *ActivityRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.Activity{}).Error
+}
+
+// FindTimeline returns the merged, paginated timeline of a contact, newest first
+func (r *ActivityRepository) FindTimeline(contactID, orgID string, page, limit int) ([]TimelineEvent, int64, error) {
+	var events []TimelineEvent
+	var total int64
+
+	params := map[string]interface{}{"contact": contactID, "org": orgID}
+
+	if err := database.DB.Raw("SELECT COUNT(*) FROM ("+timelineUnionSQL+") t", params).
+		Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	params["limit"] = limit
+	params["offset"] = (page - 1) * limit
+	if err := database.DB.Raw("SELECT * FROM ("+timelineUnionSQL+") t ORDER BY occurred_at DESC LIMIT @limit OFFSET @offset", params).
+		Scan(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AppointmentRepository struct{}
+
+// AppointmentFilter narrows an appointment listing; empty fields are ignored
+type AppointmentFilter struct {
+	AgentID   string
+	ContactID string
+	Status    string
+	From      *time.Time
+	To        *time.Time
+}
+
+// Create creates a new appointment
+func (r *AppointmentRepository) Create(appointment *models.Appointment) error {
+	return database.DB.Create(appointment).Error
+}
+
+// FindByID finds an appointment by ID within an organization
+func (r *AppointmentRepository) FindByID(id, orgID string) (*models.Appointment, error) {
+	var appointment models.Appointment
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&appointment).Error; err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// FindAllByOrg returns paginated appointments for an organization in start order
+func (r *AppointmentRepository) FindAllByOrg(orgID string, f AppointmentFilter, page, limit int) ([]models.Appointment, int64, error) {
+	var appointments []models.Appointment
+	var total int64
+
+	query := database.DB.Model(&models.Appointment{}).Where("organization_id = ?", orgID)
+
+	if f.AgentID != "" {
+		query = query.Where("agent_id = ?", f.AgentID)
+	}
+	if f.ContactID != "" {
+		query = query.Where("contact_id = ?", f.ContactID)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.From != nil {
+		query = query.Where("end_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("start_at <= ?", *f.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("start_at ASC").Offset(offset).Limit(limit).Find(&appointments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return appointments, total, nil
+}
+
+// Update updates an appointment
+func (r *AppointmentRepository) Update(appointment *models.Appointment) error {
+	return database.DB.Save(appointment).Error
+}
+
+// SaveIfFree creates or saves an appointment unless its agent has a scheduled or confirmed
+// appointment overlapping it, and returns those conflicts instead. The agent's user row is
+// locked for the transaction, so concurrent bookings for one agent are checked one at a time.
+func (r *AppointmentRepository) SaveIfFree(appointment *models.Appointment) ([]models.Appointment, error) {
+	var conflicts []models.Appointment
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var users []models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", appointment.AgentID).Find(&users).Error; err != nil {
+			return err
+		}
+
+		var err error
+		conflicts, err = findConflicts(tx, appointment.AgentID, appointment.StartAt, appointment.EndAt, appointment.ID)
+		if err != nil || len(conflicts) > 0 {
+			return err
+		}
+
+		if appointment.ID == "" {
+			return tx.Create(appointment).Error
+		}
+		return tx.Save(appointment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// findConflicts returns the agent's scheduled or confirmed appointments overlapping [start, end),
+// ignoring excludeID so an appointment does not conflict with itself when rescheduled
+func findConflicts(tx *gorm.DB, agentID string, start, end time.Time, excludeID string) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+
+	query := tx.Where("agent_id = ? AND status IN ?", agentID, []string{"scheduled", "confirmed"}).
+		Where("start_at < ? AND end_at > ?", end, start)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	err := query.Order("start_at ASC").Find(&appointments).Error
+	return appointments, err
+}
+
+// FindForAgentFeed returns an agent's appointments ending after since, for calendar subscriptions
+func (r *AppointmentRepository) FindForAgentFeed(agentID string, since time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := database.DB.Where("agent_id = ? AND end_at >= ?", agentID, since).
+		Order("start_at ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
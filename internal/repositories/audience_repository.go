@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	"gorm.io/gorm"
@@ -41,8 +43,8 @@ func (r *AudienceRepository) Delete(id, orgID string) error {
 	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.Audience{}).Error
 }
 
-// AddContacts adds multiple contacts to an audience
-func (r *AudienceRepository) AddContacts(audienceID string, contactIDs []string) error {
+// AddContacts adds multiple contacts to an audience and logs each new membership
+func (r *AudienceRepository) AddContacts(audienceID string, contactIDs []string, changedBy *string) error {
 	return database.DB.Transaction(func(tx *gorm.DB) error {
 		for _, contactID := range contactIDs {
 			audienceContact := models.AudienceContact{
@@ -50,8 +52,54 @@ func (r *AudienceRepository) AddContacts(audienceID string, contactIDs []string)
 				ContactID:  contactID,
 			}
 			// Use FirstOrCreate to avoid duplicates
-			if err := tx.Where("audience_id = ? AND contact_id = ?", audienceID, contactID).
-				FirstOrCreate(&audienceContact).Error; err != nil {
+			result := tx.Where("audience_id = ? AND contact_id = ?", audienceID, contactID).
+				FirstOrCreate(&audienceContact)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			entry := models.AudienceMembershipLog{
+				AudienceID: audienceID,
+				ContactID:  contactID,
+				Action:     "added",
+				ChangedBy:  changedBy,
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveContacts removes multiple contacts from an audience and logs each removal
+func (r *AudienceRepository) RemoveContacts(audienceID string, contactIDs []string, changedBy *string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var members []models.AudienceContact
+		if err := tx.Where("audience_id = ? AND contact_id IN ?", audienceID, contactIDs).
+			Find(&members).Error; err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("audience_id = ? AND contact_id IN ?", audienceID, contactIDs).
+			Delete(&models.AudienceContact{}).Error; err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			entry := models.AudienceMembershipLog{
+				AudienceID: audienceID,
+				ContactID:  member.ContactID,
+				Action:     "removed",
+				ChangedBy:  changedBy,
+			}
+			if err := tx.Create(&entry).Error; err != nil {
 				return err
 			}
 		}
@@ -59,10 +107,13 @@ func (r *AudienceRepository) AddContacts(audienceID string, contactIDs []string)
 	})
 }
 
-// RemoveContacts removes multiple contacts from an audience
-func (r *AudienceRepository) RemoveContacts(audienceID string, contactIDs []string) error {
-	return database.DB.Where("audience_id = ? AND contact_id IN ?", audienceID, contactIDs).
-		Delete(&models.AudienceContact{}).Error
+// FindAddedContactIDs returns contacts added to an audience in (since, until]
+func (r *AudienceRepository) FindAddedContactIDs(audienceID string, since, until time.Time) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.AudienceMembershipLog{}).
+		Where("audience_id = ? AND action = ? AND created_at > ? AND created_at <= ?", audienceID, "added", since, until).
+		Distinct().Pluck("contact_id", &ids).Error
+	return ids, err
 }
 
 // FindContactsByAudience returns paginated contacts for an audience
@@ -1,12 +1,23 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 )
 
 type CampaignLogRepository struct{}
 
+// CampaignVariantCounts are the delivery and engagement totals for one A/B variant
+type CampaignVariantCounts struct {
+	VariantID string
+	Sent      int64
+	Failed    int64
+	Opened    int64
+	Clicked   int64
+}
+
 // Create creates a new campaign log entry
 func (r *CampaignLogRepository) Create(log *models.CampaignLog) error {
 	return database.DB.Create(log).Error
@@ -82,3 +93,152 @@ func (r *CampaignLogRepository) GetStatsByCampaign(campaignID string) (map[strin
 
 	return stats, nil
 }
+
+// GetLocaleStatsByCampaign returns status counts per template variant locale. Emails sent
+// with the template default are counted under "default".
+func (r *CampaignLogRepository) GetLocaleStatsByCampaign(campaignID string) (map[string]map[string]int64, error) {
+	var results []struct {
+		Locale string
+		Status string
+		Count  int64
+	}
+
+	err := database.DB.Model(&models.CampaignLog{}).
+		Select("locale, status, COUNT(*) as count").
+		Where("campaign_id = ?", campaignID).
+		Group("locale, status").
+		Scan(&results).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]map[string]int64)
+	for _, result := range results {
+		locale := result.Locale
+		if locale == "" {
+			locale = "default"
+		}
+		if stats[locale] == nil {
+			stats[locale] = make(map[string]int64)
+		}
+		stats[locale][result.Status] = result.Count
+	}
+
+	return stats, nil
+}
+
+// GetVariantStatsByCampaign returns delivery and engagement totals per A/B variant
+func (r *CampaignLogRepository) GetVariantStatsByCampaign(campaignID string) (map[string]CampaignVariantCounts, error) {
+	var results []CampaignVariantCounts
+
+	err := database.DB.Model(&models.CampaignLog{}).
+		Select(`variant_id,
+			SUM(CASE WHEN status = 'sent' THEN 1 ELSE 0 END) as sent,
+			SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) as failed,
+			COUNT(opened_at) as opened,
+			COUNT(clicked_at) as clicked`).
+		Where("campaign_id = ? AND variant_id IS NOT NULL", campaignID).
+		Group("variant_id").
+		Scan(&results).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]CampaignVariantCounts, len(results))
+	for _, result := range results {
+		stats[result.VariantID] = result
+	}
+
+	return stats, nil
+}
+
+// FindContactIDsByCampaign returns the contacts a campaign has already emailed or tried to
+func (r *CampaignLogRepository) FindContactIDsByCampaign(campaignID string) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.CampaignLog{}).
+		Where("campaign_id = ?", campaignID).
+		Distinct().Pluck("contact_id", &ids).Error
+	return ids, err
+}
+
+// MarkOpened records the first open of a campaign email
+func (r *CampaignLogRepository) MarkOpened(logID string, openedAt time.Time) error {
+	return database.DB.Model(&models.CampaignLog{}).
+		Where("id = ? AND opened_at IS NULL", logID).
+		Update("opened_at", openedAt).Error
+}
+
+// MarkClicked records the first link click in a campaign email. A click also counts as an
+// open, since clients that block images never load the tracking pixel.
+func (r *CampaignLogRepository) MarkClicked(logID string, clickedAt time.Time) error {
+	if err := database.DB.Model(&models.CampaignLog{}).
+		Where("id = ? AND clicked_at IS NULL", logID).
+		Update("clicked_at", clickedAt).Error; err != nil {
+		return err
+	}
+	return r.MarkOpened(logID, clickedAt)
+}
+
+// CountSentSince returns how many campaign emails each contact was sent since a time,
+// counting those the send window holds back or that are being sent as sent already
+func (r *CampaignLogRepository) CountSentSince(contactIDs []string, since time.Time) (map[string]int64, error) {
+	var results []struct {
+		ContactID string
+		Count     int64
+	}
+
+	err := database.DB.Model(&models.CampaignLog{}).
+		Select("contact_id, COUNT(*) as count").
+		Where("contact_id IN ? AND status IN ? AND created_at >= ?", contactIDs, []string{"sent", "deferred", "queued"}, since).
+		Group("contact_id").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		counts[result.ContactID] = result.Count
+	}
+	return counts, nil
+}
+
+// Update saves a campaign log entry
+func (r *CampaignLogRepository) Update(log *models.CampaignLog) error {
+	return database.DB.Save(log).Error
+}
+
+// FindDueDeferred returns emails held back by the send window that may now go out, leaving
+// out those of paused campaigns
+func (r *CampaignLogRepository) FindDueDeferred(now time.Time) ([]models.CampaignLog, error) {
+	var logs []models.CampaignLog
+	err := database.DB.
+		Select("campaign_log.*").
+		Joins("JOIN campaign ON campaign.id = campaign_log.campaign_id").
+		Where("campaign_log.status = ? AND campaign_log.deferred_until <= ?", "deferred", now).
+		Where("campaign.status <> ?", "paused").
+		Order("campaign_log.deferred_until").
+		Find(&logs).Error
+	return logs, err
+}
+
+// ClaimDeferred moves a deferred email to queued and reports whether this call claimed it,
+// so two scheduler ticks never send it twice
+func (r *CampaignLogRepository) ClaimDeferred(logID string) (bool, error) {
+	result := database.DB.Model(&models.CampaignLog{}).
+		Where("id = ? AND status = ?", logID, "deferred").
+		Update("status", "queued")
+	return result.RowsAffected == 1, result.Error
+}
+
+// CountPendingDeferred counts a campaign's emails the send window still holds back, including
+// those claimed by a deferred send that hasn't finished
+func (r *CampaignLogRepository) CountPendingDeferred(campaignID string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.CampaignLog{}).
+		Where("campaign_id = ? AND deferred_until IS NOT NULL AND status IN ?", campaignID, []string{"deferred", "queued"}).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type CampaignPreviewRepository struct{}
+
+// Create creates a new campaign preview
+func (r *CampaignPreviewRepository) Create(preview *models.CampaignPreview) error {
+	return database.DB.Create(preview).Error
+}
+
+// FindByID finds a preview of a campaign within an organization
+func (r *CampaignPreviewRepository) FindByID(id, campaignID, orgID string) (*models.CampaignPreview, error) {
+	var preview models.CampaignPreview
+	if err := database.DB.Where("id = ? AND campaign_id = ? AND organization_id = ?", id, campaignID, orgID).
+		First(&preview).Error; err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// FindByIDOnly finds a preview by ID without organization constraint (for background jobs)
+func (r *CampaignPreviewRepository) FindByIDOnly(id string) (*models.CampaignPreview, error) {
+	var preview models.CampaignPreview
+	if err := database.DB.Where("id = ?", id).First(&preview).Error; err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// Update updates a campaign preview
+func (r *CampaignPreviewRepository) Update(preview *models.CampaignPreview) error {
+	return database.DB.Save(preview).Error
+}
+
+// DeleteByCampaign deletes every preview of a campaign
+func (r *CampaignPreviewRepository) DeleteByCampaign(campaignID string) error {
+	return database.DB.Where("campaign_id = ?", campaignID).Delete(&models.CampaignPreview{}).Error
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
 )
 
 type CampaignRepository struct{}
@@ -106,39 +107,107 @@ func (r *CampaignRepository) FindRecurringCampaigns() ([]models.Campaign, error)
 	return campaigns, err
 }
 
+// FindTestingCampaigns finds test-then-winner campaigns whose test sample has been sent and
+// that are waiting to pick a winner
+func (r *CampaignRepository) FindTestingCampaigns() ([]models.Campaign, error) {
+	var campaigns []models.Campaign
+	err := database.DB.
+		Where("status = ?", "testing").
+		Where("test_sent_at IS NOT NULL").
+		Where("winner_variant_id IS NULL").
+		Find(&campaigns).Error
+	return campaigns, err
+}
+
 // UpdateStatus updates only the status of a campaign
 func (r *CampaignRepository) UpdateStatus(id, status string) error {
 	return database.DB.Model(&models.Campaign{}).Where("id = ?", id).Update("status", status).Error
 }
 
+// ClaimForRun moves a campaign to running only while it is still in one of the given statuses
+// and reports whether this call claimed it, so a run is queued once
+func (r *CampaignRepository) ClaimForRun(id string, statuses ...string) (bool, error) {
+	result := database.DB.Model(&models.Campaign{}).
+		Where("id = ? AND status IN ?", id, statuses).
+		Update("status", "running")
+	return result.RowsAffected == 1, result.Error
+}
+
+// CompleteRun marks a running campaign completed and reports whether this call completed it,
+// so its sent notification goes out once
+func (r *CampaignRepository) CompleteRun(id string) (bool, error) {
+	result := database.DB.Model(&models.Campaign{}).
+		Where("id = ? AND status = ?", id, "running").
+		Update("status", "completed")
+	return result.RowsAffected == 1, result.Error
+}
+
+// StartTestClock records when a campaign's whole test sample went out, unless it already has
+func (r *CampaignRepository) StartTestClock(id string, sentAt time.Time) error {
+	return database.DB.Model(&models.Campaign{}).
+		Where("id = ? AND test_sent_at IS NULL", id).
+		Update("test_sent_at", sentAt).Error
+}
+
 // UpdateLastRunAt updates the last_run_at timestamp
 func (r *CampaignRepository) UpdateLastRunAt(id string, lastRunAt time.Time) error {
 	return database.DB.Model(&models.Campaign{}).Where("id = ?", id).Update("last_run_at", lastRunAt).Error
 }
 
-// GetRecipientContacts returns all contact IDs for a campaign (from audiences or single contact)
+// GetRecipientContacts returns the IDs of a campaign's active recipients: its single contact,
+// or everyone in its audiences who is not in one of its excluded audiences
 func (r *CampaignRepository) GetRecipientContacts(campaign *models.Campaign) ([]string, error) {
 	var contactIDs []string
 
-	// Single contact
-	if campaign.ContactID != nil {
-		return []string{*campaign.ContactID}, nil
+	query := database.DB.Model(&models.Contact{}).
+		Where("organization_id = ? AND is_active = ?", campaign.OrganizationID, true)
+
+	switch {
+	case campaign.ContactID != nil:
+		// Single contact
+		query = query.Where("id = ?", *campaign.ContactID)
+	case len(campaign.AudienceIDs) > 0:
+		// Multiple audiences
+		query = query.Where("id IN (?)", database.DB.Table("audience_contact").
+			Select("contact_id").
+			Where("audience_id IN ?", []string(campaign.AudienceIDs)))
+	default:
+		return contactIDs, nil
 	}
 
-	// Multiple audiences
-	if len(campaign.AudienceIDs) > 0 {
-		var ids []string
-		err := database.DB.Table("audience_contact").
-			Select("DISTINCT contact_id").
-			Where("audience_id IN ?", []string(campaign.AudienceIDs)).
-			Pluck("contact_id", &ids).Error
-
-		if err != nil {
-			return nil, err
-		}
-
-		return ids, nil
+	if len(campaign.ExcludedAudienceIDs) > 0 {
+		query = query.Where("id NOT IN (?)", database.DB.Table("audience_contact").
+			Select("contact_id").
+			Where("audience_id IN ?", []string(campaign.ExcludedAudienceIDs)))
 	}
 
+	if err := query.Pluck("id", &contactIDs).Error; err != nil {
+		return nil, err
+	}
 	return contactIDs, nil
 }
+
+// CountAudienceMembers counts a campaign's audience before any filtering: the distinct
+// contacts in its audiences (or its single contact) and their audience memberships, which are
+// higher when a contact is in more than one audience
+func (r *CampaignRepository) CountAudienceMembers(campaign *models.Campaign) (contacts, memberships int64, err error) {
+	switch {
+	case campaign.ContactID != nil:
+		err = database.DB.Model(&models.Contact{}).
+			Where("id = ? AND organization_id = ?", *campaign.ContactID, campaign.OrganizationID).
+			Count(&contacts).Error
+		return contacts, contacts, err
+	case len(campaign.AudienceIDs) > 0:
+		members := func() *gorm.DB {
+			return database.DB.Table("audience_contact").
+				Joins("JOIN contact ON contact.id = audience_contact.contact_id").
+				Where("audience_contact.audience_id IN ? AND contact.organization_id = ?", []string(campaign.AudienceIDs), campaign.OrganizationID)
+		}
+		if err = members().Count(&memberships).Error; err != nil {
+			return 0, 0, err
+		}
+		err = members().Distinct("audience_contact.contact_id").Count(&contacts).Error
+		return contacts, memberships, err
+	}
+	return 0, 0, nil
+}
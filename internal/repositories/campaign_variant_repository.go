@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type CampaignVariantRepository struct{}
+
+// CreateAll creates a campaign's variants
+func (r *CampaignVariantRepository) CreateAll(variants []models.CampaignVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	return database.DB.Create(&variants).Error
+}
+
+// FindByCampaign returns a campaign's variants in label order
+func (r *CampaignVariantRepository) FindByCampaign(campaignID string) ([]models.CampaignVariant, error) {
+	var variants []models.CampaignVariant
+	if err := database.DB.Where("campaign_id = ?", campaignID).Order("label ASC").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// MarkWinner flags the winning variant of a test-then-winner campaign
+func (r *CampaignVariantRepository) MarkWinner(campaignID, variantID string) error {
+	return database.DB.Model(&models.CampaignVariant{}).
+		Where("campaign_id = ?", campaignID).
+		Update("is_winner", gorm.Expr("id = ?", variantID)).Error
+}
+
+// DeleteByCampaign deletes a campaign's variants
+func (r *CampaignVariantRepository) DeleteByCampaign(campaignID string) error {
+	return database.DB.Where("campaign_id = ?", campaignID).Delete(&models.CampaignVariant{}).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type CommissionRuleRepository struct{}
+
+// Create creates a new commission rule, demoting the previous default when this one is the default
+func (r *CommissionRuleRepository) Create(rule *models.CommissionRule) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if rule.IsDefault {
+			if err := clearDefaultCommissionRule(tx, rule.OrganizationID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(rule).Error
+	})
+}
+
+// FindByID finds a commission rule by ID within an organization
+func (r *CommissionRuleRepository) FindByID(id, orgID string) (*models.CommissionRule, error) {
+	var rule models.CommissionRule
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindAllByOrg returns all commission rules for an organization
+func (r *CommissionRuleRepository) FindAllByOrg(orgID string) ([]models.CommissionRule, error) {
+	var rules []models.CommissionRule
+	if err := database.DB.Where("organization_id = ?", orgID).Order("created_at ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// FindDefault returns the organization's default commission rule
+func (r *CommissionRuleRepository) FindDefault(orgID string) (*models.CommissionRule, error) {
+	var rule models.CommissionRule
+	if err := database.DB.Where("organization_id = ? AND is_default = ?", orgID, true).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Update updates a commission rule, demoting the previous default when this one is the default
+func (r *CommissionRuleRepository) Update(rule *models.CommissionRule) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if rule.IsDefault {
+			if err := clearDefaultCommissionRule(tx, rule.OrganizationID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(rule).Error
+	})
+}
+
+// Delete deletes a commission rule
+func (r *CommissionRuleRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.CommissionRule{}).Error
+}
+
+// CountDeals returns how many deals pin a commission rule
+func (r *CommissionRuleRepository) CountDeals(id string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.Deal{}).Where("commission_rule_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func clearDefaultCommissionRule(tx *gorm.DB, orgID string) error {
+	return tx.Model(&models.CommissionRule{}).
+		Where("organization_id = ? AND is_default = ?", orgID, true).
+		Update("is_default", false).Error
+}
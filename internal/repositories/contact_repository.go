@@ -2,6 +2,7 @@ package repository
 
 import (
 	"strings"
+	"time"
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
@@ -26,11 +27,24 @@ func (r *ContactRepository) FindByID(id, orgID string) (*models.Contact, error)
 
 // FindAllByOrg returns paginated contacts for an organization with optional search
 func (r *ContactRepository) FindAllByOrg(orgID string, page, limit int, search string) ([]models.Contact, int64, error) {
+	return r.FindAllByOrgAndAssignee(orgID, page, limit, search, "")
+}
+
+// FindAllByOrgAndAssignee returns paginated contacts with optional search and assignee filter.
+// assignedTo is empty for no filter, "unassigned" for contacts without an agent, or an agent ID.
+func (r *ContactRepository) FindAllByOrgAndAssignee(orgID string, page, limit int, search, assignedTo string) ([]models.Contact, int64, error) {
 	var contacts []models.Contact
 	var total int64
 
 	query := database.DB.Where("organization_id = ?", orgID)
 
+	// Add assignee filter if provided
+	if assignedTo == "unassigned" {
+		query = query.Where("assigned_to IS NULL")
+	} else if assignedTo != "" {
+		query = query.Where("assigned_to = ?", assignedTo)
+	}
+
 	// Add search filter if provided
 	if search != "" {
 		searchPattern := "%" + search + "%"
@@ -59,6 +73,114 @@ func (r *ContactRepository) Update(contact *models.Contact) error {
 	return database.DB.Save(contact).Error
 }
 
+// UpdateStage moves a contact to a new stage and records the change in one transaction
+func (r *ContactRepository) UpdateStage(contact *models.Contact, history *models.ContactStageHistory) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Contact{}).
+			Where("id = ? AND organization_id = ?", contact.ID, contact.OrganizationID).
+			Updates(map[string]interface{}{
+				"stage_id":         contact.StageID,
+				"stage_changed_at": contact.StageChangedAt,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Create(history).Error
+	})
+}
+
+// FindByStage returns the most recently moved active contacts in a stage (nil stage = unstaged)
+func (r *ContactRepository) FindByStage(orgID string, stageID *string, limit int) ([]models.Contact, error) {
+	var contacts []models.Contact
+	query := database.DB.Where("organization_id = ? AND is_active = ?", orgID, true)
+	if stageID != nil {
+		query = query.Where("stage_id = ?", *stageID)
+	} else {
+		query = query.Where("stage_id IS NULL")
+	}
+	if err := query.Order("stage_changed_at DESC NULLS LAST, created_at DESC").Limit(limit).Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// UpdateAssignee assigns contacts to an agent (nil agentID unassigns them)
+func (r *ContactRepository) UpdateAssignee(ids []string, orgID string, agentID *string) (int64, error) {
+	result := database.DB.Model(&models.Contact{}).
+		Where("id IN ? AND organization_id = ?", ids, orgID).
+		Update("assigned_to", agentID)
+	return result.RowsAffected, result.Error
+}
+
+// ReassignFromAgent hands every contact of agentID to toAgentID, or to the unassigned pool
+// when it is nil, within tx
+func (r *ContactRepository) ReassignFromAgent(tx *gorm.DB, orgID, agentID string, toAgentID *string) (int64, error) {
+	result := tx.Model(&models.Contact{}).
+		Where("organization_id = ? AND assigned_to = ?", orgID, agentID).
+		Update("assigned_to", toAgentID)
+	return result.RowsAffected, result.Error
+}
+
+// FindUnassigned returns the active contacts of an organization that have no agent
+func (r *ContactRepository) FindUnassigned(orgID string) ([]models.Contact, error) {
+	var contacts []models.Contact
+	if err := database.DB.Where("organization_id = ? AND is_active = ? AND assigned_to IS NULL", orgID, true).
+		Order("created_at ASC").
+		Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// CountOpenLeadsByAgent returns the number of active, not-yet-closed contacts held by each agent
+func (r *ContactRepository) CountOpenLeadsByAgent(orgID string, agentIDs []string) (map[string]int64, error) {
+	var results []struct {
+		AssignedTo string
+		Count      int64
+	}
+
+	err := database.DB.Model(&models.Contact{}).
+		Select("contact.assigned_to, COUNT(*) AS count").
+		Joins("LEFT JOIN pipeline_stage ON pipeline_stage.id = contact.stage_id").
+		Where("contact.organization_id = ? AND contact.is_active = ?", orgID, true).
+		Where("contact.assigned_to IN ?", agentIDs).
+		Where("pipeline_stage.outcome IS NULL OR pipeline_stage.outcome = ?", "open").
+		Group("contact.assigned_to").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, result := range results {
+		counts[result.AssignedTo] = result.Count
+	}
+	return counts, nil
+}
+
+// FindNeedingFollowUp returns active, assigned, still-open contacts with no activity
+// and no completed task since cutoff, and no open task planned
+func (r *ContactRepository) FindNeedingFollowUp(orgID string, cutoff time.Time) ([]models.Contact, error) {
+	var contacts []models.Contact
+	err := database.DB.
+		Joins("LEFT JOIN pipeline_stage ON pipeline_stage.id = contact.stage_id").
+		Where("contact.organization_id = ? AND contact.is_active = ?", orgID, true).
+		Where("contact.assigned_to IS NOT NULL AND contact.created_at < ?", cutoff).
+		Where("pipeline_stage.outcome IS NULL OR pipeline_stage.outcome = ?", "open").
+		Where("NOT EXISTS (SELECT 1 FROM activity WHERE activity.contact_id = contact.id AND activity.occurred_at >= ?)", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM task WHERE task.contact_id = contact.id AND (task.status = ? OR task.completed_at >= ?))", "open", cutoff).
+		Find(&contacts).Error
+	return contacts, err
+}
+
+// FindIDsCreatedBetween returns an organization's contacts created in (since, until]
+func (r *ContactRepository) FindIDsCreatedBetween(orgID string, since, until time.Time) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.Contact{}).
+		Where("organization_id = ? AND created_at > ? AND created_at <= ?", orgID, since, until).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
 // Delete soft deletes a contact (sets is_active to false)
 func (r *ContactRepository) Delete(id, orgID string) error {
 	return database.DB.Model(&models.Contact{}).
@@ -66,6 +188,22 @@ func (r *ContactRepository) Delete(id, orgID string) error {
 		Update("is_active", false).Error
 }
 
+// Unsubscribe records that a contact opted out of campaign emails; repeat calls keep the first date
+func (r *ContactRepository) Unsubscribe(id string) (*models.Contact, error) {
+	var contact models.Contact
+	if err := database.DB.Where("id = ?", id).First(&contact).Error; err != nil {
+		return nil, err
+	}
+	if contact.UnsubscribedAt == nil {
+		now := time.Now()
+		contact.UnsubscribedAt = &now
+		if err := database.DB.Model(&contact).Update("unsubscribed_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &contact, nil
+}
+
 // FindByEmailOrPhone checks if a contact with the given email or phone exists in the organization
 func (r *ContactRepository) FindByEmailOrPhone(email, phone, orgID string) (*models.Contact, error) {
 	var contact models.Contact
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type ContactStageHistoryRepository struct{}
+
+// FindByContact returns the stage change history of a contact, newest first
+func (r *ContactStageHistoryRepository) FindByContact(contactID, orgID string) ([]models.ContactStageHistory, error) {
+	var history []models.ContactStageHistory
+	if err := database.DB.Where("contact_id = ? AND organization_id = ?", contactID, orgID).
+		Order("changed_at DESC").
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// FindContactIDsMovedTo returns contacts moved into a stage in (since, until]
+func (r *ContactStageHistoryRepository) FindContactIDsMovedTo(stageID string, since, until time.Time) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.ContactStageHistory{}).
+		Where("to_stage_id = ? AND changed_at > ? AND changed_at <= ?", stageID, since, until).
+		Distinct().Pluck("contact_id", &ids).Error
+	return ids, err
+}
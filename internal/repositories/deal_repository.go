@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type DealRepository struct{}
+
+// DealFilter narrows a deal listing; empty fields are ignored
+type DealFilter struct {
+	AgentID   string // matches either the listing or the buyer agent
+	ContactID string
+	Stage     string
+}
+
+// Create creates a new deal
+func (r *DealRepository) Create(deal *models.Deal) error {
+	return database.DB.Create(deal).Error
+}
+
+// FindByID finds a deal by ID within an organization
+func (r *DealRepository) FindByID(id, orgID string) (*models.Deal, error) {
+	var deal models.Deal
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&deal).Error; err != nil {
+		return nil, err
+	}
+	return &deal, nil
+}
+
+// FindAllByOrg returns paginated deals for an organization, newest first
+func (r *DealRepository) FindAllByOrg(orgID string, f DealFilter, page, limit int) ([]models.Deal, int64, error) {
+	var deals []models.Deal
+	var total int64
+
+	query := database.DB.Model(&models.Deal{}).Where("organization_id = ?", orgID)
+
+	if f.AgentID != "" {
+		query = query.Where("listing_agent_id = ? OR buyer_agent_id = ?", f.AgentID, f.AgentID)
+	}
+	if f.ContactID != "" {
+		query = query.Where("contact_id = ?", f.ContactID)
+	}
+	if f.Stage != "" {
+		query = query.Where("stage = ?", f.Stage)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&deals).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deals, total, nil
+}
+
+// Update updates a deal
+func (r *DealRepository) Update(deal *models.Deal) error {
+	return database.DB.Save(deal).Error
+}
+
+// Delete deletes a deal
+func (r *DealRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.Deal{}).Error
+}
+
+// FindClosedWonBetween returns deals won with a closing date in [from, to)
+func (r *DealRepository) FindClosedWonBetween(orgID string, from, to time.Time) ([]models.Deal, error) {
+	var deals []models.Deal
+	err := database.DB.Where("organization_id = ? AND stage = ? AND closed_at >= ? AND closed_at < ?", orgID, "closed_won", from, to).
+		Order("closed_at ASC").
+		Find(&deals).Error
+	return deals, err
+}
+
+// HasClosedWonDeal reports whether a contact has a deal that closed won
+func (r *DealRepository) HasClosedWonDeal(contactID string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Deal{}).
+		Where("contact_id = ? AND stage = ?", contactID, "closed_won").
+		Count(&count).Error
+	return count > 0, err
+}
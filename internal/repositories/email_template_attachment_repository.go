@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type EmailTemplateAttachmentRepository struct{}
+
+// Create creates a new template attachment
+func (r *EmailTemplateAttachmentRepository) Create(attachment *models.EmailTemplateAttachment) error {
+	return database.DB.Create(attachment).Error
+}
+
+// FindByID finds an attachment of a template within an organization
+func (r *EmailTemplateAttachmentRepository) FindByID(id, templateID, orgID string) (*models.EmailTemplateAttachment, error) {
+	var attachment models.EmailTemplateAttachment
+	if err := database.DB.Where("id = ? AND template_id = ? AND organization_id = ?", id, templateID, orgID).First(&attachment).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// FindByTemplate returns a template's attachments in upload order
+func (r *EmailTemplateAttachmentRepository) FindByTemplate(templateID, orgID string) ([]models.EmailTemplateAttachment, error) {
+	var attachments []models.EmailTemplateAttachment
+	if err := database.DB.Where("template_id = ? AND organization_id = ?", templateID, orgID).Order("created_at ASC").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// FindByIDs returns the listed attachments of a template in upload order, including ones
+// since removed from the template, for sending a pinned template version
+func (r *EmailTemplateAttachmentRepository) FindByIDs(ids []string, templateID, orgID string) ([]models.EmailTemplateAttachment, error) {
+	var attachments []models.EmailTemplateAttachment
+	if len(ids) == 0 {
+		return attachments, nil
+	}
+	if err := database.DB.Unscoped().Where("id IN ? AND template_id = ? AND organization_id = ?", ids, templateID, orgID).Order("created_at ASC").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// FindAllByTemplate returns every attachment a template has had, including removed ones
+func (r *EmailTemplateAttachmentRepository) FindAllByTemplate(templateID, orgID string) ([]models.EmailTemplateAttachment, error) {
+	var attachments []models.EmailTemplateAttachment
+	if err := database.DB.Unscoped().Where("template_id = ? AND organization_id = ?", templateID, orgID).Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// TotalSizeByTemplate returns the combined size in bytes of a template's attachments
+func (r *EmailTemplateAttachmentRepository) TotalSizeByTemplate(templateID, orgID string) (int64, error) {
+	var total int64
+	err := database.DB.Model(&models.EmailTemplateAttachment{}).
+		Where("template_id = ? AND organization_id = ?", templateID, orgID).
+		Select("COALESCE(SUM(size_bytes), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// Delete removes an attachment from its template; versions that recorded it can still load it
+func (r *EmailTemplateAttachmentRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.EmailTemplateAttachment{}).Error
+}
+
+// SetCurrent makes exactly the listed attachments the template's current ones, bringing back
+// removed attachments a restored version recorded
+func (r *EmailTemplateAttachmentRepository) SetCurrent(templateID, orgID string, ids []string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		removed := tx.Where("template_id = ? AND organization_id = ?", templateID, orgID)
+		if len(ids) > 0 {
+			removed = removed.Where("id NOT IN ?", ids)
+		}
+		if err := removed.Delete(&models.EmailTemplateAttachment{}).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Unscoped().Model(&models.EmailTemplateAttachment{}).
+			Where("id IN ? AND template_id = ? AND organization_id = ?", ids, templateID, orgID).
+			Update("deleted_at", nil).Error
+	})
+}
+
+// DeleteByTemplate permanently deletes every attachment record of a template
+func (r *EmailTemplateAttachmentRepository) DeleteByTemplate(templateID, orgID string) error {
+	return database.DB.Unscoped().Where("template_id = ? AND organization_id = ?", templateID, orgID).Delete(&models.EmailTemplateAttachment{}).Error
+}
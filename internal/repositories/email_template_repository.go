@@ -3,6 +3,7 @@ package repository
 import (
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
 )
 
 type EmailTemplateRepository struct{}
@@ -35,9 +36,14 @@ func (r *EmailTemplateRepository) Update(template *models.EmailTemplate) error {
 	return database.DB.Save(template).Error
 }
 
-// Delete deletes an email template
+// Delete deletes an email template and its version history
 func (r *EmailTemplateRepository) Delete(id, orgID string) error {
-	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.EmailTemplate{}).Error
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ? AND organization_id = ?", id, orgID).Delete(&models.EmailTemplateVersion{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.EmailTemplate{}).Error
+	})
 }
 
 // FindByName finds a template by name within an organization
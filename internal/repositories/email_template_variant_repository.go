@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type EmailTemplateVariantRepository struct{}
+
+// Create creates a new template variant
+func (r *EmailTemplateVariantRepository) Create(variant *models.EmailTemplateVariant) error {
+	return database.DB.Create(variant).Error
+}
+
+// FindByLocale finds a template's variant for a locale within an organization
+func (r *EmailTemplateVariantRepository) FindByLocale(templateID, locale, orgID string) (*models.EmailTemplateVariant, error) {
+	var variant models.EmailTemplateVariant
+	if err := database.DB.Where("email_template_id = ? AND locale = ? AND organization_id = ?", templateID, locale, orgID).
+		First(&variant).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// FindByTemplate returns all variants of a template
+func (r *EmailTemplateVariantRepository) FindByTemplate(templateID, orgID string) ([]models.EmailTemplateVariant, error) {
+	var variants []models.EmailTemplateVariant
+	if err := database.DB.Where("email_template_id = ? AND organization_id = ?", templateID, orgID).
+		Order("locale ASC").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// Update updates a template variant
+func (r *EmailTemplateVariantRepository) Update(variant *models.EmailTemplateVariant) error {
+	return database.DB.Save(variant).Error
+}
+
+// Delete deletes a template variant
+func (r *EmailTemplateVariantRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.EmailTemplateVariant{}).Error
+}
+
+// DeleteByTemplate deletes every variant of a template
+func (r *EmailTemplateVariantRepository) DeleteByTemplate(templateID, orgID string) error {
+	return database.DB.Where("email_template_id = ? AND organization_id = ?", templateID, orgID).
+		Delete(&models.EmailTemplateVariant{}).Error
+}
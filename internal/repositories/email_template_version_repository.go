@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type EmailTemplateVersionRepository struct{}
+
+// CreateForTemplate saves (or creates) the template and records its content as the next version, atomically.
+// The version number and the template's CurrentVersion are set on the passed structs.
+func (r *EmailTemplateVersionRepository) CreateForTemplate(template *models.EmailTemplate, version *models.EmailTemplateVersion) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if template.ID != "" {
+			if err := tx.Model(&models.EmailTemplateVersion{}).
+				Where("template_id = ?", template.ID).
+				Select("COALESCE(MAX(version), 0)").
+				Scan(&latest).Error; err != nil {
+				return err
+			}
+		}
+
+		// Saving first also creates a new template, giving it an ID to reference
+		template.CurrentVersion = latest + 1
+		if err := tx.Save(template).Error; err != nil {
+			return err
+		}
+
+		version.TemplateID = template.ID
+		version.OrganizationID = template.OrganizationID
+		version.Version = template.CurrentVersion
+		return tx.Create(version).Error
+	})
+}
+
+// FindByTemplate returns every version of a template, newest first
+func (r *EmailTemplateVersionRepository) FindByTemplate(templateID, orgID string) ([]models.EmailTemplateVersion, error) {
+	var versions []models.EmailTemplateVersion
+	if err := database.DB.Where("template_id = ? AND organization_id = ?", templateID, orgID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// FindByVersion finds one version of a template
+func (r *EmailTemplateVersionRepository) FindByVersion(templateID, orgID string, version int) (*models.EmailTemplateVersion, error) {
+	var v models.EmailTemplateVersion
+	if err := database.DB.Where("template_id = ? AND organization_id = ? AND version = ?", templateID, orgID, version).
+		First(&v).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
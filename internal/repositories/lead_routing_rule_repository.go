@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type LeadRoutingRuleRepository struct{}
+
+// Create creates a new routing rule
+func (r *LeadRoutingRuleRepository) Create(rule *models.LeadRoutingRule) error {
+	return database.DB.Create(rule).Error
+}
+
+// FindByID finds a routing rule by ID within an organization
+func (r *LeadRoutingRuleRepository) FindByID(id, orgID string) (*models.LeadRoutingRule, error) {
+	var rule models.LeadRoutingRule
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindAllByOrg returns all routing rules for an organization in evaluation order
+func (r *LeadRoutingRuleRepository) FindAllByOrg(orgID string) ([]models.LeadRoutingRule, error) {
+	var rules []models.LeadRoutingRule
+	if err := database.DB.Where("organization_id = ?", orgID).Order("priority ASC, created_at ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// FindActiveByOrg returns the active routing rules for an organization in evaluation order
+func (r *LeadRoutingRuleRepository) FindActiveByOrg(orgID string) ([]models.LeadRoutingRule, error) {
+	var rules []models.LeadRoutingRule
+	if err := database.DB.Where("organization_id = ? AND is_active = ?", orgID, true).Order("priority ASC, created_at ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Update updates a routing rule
+func (r *LeadRoutingRuleRepository) Update(rule *models.LeadRoutingRule) error {
+	return database.DB.Save(rule).Error
+}
+
+// UpdateLastAssigned stores the round-robin cursor of a rule
+func (r *LeadRoutingRuleRepository) UpdateLastAssigned(id, agentID string) error {
+	return database.DB.Model(&models.LeadRoutingRule{}).Where("id = ?", id).Update("last_assigned_agent_id", agentID).Error
+}
+
+// Delete deletes a routing rule
+func (r *LeadRoutingRuleRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.LeadRoutingRule{}).Error
+}
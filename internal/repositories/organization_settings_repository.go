@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type OrganizationSettingsRepository struct{}
+
+// FindByOrg finds the settings row of an organization
+func (r *OrganizationSettingsRepository) FindByOrg(orgID string) (*models.OrganizationSettings, error) {
+	var settings models.OrganizationSettings
+	if err := database.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Save creates or updates the settings of an organization
+func (r *OrganizationSettingsRepository) Save(settings *models.OrganizationSettings) error {
+	return database.DB.Save(settings).Error
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PipelineStageRepository struct{}
+
+// StageSummary holds aggregated board figures for a single pipeline column
+type StageSummary struct {
+	StageID        *string
+	ContactCount   int64
+	TotalBudgetMin float64
+	TotalBudgetMax float64
+}
+
+// Create creates a new pipeline stage
+func (r *PipelineStageRepository) Create(stage *models.PipelineStage) error {
+	return database.DB.Create(stage).Error
+}
+
+// CreateDefaults creates an organization's first stages unless it already has some, and
+// returns the organization's stages. The organization row is locked for the transaction, so
+// concurrent first requests seed the pipeline once.
+func (r *PipelineStageRepository) CreateDefaults(orgID string, stages []models.PipelineStage) ([]models.PipelineStage, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var orgs []models.Organization
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", orgID).Find(&orgs).Error; err != nil {
+			return err
+		}
+
+		var existing []models.PipelineStage
+		if err := tx.Where("organization_id = ?", orgID).Order("position ASC, created_at ASC").Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			stages = existing
+			return nil
+		}
+
+		for i := range stages {
+			if err := tx.Create(&stages[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stages, nil
+}
+
+// FindByID finds a pipeline stage by ID within an organization
+func (r *PipelineStageRepository) FindByID(id, orgID string) (*models.PipelineStage, error) {
+	var stage models.PipelineStage
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&stage).Error; err != nil {
+		return nil, err
+	}
+	return &stage, nil
+}
+
+// FindAllByOrg returns all pipeline stages for an organization in board order
+func (r *PipelineStageRepository) FindAllByOrg(orgID string) ([]models.PipelineStage, error) {
+	var stages []models.PipelineStage
+	if err := database.DB.Where("organization_id = ?", orgID).Order("position ASC, created_at ASC").Find(&stages).Error; err != nil {
+		return nil, err
+	}
+	return stages, nil
+}
+
+// Update updates a pipeline stage
+func (r *PipelineStageRepository) Update(stage *models.PipelineStage) error {
+	return database.DB.Save(stage).Error
+}
+
+// UpdatePositions rewrites stage positions to follow the given ID order
+func (r *PipelineStageRepository) UpdatePositions(orgID string, stageIDs []string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range stageIDs {
+			if err := tx.Model(&models.PipelineStage{}).
+				Where("id = ? AND organization_id = ?", id, orgID).
+				Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete deletes a pipeline stage
+func (r *PipelineStageRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.PipelineStage{}).Error
+}
+
+// CountContacts returns the number of contacts currently in a stage
+func (r *PipelineStageRepository) CountContacts(stageID string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.Contact{}).
+		Where("stage_id = ?", stageID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetBoardSummary returns contact counts and budget totals per stage for active contacts
+func (r *PipelineStageRepository) GetBoardSummary(orgID string) ([]StageSummary, error) {
+	var results []StageSummary
+	err := database.DB.Model(&models.Contact{}).
+		Select("stage_id, COUNT(*) AS contact_count, COALESCE(SUM(budget_min), 0) AS total_budget_min, COALESCE(SUM(budget_max), 0) AS total_budget_max").
+		Where("organization_id = ? AND is_active = ?", orgID, true).
+		Group("stage_id").
+		Scan(&results).Error
+	return results, err
+}
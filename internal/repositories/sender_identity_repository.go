@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type SenderIdentityRepository struct{}
+
+// Create creates a new sender identity
+func (r *SenderIdentityRepository) Create(identity *models.SenderIdentity) error {
+	return database.DB.Create(identity).Error
+}
+
+// FindByID finds a sender identity by ID within an organization
+func (r *SenderIdentityRepository) FindByID(id, orgID string) (*models.SenderIdentity, error) {
+	var identity models.SenderIdentity
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// FindByEmail finds a sender identity by address within an organization
+func (r *SenderIdentityRepository) FindByEmail(email, orgID string) (*models.SenderIdentity, error) {
+	var identity models.SenderIdentity
+	if err := database.DB.Where("LOWER(email) = ? AND organization_id = ?", strings.ToLower(email), orgID).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// FindByVerificationToken finds the identity awaiting confirmation with this token
+func (r *SenderIdentityRepository) FindByVerificationToken(token string) (*models.SenderIdentity, error) {
+	var identity models.SenderIdentity
+	if err := database.DB.Where("verification_token = ?", token).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// FindAllByOrg returns all sender identities for an organization
+func (r *SenderIdentityRepository) FindAllByOrg(orgID string) ([]models.SenderIdentity, error) {
+	var identities []models.SenderIdentity
+	if err := database.DB.Where("organization_id = ?", orgID).Order("email ASC").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// FindVerifiedByOrg returns the organization's verified sender identities
+func (r *SenderIdentityRepository) FindVerifiedByOrg(orgID string) ([]models.SenderIdentity, error) {
+	var identities []models.SenderIdentity
+	if err := database.DB.Where("organization_id = ? AND verified_at IS NOT NULL", orgID).Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// Update updates a sender identity
+func (r *SenderIdentityRepository) Update(identity *models.SenderIdentity) error {
+	return database.DB.Save(identity).Error
+}
+
+// Delete deletes a sender identity
+func (r *SenderIdentityRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.SenderIdentity{}).Error
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type SequenceEnrollmentRepository struct{}
+
+// Enroll enrolls a contact unless it was ever enrolled in the sequence before, and reports
+// whether a new enrollment was created
+func (r *SequenceEnrollmentRepository) Enroll(enrollment *models.SequenceEnrollment) (bool, error) {
+	result := database.DB.Where("sequence_id = ? AND contact_id = ?", enrollment.SequenceID, enrollment.ContactID).
+		FirstOrCreate(enrollment)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByID finds an enrollment by ID within an organization
+func (r *SequenceEnrollmentRepository) FindByID(id, orgID string) (*models.SequenceEnrollment, error) {
+	var enrollment models.SequenceEnrollment
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&enrollment).Error; err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// FindBySequence returns paginated enrollments of a sequence, optionally with one status
+func (r *SequenceEnrollmentRepository) FindBySequence(sequenceID, status string, page, limit int) ([]models.SequenceEnrollment, int64, error) {
+	var enrollments []models.SequenceEnrollment
+	var total int64
+
+	query := database.DB.Model(&models.SequenceEnrollment{}).Where("sequence_id = ?", sequenceID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Offset(offset).Limit(limit).Order("enrolled_at DESC").Find(&enrollments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return enrollments, total, nil
+}
+
+// FindDue returns active enrollments of active sequences whose next step is due
+func (r *SequenceEnrollmentRepository) FindDue(now time.Time, limit int) ([]models.SequenceEnrollment, error) {
+	var enrollments []models.SequenceEnrollment
+	err := database.DB.
+		Joins("JOIN sequence ON sequence.id = sequence_enrollment.sequence_id").
+		Where("sequence.status = ?", "active").
+		Where("sequence_enrollment.status = ? AND sequence_enrollment.next_run_at <= ?", "active", now).
+		Order("sequence_enrollment.next_run_at ASC").
+		Limit(limit).
+		Find(&enrollments).Error
+	return enrollments, err
+}
+
+// CountByStatus returns a sequence's enrollment counts by status
+func (r *SequenceEnrollmentRepository) CountByStatus(sequenceID string) (map[string]int64, error) {
+	var results []struct {
+		Status string
+		Count  int64
+	}
+
+	err := database.DB.Model(&models.SequenceEnrollment{}).
+		Select("status, COUNT(*) as count").
+		Where("sequence_id = ?", sequenceID).
+		Group("status").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, result := range results {
+		counts[result.Status] = result.Count
+	}
+	return counts, nil
+}
+
+// Update updates an enrollment
+func (r *SequenceEnrollmentRepository) Update(enrollment *models.SequenceEnrollment) error {
+	return database.DB.Save(enrollment).Error
+}
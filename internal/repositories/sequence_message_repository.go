@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type SequenceMessageRepository struct{}
+
+// Create creates a sequence message entry
+func (r *SequenceMessageRepository) Create(message *models.SequenceMessage) error {
+	return database.DB.Create(message).Error
+}
+
+// FindByID finds a sequence message by ID
+func (r *SequenceMessageRepository) FindByID(id string) (*models.SequenceMessage, error) {
+	var message models.SequenceMessage
+	if err := database.DB.Where("id = ?", id).First(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// FindByEnrollment returns the emails a sequence sent to one enrolled contact
+func (r *SequenceMessageRepository) FindByEnrollment(enrollmentID string) ([]models.SequenceMessage, error) {
+	var messages []models.SequenceMessage
+	if err := database.DB.Where("enrollment_id = ?", enrollmentID).Order("created_at ASC").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// UpdateStatus updates the status of a sequence message
+func (r *SequenceMessageRepository) UpdateStatus(id, status, errorMessage string) error {
+	updates := map[string]interface{}{
+		"status": status,
+	}
+	if errorMessage != "" {
+		updates["error_message"] = errorMessage
+	}
+	if status == "sent" {
+		updates["sent_at"] = time.Now()
+	}
+	return database.DB.Model(&models.SequenceMessage{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// MarkOpened records the first open of a sequence email
+func (r *SequenceMessageRepository) MarkOpened(id string, openedAt time.Time) error {
+	return database.DB.Model(&models.SequenceMessage{}).
+		Where("id = ? AND opened_at IS NULL", id).
+		Update("opened_at", openedAt).Error
+}
+
+// MarkClicked records the first link click in a sequence email, which also counts as an open
+func (r *SequenceMessageRepository) MarkClicked(id string, clickedAt time.Time) error {
+	if err := database.DB.Model(&models.SequenceMessage{}).
+		Where("id = ? AND clicked_at IS NULL", id).
+		Update("clicked_at", clickedAt).Error; err != nil {
+		return err
+	}
+	return r.MarkOpened(id, clickedAt)
+}
+
+// CountEngagementBySequence returns how many sequence emails were sent, opened and clicked
+func (r *SequenceMessageRepository) CountEngagementBySequence(sequenceID string) (map[string]int64, error) {
+	var result struct {
+		Sent    int64
+		Failed  int64
+		Opened  int64
+		Clicked int64
+	}
+
+	err := database.DB.Model(&models.SequenceMessage{}).
+		Select(`SUM(CASE WHEN status = 'sent' THEN 1 ELSE 0 END) as sent,
+			SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) as failed,
+			COUNT(opened_at) as opened,
+			COUNT(clicked_at) as clicked`).
+		Where("sequence_id = ?", sequenceID).
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]int64{
+		"sent":    result.Sent,
+		"failed":  result.Failed,
+		"opened":  result.Opened,
+		"clicked": result.Clicked,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type SequenceRepository struct{}
+
+// Create creates a sequence with its steps
+func (r *SequenceRepository) Create(sequence *models.Sequence, steps []models.SequenceStep) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sequence).Error; err != nil {
+			return err
+		}
+		return createSteps(tx, sequence.ID, steps)
+	})
+}
+
+// FindByID finds a sequence by ID within an organization
+func (r *SequenceRepository) FindByID(id, orgID string) (*models.Sequence, error) {
+	var sequence models.Sequence
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&sequence).Error; err != nil {
+		return nil, err
+	}
+	return &sequence, nil
+}
+
+// FindByIDOnly finds a sequence by ID without an organization check (scheduler context)
+func (r *SequenceRepository) FindByIDOnly(id string) (*models.Sequence, error) {
+	var sequence models.Sequence
+	if err := database.DB.Where("id = ?", id).First(&sequence).Error; err != nil {
+		return nil, err
+	}
+	return &sequence, nil
+}
+
+// FindAllByOrg returns an organization's sequences, newest first
+func (r *SequenceRepository) FindAllByOrg(orgID string) ([]models.Sequence, error) {
+	var sequences []models.Sequence
+	if err := database.DB.Where("organization_id = ?", orgID).Order("created_at DESC").Find(&sequences).Error; err != nil {
+		return nil, err
+	}
+	return sequences, nil
+}
+
+// FindActiveTriggered returns active sequences that enroll contacts on a trigger
+func (r *SequenceRepository) FindActiveTriggered() ([]models.Sequence, error) {
+	var sequences []models.Sequence
+	err := database.DB.Where("status = ? AND trigger_type <> ''", "active").Find(&sequences).Error
+	return sequences, err
+}
+
+// Update updates a sequence
+func (r *SequenceRepository) Update(sequence *models.Sequence) error {
+	return database.DB.Save(sequence).Error
+}
+
+// UpdateTriggerCheckedAt moves a sequence's trigger checkpoint
+func (r *SequenceRepository) UpdateTriggerCheckedAt(id string, checkedAt time.Time) error {
+	return database.DB.Model(&models.Sequence{}).Where("id = ?", id).Update("trigger_checked_at", checkedAt).Error
+}
+
+// Delete deletes a sequence with its steps, enrollments and message log
+func (r *SequenceRepository) Delete(id, orgID string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.Sequence{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		for _, model := range []interface{}{&models.SequenceStep{}, &models.SequenceEnrollment{}, &models.SequenceMessage{}} {
+			if err := tx.Where("sequence_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindSteps returns a sequence's steps in order
+func (r *SequenceRepository) FindSteps(sequenceID string) ([]models.SequenceStep, error) {
+	var steps []models.SequenceStep
+	if err := database.DB.Where("sequence_id = ?", sequenceID).Order("position ASC").Find(&steps).Error; err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// ReplaceSteps swaps a sequence's steps for new ones
+func (r *SequenceRepository) ReplaceSteps(sequenceID string, steps []models.SequenceStep) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("sequence_id = ?", sequenceID).Delete(&models.SequenceStep{}).Error; err != nil {
+			return err
+		}
+		return createSteps(tx, sequenceID, steps)
+	})
+}
+
+func createSteps(tx *gorm.DB, sequenceID string, steps []models.SequenceStep) error {
+	if len(steps) == 0 {
+		return nil
+	}
+	for i := range steps {
+		steps[i].ID = ""
+		steps[i].SequenceID = sequenceID
+	}
+	return tx.Create(&steps).Error
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SystemTemplateRepository struct{}
+
+// Create creates a new system template
+func (r *SystemTemplateRepository) Create(template *models.SystemTemplate) error {
+	return database.DB.Create(template).Error
+}
+
+// FindByID finds a system template by ID
+func (r *SystemTemplateRepository) FindByID(id string) (*models.SystemTemplate, error) {
+	var template models.SystemTemplate
+	if err := database.DB.Where("id = ?", id).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// FindPublishedByID finds a system template that organizations can see
+func (r *SystemTemplateRepository) FindPublishedByID(id string) (*models.SystemTemplate, error) {
+	var template models.SystemTemplate
+	if err := database.DB.Where("id = ? AND is_published = ?", id, true).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// CreateSeed creates a built-in library template and records its slug as seeded
+func (r *SystemTemplateRepository) CreateSeed(template *models.SystemTemplate) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.SystemTemplateSeed{Slug: template.Slug}).Error
+	})
+}
+
+// MarkSeeded records a built-in slug as seeded without creating a template
+func (r *SystemTemplateRepository) MarkSeeded(slug string) error {
+	return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SystemTemplateSeed{Slug: slug}).Error
+}
+
+// SeededSlugs returns the slugs of built-in templates that were already seeded
+func (r *SystemTemplateRepository) SeededSlugs() (map[string]bool, error) {
+	var slugs []string
+	if err := database.DB.Model(&models.SystemTemplateSeed{}).Pluck("slug", &slugs).Error; err != nil {
+		return nil, err
+	}
+	seeded := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		seeded[slug] = true
+	}
+	return seeded, nil
+}
+
+// FindBySlug finds a system template by slug
+func (r *SystemTemplateRepository) FindBySlug(slug string) (*models.SystemTemplate, error) {
+	var template models.SystemTemplate
+	if err := database.DB.Where("slug = ?", slug).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// FindAll returns system templates, optionally only published ones or one category
+func (r *SystemTemplateRepository) FindAll(publishedOnly bool, category string) ([]models.SystemTemplate, error) {
+	var templates []models.SystemTemplate
+	query := database.DB.Model(&models.SystemTemplate{})
+	if publishedOnly {
+		query = query.Where("is_published = ?", true)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if err := query.Order("category ASC, name ASC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Update updates a system template
+func (r *SystemTemplateRepository) Update(template *models.SystemTemplate) error {
+	return database.DB.Save(template).Error
+}
+
+// IncrementCloneCount records that an organization cloned a system template
+func (r *SystemTemplateRepository) IncrementCloneCount(id string) error {
+	return database.DB.Model(&models.SystemTemplate{}).Where("id = ?", id).
+		UpdateColumn("clone_count", gorm.Expr("clone_count + 1")).Error
+}
+
+// Delete deletes a system template. Organization copies are unaffected.
+func (r *SystemTemplateRepository) Delete(id string) error {
+	return database.DB.Where("id = ?", id).Delete(&models.SystemTemplate{}).Error
+}
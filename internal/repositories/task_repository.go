@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type TaskRepository struct{}
+
+// TaskFilter narrows a task listing; empty fields are ignored
+type TaskFilter struct {
+	AssignedTo string
+	ContactID  string
+	Status     string
+	DueBefore  *time.Time
+	DueAfter   *time.Time
+}
+
+// Create creates a new task
+func (r *TaskRepository) Create(task *models.Task) error {
+	return database.DB.Create(task).Error
+}
+
+// FindByID finds a task by ID within an organization
+func (r *TaskRepository) FindByID(id, orgID string) (*models.Task, error) {
+	var task models.Task
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&task).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// FindAllByOrg returns paginated tasks for an organization, soonest due first
+func (r *TaskRepository) FindAllByOrg(orgID string, f TaskFilter, page, limit int) ([]models.Task, int64, error) {
+	var tasks []models.Task
+	var total int64
+
+	query := database.DB.Model(&models.Task{}).Where("organization_id = ?", orgID)
+
+	if f.AssignedTo != "" {
+		query = query.Where("assigned_to = ?", f.AssignedTo)
+	}
+	if f.ContactID != "" {
+		query = query.Where("contact_id = ?", f.ContactID)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.DueBefore != nil {
+		query = query.Where("due_at <= ?", *f.DueBefore)
+	}
+	if f.DueAfter != nil {
+		query = query.Where("due_at >= ?", *f.DueAfter)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("due_at ASC").Offset(offset).Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, total, nil
+}
+
+// Update updates a task
+func (r *TaskRepository) Update(task *models.Task) error {
+	return database.DB.Save(task).Error
+}
+
+// Delete deletes a task
+func (r *TaskRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.Task{}).Error
+}
+
+// FindDueSoon returns open tasks due between now and until that have not had a due reminder
+func (r *TaskRepository) FindDueSoon(now, until time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	err := database.DB.Where("status = ? AND due_notified_at IS NULL AND due_at > ? AND due_at <= ?", "open", now, until).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// FindOverdue returns open tasks past their due time that have not had an overdue reminder
+func (r *TaskRepository) FindOverdue(now time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	err := database.DB.Where("status = ? AND overdue_notified_at IS NULL AND due_at <= ?", "open", now).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// MarkNotified stamps a reminder column (due_notified_at or overdue_notified_at) on a task
+func (r *TaskRepository) MarkNotified(id, column string, at time.Time) error {
+	return database.DB.Model(&models.Task{}).Where("id = ?", id).Update(column, at).Error
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+type TemplatePartialRepository struct{}
+
+// Create creates a new template partial
+func (r *TemplatePartialRepository) Create(partial *models.TemplatePartial) error {
+	return database.DB.Create(partial).Error
+}
+
+// FindByID finds a template partial by ID within an organization
+func (r *TemplatePartialRepository) FindByID(id, orgID string) (*models.TemplatePartial, error) {
+	var partial models.TemplatePartial
+	if err := database.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&partial).Error; err != nil {
+		return nil, err
+	}
+	return &partial, nil
+}
+
+// FindByName finds a template partial by name within an organization
+func (r *TemplatePartialRepository) FindByName(name, orgID string) (*models.TemplatePartial, error) {
+	var partial models.TemplatePartial
+	if err := database.DB.Where("name = ? AND organization_id = ?", name, orgID).First(&partial).Error; err != nil {
+		return nil, err
+	}
+	return &partial, nil
+}
+
+// FindAllByOrg returns all template partials for an organization
+func (r *TemplatePartialRepository) FindAllByOrg(orgID string) ([]models.TemplatePartial, error) {
+	var partials []models.TemplatePartial
+	if err := database.DB.Where("organization_id = ?", orgID).Order("name ASC").Find(&partials).Error; err != nil {
+		return nil, err
+	}
+	return partials, nil
+}
+
+// Update updates a template partial
+func (r *TemplatePartialRepository) Update(partial *models.TemplatePartial) error {
+	return database.DB.Save(partial).Error
+}
+
+// Delete deletes a template partial
+func (r *TemplatePartialRepository) Delete(id, orgID string) error {
+	return database.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.TemplatePartial{}).Error
+}
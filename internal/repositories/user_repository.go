@@ -4,6 +4,7 @@ import (
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type UserRepository struct{}
@@ -40,6 +41,22 @@ func (r *UserRepository) Update(user *models.User) error {
 	return database.DB.Save(user).Error
 }
 
+// Deactivate deactivates a user and, in the same transaction, runs also: work that must
+// succeed or fail together with the deactivation, such as handing over their contacts
+func (r *UserRepository) Deactivate(user *models.User, also func(tx *gorm.DB) error) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return also(tx)
+	})
+	if err != nil {
+		return err
+	}
+	user.IsActive = false
+	return nil
+}
+
 func (r *UserRepository) FindAdminsByOrg(orgID uuid.UUID) ([]models.User, error) {
 	var admins []models.User
 	err := database.DB.Where("organization_id = ? AND role = ?", orgID, "org_admin").Find(&admins).Error
@@ -54,8 +71,22 @@ func (r *UserRepository) FindByInviteToken(token string) (*models.User, error) {
 	return &user, nil
 }
 
+func (r *UserRepository) FindByCalendarToken(token string) (*models.User, error) {
+	var user models.User
+	if err := database.DB.Where("calendar_token = ?", token).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) FindByOrgAndRole(orgID uuid.UUID, role string) ([]models.User, error) {
 	var users []models.User
 	err := database.DB.Where("organization_id = ? AND role = ?", orgID, role).Find(&users).Error
 	return users, err
 }
+
+func (r *UserRepository) FindActiveByIDs(orgID uuid.UUID, ids []string) ([]models.User, error) {
+	var users []models.User
+	err := database.DB.Where("organization_id = ? AND is_active = ? AND id IN ?", orgID, true, ids).Find(&users).Error
+	return users, err
+}
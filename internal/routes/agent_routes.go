@@ -19,6 +19,23 @@ func RegisterAgentRoutes(app *fiber.App) {
 	contacts.Put("/:id", handlers.UpdateContact)
 	contacts.Delete("/:id", handlers.DeleteContact)
 	contacts.Post("/import", handlers.ImportContactsCSV)
+	contacts.Post("/bulk-stage", handlers.BulkMoveContactStage)
+	contacts.Post("/bulk-assign", handlers.BulkAssignContacts)
+	contacts.Put("/:id/assign", handlers.AssignContact)
+	contacts.Put("/:id/stage", handlers.MoveContactStage)
+	contacts.Get("/:id/stage-history", handlers.GetContactStageHistory)
+	contacts.Get("/:id/timeline", handlers.GetContactTimeline)
+
+	// Contact activity routes
+	contacts.Post("/:id/activities", handlers.CreateContactActivity)
+	contacts.Get("/:id/activities", handlers.GetContactActivities)
+	contacts.Put("/:id/activities/:activityId", handlers.UpdateContactActivity)
+	contacts.Delete("/:id/activities/:activityId", handlers.DeleteContactActivity)
+
+	// Pipeline routes
+	pipeline := agent.Group("/pipeline")
+	pipeline.Get("/stages", handlers.GetPipelineStages)
+	pipeline.Get("/board", handlers.GetPipelineBoard)
 
 	// Audience routes
 	audiences := agent.Group("/audiences")
@@ -35,10 +52,32 @@ func RegisterAgentRoutes(app *fiber.App) {
 	templates := agent.Group("/email-templates")
 	templates.Post("/", handlers.CreateEmailTemplate)
 	templates.Get("/", handlers.GetEmailTemplates)
+	templates.Post("/blocks/compile", handlers.CompileEmailTemplateBlocks)
 	templates.Get("/:id", handlers.GetEmailTemplateByID)
 	templates.Put("/:id", handlers.UpdateEmailTemplate)
 	templates.Delete("/:id", handlers.DeleteEmailTemplate)
+	templates.Post("/:id/preview", handlers.PreviewEmailTemplate)
+	templates.Get("/:id/versions", handlers.GetEmailTemplateVersions)
+	templates.Get("/:id/versions/diff", handlers.DiffEmailTemplateVersions)
+	templates.Get("/:id/versions/:version", handlers.GetEmailTemplateVersion)
+	templates.Post("/:id/versions/:version/restore", handlers.RestoreEmailTemplateVersion)
 	templates.Post("/:id/test-send", handlers.TestSendEmail)
+	templates.Post("/:id/attachments", handlers.UploadEmailTemplateAttachment)
+	templates.Get("/:id/attachments", handlers.GetEmailTemplateAttachments)
+	templates.Delete("/:id/attachments/:attachmentId", handlers.DeleteEmailTemplateAttachment)
+	templates.Post("/:id/variants", handlers.CreateEmailTemplateVariant)
+	templates.Get("/:id/variants", handlers.GetEmailTemplateVariants)
+	templates.Put("/:id/variants/:locale", handlers.UpdateEmailTemplateVariant)
+	templates.Delete("/:id/variants/:locale", handlers.DeleteEmailTemplateVariant)
+
+	// Template partials are read-only for agents
+	agent.Get("/template-partials", handlers.GetTemplatePartials)
+	agent.Get("/template-partials/:id", handlers.GetTemplatePartialByID)
+
+	// Published system templates, cloned into the organization's own templates
+	agent.Get("/template-library", handlers.GetTemplateLibrary)
+	agent.Get("/template-library/:id", handlers.GetTemplateLibraryItem)
+	agent.Post("/template-library/:id/clone", handlers.CloneTemplateLibraryItem)
 
 	// Campaign routes
 	campaigns := agent.Group("/campaigns")
@@ -49,7 +88,54 @@ func RegisterAgentRoutes(app *fiber.App) {
 	campaigns.Delete("/:id", handlers.DeleteCampaign)
 	campaigns.Post("/:id/pause", handlers.PauseCampaign)
 	campaigns.Post("/:id/resume", handlers.ResumeCampaign)
+	campaigns.Post("/:id/schedule", handlers.ScheduleCampaign)
+	campaigns.Post("/:id/send-now", handlers.SendCampaignNow)
+	campaigns.Post("/:id/clone", handlers.CloneCampaign)
 	campaigns.Get("/:id/logs", handlers.GetCampaignLogs)
+	campaigns.Get("/:id/recipients", handlers.GetCampaignRecipients)
+	campaigns.Post("/:id/dry-run", handlers.DryRunCampaign)
+	campaigns.Get("/:id/dry-runs/:previewId", handlers.GetCampaignPreview)
+
+	// Sequence routes
+	sequences := agent.Group("/sequences")
+	sequences.Post("/", handlers.CreateSequence)
+	sequences.Get("/", handlers.GetSequences)
+	sequences.Get("/:id", handlers.GetSequenceByID)
+	sequences.Put("/:id", handlers.UpdateSequence)
+	sequences.Delete("/:id", handlers.DeleteSequence)
+	sequences.Post("/:id/activate", handlers.ActivateSequence)
+	sequences.Post("/:id/pause", handlers.PauseSequence)
+	sequences.Post("/:id/enrollments", handlers.EnrollSequenceContacts)
+	sequences.Get("/:id/enrollments", handlers.GetSequenceEnrollments)
+	sequences.Post("/:id/enrollments/:enrollmentId/exit", handlers.ExitSequenceEnrollment)
+
+	// Task routes
+	tasks := agent.Group("/tasks")
+	tasks.Post("/", handlers.CreateTask)
+	tasks.Get("/", handlers.GetTasks)
+	tasks.Get("/:id", handlers.GetTaskByID)
+	tasks.Put("/:id", handlers.UpdateTask)
+	tasks.Delete("/:id", handlers.DeleteTask)
+	tasks.Post("/:id/complete", handlers.CompleteTask)
+
+	// Appointment routes
+	appointments := agent.Group("/appointments")
+	appointments.Post("/", handlers.CreateAppointment)
+	appointments.Get("/", handlers.GetAppointments)
+	appointments.Get("/:id", handlers.GetAppointmentByID)
+	appointments.Put("/:id", handlers.UpdateAppointment)
+	appointments.Post("/:id/send-confirmation", handlers.SendAppointmentConfirmation)
+
+	// Deal routes
+	deals := agent.Group("/deals")
+	deals.Post("/", handlers.CreateDeal)
+	deals.Get("/", handlers.GetDeals)
+	deals.Get("/:id", handlers.GetDealByID)
+	deals.Put("/:id", handlers.UpdateDeal)
+	deals.Delete("/:id", handlers.DeleteDeal)
+
+	// Calendar subscription
+	agent.Get("/calendar/feed-url", handlers.GetCalendarFeedURL)
 
 	// Notification routes
 	notifications := agent.Group("/notifications")
@@ -14,6 +14,53 @@ func RegisterOrgAdminRoutes(app *fiber.App) {
 	agentRoutes.Post("/", handlers.CreateAgent)                           // Create agent
 	agentRoutes.Get("/", handlers.GetAgents)                              // List all agents
 	agentRoutes.Put("/:id", handlers.UpdateAgent)                         // Update agent
-	agentRoutes.Delete("/:id", handlers.DeactivateAgent)                  // Deactivate agent
+	agentRoutes.Delete("/:id", handlers.DeactivateAgent)                  // Deactivate agent (optional ?reassign_to=)
 	agentRoutes.Post("/:id/regenerate-invite", handlers.RegenerateInvite) // Regenerate invite
+
+	// Pipeline configuration
+	stageRoutes := orgAdmin.Group("/pipeline/stages")
+	stageRoutes.Post("/", handlers.CreatePipelineStage)
+	stageRoutes.Put("/reorder", handlers.ReorderPipelineStages)
+	stageRoutes.Put("/:id", handlers.UpdatePipelineStage)
+	stageRoutes.Delete("/:id", handlers.DeletePipelineStage)
+
+	// Lead routing rules
+	routingRoutes := orgAdmin.Group("/routing-rules")
+	routingRoutes.Post("/", handlers.CreateRoutingRule)
+	routingRoutes.Get("/", handlers.GetRoutingRules)
+	routingRoutes.Post("/apply", handlers.ApplyRoutingRules)
+	routingRoutes.Put("/:id", handlers.UpdateRoutingRule)
+	routingRoutes.Delete("/:id", handlers.DeleteRoutingRule)
+
+	// Commission rules and payouts
+	commissionRoutes := orgAdmin.Group("/commission-rules")
+	commissionRoutes.Post("/", handlers.CreateCommissionRule)
+	commissionRoutes.Get("/", handlers.GetCommissionRules)
+	commissionRoutes.Put("/:id", handlers.UpdateCommissionRule)
+	commissionRoutes.Delete("/:id", handlers.DeleteCommissionRule)
+	orgAdmin.Get("/commissions/payouts", handlers.GetCommissionPayouts) // ?from=YYYY-MM-DD&to=YYYY-MM-DD
+
+	// Sender identities (verified Reply-To addresses for templates)
+	senderRoutes := orgAdmin.Group("/sender-identities")
+	senderRoutes.Post("/", handlers.CreateSenderIdentity)
+	senderRoutes.Get("/", handlers.GetSenderIdentities)
+	senderRoutes.Post("/:id/resend", handlers.ResendSenderVerification)
+	senderRoutes.Delete("/:id", handlers.DeleteSenderIdentity)
+
+	// Template partials (shared header, footer, signature, disclaimer)
+	partialRoutes := orgAdmin.Group("/template-partials")
+	partialRoutes.Post("/", handlers.CreateTemplatePartial)
+	partialRoutes.Get("/", handlers.GetTemplatePartials)
+	partialRoutes.Get("/:id", handlers.GetTemplatePartialByID)
+	partialRoutes.Put("/:id", handlers.UpdateTemplatePartial)
+	partialRoutes.Delete("/:id", handlers.DeleteTemplatePartial)
+
+	// Campaign approval, when the organization requires it for org_user campaigns
+	campaignRoutes := orgAdmin.Group("/campaigns")
+	campaignRoutes.Post("/:id/approve", handlers.ApproveCampaign)
+	campaignRoutes.Post("/:id/reject", handlers.RejectCampaign)
+
+	// Organization settings (including the brand kit and campaign approval)
+	orgAdmin.Get("/settings", handlers.GetOrganizationSettings)
+	orgAdmin.Put("/settings", handlers.UpdateOrganizationSettings)
 }
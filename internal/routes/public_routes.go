@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/handlers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterPublicRoutes registers unauthenticated endpoints that are protected by secret tokens
+func RegisterPublicRoutes(app *fiber.App) {
+	public := app.Group("/public")
+
+	// Agent calendar subscription (.ics)
+	public.Get("/calendar/:token", handlers.GetCalendarFeed)
+
+	// Sender identity verification links
+	public.Get("/sender-identities/verify/:token", handlers.VerifySenderIdentity)
+
+	// Campaign email unsubscribe links
+	public.Get("/unsubscribe/:token", handlers.GetUnsubscribePage)
+	public.Post("/unsubscribe/:token", handlers.Unsubscribe)
+
+	// Campaign open and click tracking
+	public.Get("/track/open/:token", handlers.TrackCampaignOpen)
+	public.Get("/track/click/:token", handlers.TrackCampaignClick)
+}
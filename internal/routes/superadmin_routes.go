@@ -22,4 +22,12 @@ func RegisterSuperAdminRoutes(app *fiber.App) {
 	agents.Put("/:id", handlers.SuperAdminUpdateAgent)                         // Update agent
 	agents.Delete("/:id", handlers.SuperAdminDeactivateAgent)                  // Deactivate agent
 	agents.Post("/:id/regenerate-invite", handlers.SuperAdminRegenerateInvite) // Regenerate invite
+
+	// Global template library that organizations clone from
+	systemTemplates := superAdmin.Group("/templates")
+	systemTemplates.Post("/", handlers.CreateSystemTemplate)
+	systemTemplates.Get("/", handlers.GetSystemTemplates)
+	systemTemplates.Get("/:id", handlers.GetSystemTemplateByID)
+	systemTemplates.Put("/:id", handlers.UpdateSystemTemplate)
+	systemTemplates.Delete("/:id", handlers.DeleteSystemTemplate)
 }
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ErrAppointmentConflict is returned when an agent is already booked for the requested time
+var ErrAppointmentConflict = errors.New("agent already has an appointment at this time")
+
+// ErrAppointmentTimes is returned when an appointment does not end after it starts
+var ErrAppointmentTimes = errors.New("end_at must be after start_at")
+
+type AppointmentService struct {
+	appointmentRepo *repository.AppointmentRepository
+	contactRepo     *repository.ContactRepository
+	userRepo        *repository.UserRepository
+	emailService    *EmailService
+}
+
+func NewAppointmentService() *AppointmentService {
+	return &AppointmentService{
+		appointmentRepo: &repository.AppointmentRepository{},
+		contactRepo:     &repository.ContactRepository{},
+		userRepo:        &repository.UserRepository{},
+		emailService:    NewEmailService(),
+	}
+}
+
+// IsValidAppointmentStatus checks an appointment status value
+func IsValidAppointmentStatus(status string) bool {
+	switch status {
+	case "scheduled", "confirmed", "cancelled", "no_show":
+		return true
+	}
+	return false
+}
+
+// Book saves an appointment if its agent is free for it. When the slot is taken it saves
+// nothing and returns the overlapping appointments with ErrAppointmentConflict.
+func (s *AppointmentService) Book(appointment *models.Appointment) ([]models.Appointment, error) {
+	if !appointment.EndAt.After(appointment.StartAt) {
+		return nil, ErrAppointmentTimes
+	}
+
+	conflicts, err := s.appointmentRepo.SaveIfFree(appointment)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return conflicts, ErrAppointmentConflict
+	}
+	return nil, nil
+}
+
+// SendConfirmation emails the contact the appointment details and records when it was sent
+func (s *AppointmentService) SendConfirmation(appointment *models.Appointment, contact *models.Contact) error {
+	if contact.Email == "" {
+		return errors.New("contact has no email address")
+	}
+
+	agentName := ""
+	if agentUUID, err := uuid.Parse(appointment.AgentID); err == nil {
+		if agent, err := s.userRepo.FindByID(agentUUID); err == nil {
+			agentName = agent.Name
+		}
+	}
+
+	if err := s.emailService.SendAppointmentConfirmation(contact.Email, contact.FirstName, agentName, appointment); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	appointment.ConfirmationSentAt = &now
+	return s.appointmentRepo.Update(appointment)
+}
+
+// CalendarToken returns the agent's calendar feed token, creating one on first use
+func (s *AppointmentService) CalendarToken(user *models.User, regenerate bool) (string, error) {
+	if user.CalendarToken != nil && !regenerate {
+		return *user.CalendarToken, nil
+	}
+
+	token, err := utils.GenerateInviteToken()
+	if err != nil {
+		return "", err
+	}
+	user.CalendarToken = &token
+	if err := s.userRepo.Update(user); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// BuildAgentFeed renders an agent's appointments from the last 30 days onwards as iCalendar
+func (s *AppointmentService) BuildAgentFeed(agent *models.User) (string, error) {
+	appointments, err := s.appointmentRepo.FindForAgentFeed(agent.ID.String(), time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		return "", err
+	}
+
+	var contactIDs []string
+	for _, appointment := range appointments {
+		contactIDs = append(contactIDs, appointment.ContactID)
+	}
+
+	contacts := make(map[string]models.Contact)
+	if len(contactIDs) > 0 {
+		found, err := s.contactRepo.FindByIDs(contactIDs, agent.OrganizationID.String())
+		if err != nil {
+			return "", err
+		}
+		for _, contact := range found {
+			contacts[contact.ID] = contact
+		}
+	}
+
+	var events []ICSEvent
+	for _, appointment := range appointments {
+		events = append(events, AppointmentToICSEvent(&appointment, contacts[appointment.ContactID]))
+	}
+
+	return BuildICSCalendar(agent.Name+" - Appointments", events), nil
+}
+
+// AppointmentToICSEvent maps an appointment onto an iCalendar event
+func AppointmentToICSEvent(appointment *models.Appointment, contact models.Contact) ICSEvent {
+	summary := appointment.Title
+	contactName := strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+	if summary == "" {
+		summary = "Appointment"
+		if contactName != "" {
+			summary = "Appointment with " + contactName
+		}
+	}
+
+	var details []string
+	if contactName != "" {
+		details = append(details, "Contact: "+contactName)
+	}
+	if contact.Phone != "" {
+		details = append(details, "Phone: "+contact.Phone)
+	}
+	if appointment.PropertyAddress != "" {
+		details = append(details, "Property: "+appointment.PropertyAddress)
+	}
+	if appointment.Notes != "" {
+		details = append(details, appointment.Notes)
+	}
+
+	location := appointment.Location
+	if location == "" {
+		location = appointment.PropertyAddress
+	}
+
+	status := "TENTATIVE"
+	switch appointment.Status {
+	case "confirmed":
+		status = "CONFIRMED"
+	case "cancelled", "no_show":
+		status = "CANCELLED"
+	}
+
+	return ICSEvent{
+		UID:          fmt.Sprintf("%s@real-estate-crm", appointment.ID),
+		Start:        appointment.StartAt,
+		End:          appointment.EndAt,
+		Summary:      summary,
+		Description:  strings.Join(details, "\n"),
+		Location:     location,
+		Status:       status,
+		LastModified: appointment.UpdatedAt,
+	}
+}
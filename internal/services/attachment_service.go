@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxAttachmentSize is the largest single file that can be attached to a template
+	MaxAttachmentSize = 10 * 1024 * 1024
+	// MaxTemplateAttachmentsSize caps a template's attachments combined. Base64 encoding adds
+	// about a third, which keeps messages under the common 20 MB provider limit.
+	MaxTemplateAttachmentsSize = 15 * 1024 * 1024
+	// MaxTemplateAttachments is the most files a template can carry
+	MaxTemplateAttachments = 5
+)
+
+// AllowedAttachmentTypes are the content types accepted for template attachments
+var AllowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+}
+
+var (
+	ErrAttachmentEmpty          = errors.New("file is empty")
+	ErrAttachmentTooLarge       = fmt.Errorf("file exceeds the %d MB attachment limit", MaxAttachmentSize/(1024*1024))
+	ErrAttachmentTypeNotAllowed = errors.New("only PDF, JPEG, PNG, GIF and WebP files can be attached")
+	ErrAttachmentLimitReached   = fmt.Errorf("a template can have at most %d attachments totalling %d MB", MaxTemplateAttachments, MaxTemplateAttachmentsSize/(1024*1024))
+)
+
+type AttachmentService struct {
+	attachmentRepo *repository.EmailTemplateAttachmentRepository
+	versionRepo    *repository.EmailTemplateVersionRepository
+	storage        FileStorage
+}
+
+func NewAttachmentService() *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: &repository.EmailTemplateAttachmentRepository{},
+		versionRepo:    &repository.EmailTemplateVersionRepository{},
+		storage:        NewLocalFileStorage(),
+	}
+}
+
+// DetectAttachmentType sniffs a file's content type from its bytes, ignoring whatever the
+// client claimed, and checks it against AllowedAttachmentTypes
+func DetectAttachmentType(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", ErrAttachmentEmpty
+	}
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	if !AllowedAttachmentTypes[contentType] {
+		return "", ErrAttachmentTypeNotAllowed
+	}
+	return contentType, nil
+}
+
+// CleanAttachmentName reduces an uploaded file name to a safe base name for email headers
+func CleanAttachmentName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if len(name) > 200 {
+		ext := filepath.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:200-len(ext)], "") + ext
+	}
+	return name
+}
+
+// Upload validates and stores a file as an attachment of a template
+func (s *AttachmentService) Upload(template *models.EmailTemplate, fileName string, data []byte, uploadedBy string) (*models.EmailTemplateAttachment, error) {
+	if len(data) > MaxAttachmentSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	contentType, err := DetectAttachmentType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.attachmentRepo.FindByTemplate(template.ID, template.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	total := int64(len(data))
+	for _, attachment := range existing {
+		total += attachment.SizeBytes
+	}
+	if len(existing) >= MaxTemplateAttachments || total > MaxTemplateAttachmentsSize {
+		return nil, ErrAttachmentLimitReached
+	}
+
+	attachment := &models.EmailTemplateAttachment{
+		TemplateID:     template.ID,
+		OrganizationID: template.OrganizationID,
+		FileName:       CleanAttachmentName(fileName),
+		ContentType:    contentType,
+		SizeBytes:      int64(len(data)),
+		StorageKey:     fmt.Sprintf("templates/%s/%s/%s", template.OrganizationID, template.ID, uuid.NewString()),
+		UploadedBy:     uploadedBy,
+	}
+
+	if err := s.storage.Save(attachment.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		s.storage.Delete(attachment.StorageKey)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// Delete removes an attachment from its template. The stored file is kept for the template
+// versions that recorded it.
+func (s *AttachmentService) Delete(attachment *models.EmailTemplateAttachment) error {
+	return s.attachmentRepo.Delete(attachment.ID, attachment.OrganizationID)
+}
+
+// DeleteForTemplate permanently removes every attachment a template has had, with the stored files
+func (s *AttachmentService) DeleteForTemplate(templateID, orgID string) error {
+	attachments, err := s.attachmentRepo.FindAllByTemplate(templateID, orgID)
+	if err != nil {
+		return err
+	}
+	if err := s.attachmentRepo.DeleteByTemplate(templateID, orgID); err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if err := s.storage.Delete(attachment.StorageKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadForTemplate reads the attachments of the template's version into memory, ready to add to
+// messages. A template resolved at a pinned version gets the files that version recorded.
+func (s *AttachmentService) LoadForTemplate(template *models.EmailTemplate) ([]EmailAttachment, error) {
+	attachments, err := s.attachmentRepo.FindByTemplate(template.ID, template.OrganizationID)
+	if template.CurrentVersion > 0 {
+		var version *models.EmailTemplateVersion
+		version, err = s.versionRepo.FindByVersion(template.ID, template.OrganizationID, template.CurrentVersion)
+		if err == nil {
+			attachments, err = s.attachmentRepo.FindByIDs(version.AttachmentIDs, template.ID, template.OrganizationID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]EmailAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		f, err := s.storage.Open(attachment.StorageKey)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", attachment.FileName, err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", attachment.FileName, err)
+		}
+		files = append(files, EmailAttachment{
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Data:        data,
+		})
+	}
+	return files, nil
+}
@@ -2,34 +2,79 @@ package services
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/google/uuid"
 )
 
 type BackgroundJobService struct {
-	jobRepo         *repository.BackgroundJobRepository
-	contactRepo     *repository.ContactRepository
-	campaignRepo    *repository.CampaignRepository
-	campaignLogRepo *repository.CampaignLogRepository
-	templateRepo    *repository.EmailTemplateRepository
-	emailService    *EmailService
-	notifService    *NotificationService
-	contactService  *ContactService
+	jobRepo             *repository.BackgroundJobRepository
+	contactRepo         *repository.ContactRepository
+	campaignRepo        *repository.CampaignRepository
+	campaignLogRepo     *repository.CampaignLogRepository
+	templateService     *EmailTemplateService
+	emailService        *EmailService
+	notifService        *NotificationService
+	contactService      *ContactService
+	routingService      *LeadRoutingService
+	taskRepo            *repository.TaskRepository
+	orgRepo             *repository.OrganizationRepository
+	settingsService     *OrganizationSettingsService
+	userRepo            *repository.UserRepository
+	senderService       *SenderIdentityService
+	attachmentService   *AttachmentService
+	partialRepo         *repository.TemplatePartialRepository
+	variantRepo         *repository.EmailTemplateVariantRepository
+	campaignVariantRepo *repository.CampaignVariantRepository
+	previewRepo         *repository.CampaignPreviewRepository
+	sequenceRepo        *repository.SequenceRepository
+	enrollmentRepo      *repository.SequenceEnrollmentRepository
+	sequenceMessageRepo *repository.SequenceMessageRepository
+	sequenceService     *SequenceService
+	audienceRepo        *repository.AudienceRepository
+	stageHistoryRepo    *repository.ContactStageHistoryRepository
+	activityRepo        *repository.ActivityRepository
+	dealRepo            *repository.DealRepository
+	pipelineService     *PipelineService
 }
 
 func NewBackgroundJobService() *BackgroundJobService {
 	return &BackgroundJobService{
-		jobRepo:         &repository.BackgroundJobRepository{},
-		contactRepo:     &repository.ContactRepository{},
-		campaignRepo:    &repository.CampaignRepository{},
-		campaignLogRepo: &repository.CampaignLogRepository{},
-		templateRepo:    &repository.EmailTemplateRepository{},
-		emailService:    NewEmailService(),
-		notifService:    NewNotificationService(),
-		contactService:  NewContactService(),
+		jobRepo:             &repository.BackgroundJobRepository{},
+		contactRepo:         &repository.ContactRepository{},
+		campaignRepo:        &repository.CampaignRepository{},
+		campaignLogRepo:     &repository.CampaignLogRepository{},
+		templateService:     NewEmailTemplateService(),
+		emailService:        NewEmailService(),
+		notifService:        NewNotificationService(),
+		contactService:      NewContactService(),
+		routingService:      NewLeadRoutingService(),
+		taskRepo:            &repository.TaskRepository{},
+		orgRepo:             &repository.OrganizationRepository{},
+		settingsService:     NewOrganizationSettingsService(),
+		userRepo:            &repository.UserRepository{},
+		senderService:       NewSenderIdentityService(),
+		attachmentService:   NewAttachmentService(),
+		partialRepo:         &repository.TemplatePartialRepository{},
+		variantRepo:         &repository.EmailTemplateVariantRepository{},
+		campaignVariantRepo: &repository.CampaignVariantRepository{},
+		previewRepo:         &repository.CampaignPreviewRepository{},
+		sequenceRepo:        &repository.SequenceRepository{},
+		enrollmentRepo:      &repository.SequenceEnrollmentRepository{},
+		sequenceMessageRepo: &repository.SequenceMessageRepository{},
+		sequenceService:     NewSequenceService(),
+		audienceRepo:        &repository.AudienceRepository{},
+		stageHistoryRepo:    &repository.ContactStageHistoryRepository{},
+		activityRepo:        &repository.ActivityRepository{},
+		dealRepo:            &repository.DealRepository{},
+		pipelineService:     NewPipelineService(),
 	}
 }
 
@@ -83,6 +128,12 @@ func (s *BackgroundJobService) ProcessCSVImport(jobID, orgID, userID string, csv
 		return
 	}
 
+	// Hand imported leads to agents using the organization's routing rules
+	routedCount, err := s.routingService.RouteContacts(orgID, contacts)
+	if err != nil {
+		log.Printf("CSV Import lead routing failed: %v", err)
+	}
+
 	// Update job progress and finish
 	if job != nil {
 		job.ProcessedRecords = &successCount
@@ -92,6 +143,7 @@ func (s *BackgroundJobService) ProcessCSVImport(jobID, orgID, userID string, csv
 
 	// Notify user
 	s.notifService.NotifyCSVImportCompleted(orgID, userID, successCount)
+	log.Printf("CSV Import routed %d contacts to agents", routedCount)
 	log.Printf("CSV Import completed: %d imported, %d skipped", successCount, skipCount)
 }
 
@@ -125,8 +177,11 @@ func (s *BackgroundJobService) ProcessCampaignRun(jobID, campaignID string) {
 	if campaign.ScheduleType == "once" && campaign.LastRunAt != nil {
 		s.FailJob(jobID, "One-time campaign already executed")
 		log.Printf("Skipping one-time campaign that already ran: %s", campaignID)
-		// Update status to completed if not already
-		if campaign.Status != "completed" {
+		// Update status to completed if not already; a campaign resumed with deferred emails
+		// left stays running until ProcessDeferredCampaignEmails sends them
+		if pending, _ := s.campaignLogRepo.CountPendingDeferred(campaignID); pending > 0 {
+			s.campaignRepo.UpdateStatus(campaignID, "running")
+		} else if campaign.Status != "completed" {
 			campaign.Status = "completed"
 			s.campaignRepo.Update(campaign)
 		}
@@ -136,88 +191,48 @@ func (s *BackgroundJobService) ProcessCampaignRun(jobID, campaignID string) {
 	// Update campaign status to running
 	s.campaignRepo.UpdateStatus(campaignID, "running")
 
-	// Get template
-	template, err := s.templateRepo.FindByID(campaign.TemplateID, campaign.OrganizationID)
+	run, err := s.newCampaignRun(campaign)
 	if err != nil {
-		s.FailJob(jobID, "Template not found")
+		s.FailJob(jobID, err.Error())
 		s.campaignRepo.UpdateStatus(campaignID, "failed")
 		return
 	}
 
-	// Get recipient contacts
-	contactIDs, err := s.campaignRepo.GetRecipientContacts(campaign)
+	// Parse every template once; a syntax error fails the whole run rather than every email
+	contents, err := s.loadCampaignContents(run)
 	if err != nil {
-		s.FailJob(jobID, "Failed to get recipients")
+		s.FailJob(jobID, err.Error())
 		s.campaignRepo.UpdateStatus(campaignID, "failed")
 		return
 	}
 
-	if len(contactIDs) == 0 {
-		s.FailJob(jobID, "No recipients found")
-		s.campaignRepo.UpdateStatus(campaignID, "completed")
-		return
-	}
-
-	// Get contact details
-	contacts, err := s.contactRepo.FindByIDs(contactIDs, campaign.OrganizationID)
+	plan, err := s.planCampaignRun(run, contents)
 	if err != nil {
-		s.FailJob(jobID, "Failed to fetch contact details")
-		s.campaignRepo.UpdateStatus(campaignID, "failed")
+		s.FailJob(jobID, err.Error())
+		if errors.Is(err, errNoCampaignRecipients) {
+			s.campaignRepo.UpdateStatus(campaignID, "completed")
+		} else {
+			s.campaignRepo.UpdateStatus(campaignID, "failed")
+		}
 		return
 	}
+	recipients, assignments := plan.recipients, plan.assignments
 
 	// Update job total records
 	job, _ := s.jobRepo.FindByID(jobID)
 	if job != nil {
-		totalRecords := len(contacts)
+		totalRecords := plan.contacts
+		if campaign.VariantMode == VariantModeTestWinner {
+			totalRecords = len(assignments)
+		}
 		job.TotalRecords = &totalRecords
 		s.jobRepo.Update(job)
 	}
 
 	// Send emails to each contact
 	sentCount := 0
-	for _, contact := range contacts {
-		// Skip contacts without email
-		if contact.Email == "" {
-			continue
-		}
-
-		// Create campaign log entry
-		campaignLog := models.CampaignLog{
-			CampaignID:     campaignID,
-			ContactID:      contact.ID,
-			RecipientEmail: contact.Email,
-			Subject:        template.Subject,
-			Status:         "queued",
-		}
-		s.campaignLogRepo.Create(&campaignLog)
-
-		// Substitute template variables
-		variables := map[string]string{
-			"first_name": contact.FirstName,
-			"last_name":  contact.LastName,
-			"email":      contact.Email,
-			"phone":      contact.Phone,
-		}
-
-		htmlBody := SubstituteTemplateVariables(template.HtmlBody, variables)
-		plainTextBody := SubstituteTemplateVariables(template.PlainTextBody, variables)
-		subject := SubstituteTemplateVariables(template.Subject, variables)
-
-		// Send email (no fromName/replyTo - using SMTP_FROM from config)
-		err := s.emailService.SendCampaignEmail(
-			contact.Email,
-			subject,
-			htmlBody,
-			plainTextBody,
-		)
-
-		if err != nil {
-			// Update log as failed
-			s.campaignLogRepo.UpdateStatus(campaignLog.ID, "failed", err.Error())
-		} else {
-			// Update log as sent
-			s.campaignLogRepo.UpdateStatus(campaignLog.ID, "sent", "")
+	for i, content := range assignments {
+		if s.sendCampaignEmail(run, content, &recipients[i]) {
 			sentCount++
 		}
 	}
@@ -233,6 +248,32 @@ func (s *BackgroundJobService) ProcessCampaignRun(jobID, campaignID string) {
 	now := time.Now()
 	campaign.LastRunAt = &now
 
+	// Emails the send window held back go out later from ProcessDeferredCampaignEmails
+	pending, err := s.campaignLogRepo.CountPendingDeferred(campaignID)
+	if err != nil {
+		log.Printf("Error counting deferred emails for campaign %s: %v", campaignID, err)
+	}
+
+	// A test sample waits for the winner; the rest of the audience is sent by ProcessCampaignWinner.
+	// The test clock only starts once the whole sample is out.
+	if campaign.VariantMode == VariantModeTestWinner {
+		campaign.Status = "testing"
+		if pending == 0 {
+			campaign.TestSentAt = &now
+		}
+		s.campaignRepo.Update(campaign)
+		log.Printf("Campaign %s test sent: %d emails, %d deferred, picking a winner %d hours after the last", campaignID, sentCount, pending, campaign.TestDurationHours)
+		return
+	}
+
+	// A one-time campaign stays running until its deferred emails are sent
+	if campaign.ScheduleType == "once" && pending > 0 {
+		campaign.Status = "running"
+		s.campaignRepo.Update(campaign)
+		log.Printf("Campaign %s: %d emails sent, %d deferred by the send window", campaignID, sentCount, pending)
+		return
+	}
+
 	// For one-time campaigns, mark as completed
 	// Update status after success
 	if campaign.ScheduleType == "once" {
@@ -248,6 +289,544 @@ func (s *BackgroundJobService) ProcessCampaignRun(jobID, campaignID string) {
 	log.Printf("Campaign %s completed: %d emails sent", campaignID, sentCount)
 }
 
+// ProcessCampaignWinner finishes a test-then-winner campaign: it picks the variant with the
+// best open or click rate from the test sample and sends it to everyone not yet emailed
+func (s *BackgroundJobService) ProcessCampaignWinner(jobID, campaignID string) {
+	s.StartJob(jobID)
+
+	campaign, err := s.campaignRepo.FindByIDOnly(campaignID)
+	if err != nil {
+		s.FailJob(jobID, "Campaign not found")
+		return
+	}
+
+	if campaign.VariantMode != VariantModeTestWinner || campaign.WinnerVariantID != nil {
+		s.FailJob(jobID, "Campaign is not waiting for a test winner")
+		return
+	}
+
+	s.campaignRepo.UpdateStatus(campaignID, "running")
+
+	run, err := s.newCampaignRun(campaign)
+	if err != nil {
+		s.FailJob(jobID, err.Error())
+		s.campaignRepo.UpdateStatus(campaignID, "failed")
+		return
+	}
+
+	variants, err := s.campaignVariantRepo.FindByCampaign(campaignID)
+	if err != nil || len(variants) == 0 {
+		s.FailJob(jobID, "Failed to load campaign variants")
+		s.campaignRepo.UpdateStatus(campaignID, "failed")
+		return
+	}
+
+	stats, err := s.campaignLogRepo.GetVariantStatsByCampaign(campaignID)
+	if err != nil {
+		s.FailJob(jobID, "Failed to load test results")
+		s.campaignRepo.UpdateStatus(campaignID, "failed")
+		return
+	}
+
+	winner := PickWinner(variants, stats, campaign.WinnerMetric)
+	template, err := s.templateService.ResolveForVariant(winner)
+	if err != nil {
+		s.FailJob(jobID, "Template not found for variant "+winner.Label)
+		s.campaignRepo.UpdateStatus(campaignID, "failed")
+		return
+	}
+	content, err := s.compileCampaignContent(run.sendContext, template, winner)
+	if err != nil {
+		s.FailJob(jobID, err.Error())
+		s.campaignRepo.UpdateStatus(campaignID, "failed")
+		return
+	}
+
+	s.campaignVariantRepo.MarkWinner(campaignID, winner.ID)
+	campaign.WinnerVariantID = &winner.ID
+
+	// The remainder is everyone in the audience now who wasn't in the test sample
+	contactIDs, err := s.campaignRepo.GetRecipientContacts(campaign)
+	if err != nil {
+		s.FailJob(jobID, "Failed to get recipients")
+		s.campaignRepo.UpdateStatus(campaignID, "failed")
+		return
+	}
+	alreadySent, err := s.campaignLogRepo.FindContactIDsByCampaign(campaignID)
+	if err != nil {
+		s.FailJob(jobID, "Failed to load test recipients")
+		s.campaignRepo.UpdateStatus(campaignID, "failed")
+		return
+	}
+	sent := make(map[string]struct{}, len(alreadySent))
+	for _, id := range alreadySent {
+		sent[id] = struct{}{}
+	}
+	remainingIDs := make([]string, 0, len(contactIDs))
+	for _, id := range contactIDs {
+		if _, ok := sent[id]; !ok {
+			remainingIDs = append(remainingIDs, id)
+		}
+	}
+
+	var recipients []models.Contact
+	if len(remainingIDs) > 0 {
+		contacts, err := s.contactRepo.FindByIDs(remainingIDs, campaign.OrganizationID)
+		if err != nil {
+			s.FailJob(jobID, "Failed to fetch contact details")
+			s.campaignRepo.UpdateStatus(campaignID, "failed")
+			return
+		}
+		recipients = s.applyFrequencyCap(run, emailableContacts(contacts))
+	}
+
+	job, _ := s.jobRepo.FindByID(jobID)
+	if job != nil {
+		totalRecords := len(recipients)
+		job.TotalRecords = &totalRecords
+		s.jobRepo.Update(job)
+	}
+
+	sentCount := 0
+	for i := range recipients {
+		if s.sendCampaignEmail(run, content, &recipients[i]) {
+			sentCount++
+		}
+	}
+
+	if job != nil {
+		job.ProcessedRecords = &sentCount
+		s.jobRepo.Update(job)
+	}
+	s.FinishJob(jobID)
+
+	// The campaign stays running until the send window lets its deferred emails out
+	pending, err := s.campaignLogRepo.CountPendingDeferred(campaignID)
+	if err != nil {
+		log.Printf("Error counting deferred emails for campaign %s: %v", campaignID, err)
+	}
+	if pending > 0 {
+		campaign.Status = "running"
+		s.campaignRepo.Update(campaign)
+		log.Printf("Campaign %s: variant %s won, %d emails sent to the remainder, %d deferred", campaignID, winner.Label, sentCount, pending)
+		return
+	}
+
+	campaign.Status = "completed"
+	s.campaignRepo.Update(campaign)
+
+	// The notification counts the test sends too
+	totalSent := sentCount
+	for _, counts := range stats {
+		totalSent += int(counts.Sent)
+	}
+	s.notifService.NotifyCampaignSent(campaign.OrganizationID, campaign.CreatedBy, campaignID, totalSent)
+	log.Printf("Campaign %s completed: variant %s won, %d emails sent to the remainder", campaignID, winner.Label, sentCount)
+}
+
+// errNoCampaignRecipients means a campaign's audience is empty
+var errNoCampaignRecipients = errors.New("No recipients found")
+
+// campaignSendPlan is who one campaign run emails and with which content
+type campaignSendPlan struct {
+	contacts   int // contacts found in the audience, before skipping any
+	recipients []models.Contact
+	// assignments holds the content for each of the first len(assignments) recipients; a
+	// test-then-winner run leaves the rest for the winner, not yet checked against the
+	// frequency cap
+	assignments []*campaignContent
+}
+
+// planCampaignRun loads a campaign's recipients, skips those who can't or shouldn't be
+// emailed, and decides which content each of the rest gets
+func (s *BackgroundJobService) planCampaignRun(run *campaignRun, contents []*campaignContent) (*campaignSendPlan, error) {
+	campaign := run.campaign
+
+	// Get recipient contacts
+	contactIDs, err := s.campaignRepo.GetRecipientContacts(campaign)
+	if err != nil {
+		return nil, errors.New("Failed to get recipients")
+	}
+	if len(contactIDs) == 0 {
+		return nil, errNoCampaignRecipients
+	}
+
+	// Get contact details
+	contacts, err := s.contactRepo.FindByIDs(contactIDs, campaign.OrganizationID)
+	if err != nil {
+		return nil, errors.New("Failed to fetch contact details")
+	}
+
+	// Skip contacts without email or who unsubscribed, then those at the frequency cap
+	if run.preview != nil {
+		for _, contact := range contacts {
+			if reason := contactSkipReason(&contact); reason != "" {
+				run.addPreviewMessage(models.CampaignPreviewMessage{
+					ContactID:      contact.ID,
+					RecipientEmail: contact.Email,
+					Status:         "skipped",
+					SkipReason:     reason,
+				})
+			}
+		}
+	}
+	recipients := emailableContacts(contacts)
+
+	// A/B recipients are shuffled so the split doesn't follow the order contacts were added in
+	if campaign.VariantMode != "" {
+		rand.Shuffle(len(recipients), func(i, j int) { recipients[i], recipients[j] = recipients[j], recipients[i] })
+	}
+
+	// A test-then-winner run only checks its sample against the frequency cap; the remainder
+	// is checked when the winner is sent, by which time the cap window has moved on
+	count := len(recipients)
+	if campaign.VariantMode == VariantModeTestWinner {
+		count = TestSampleSize(len(recipients), campaign.TestPercent, len(contents))
+	}
+	sample := s.applyFrequencyCap(run, recipients[:count])
+	remainder := recipients[count:]
+	recipients = make([]models.Contact, 0, len(sample)+len(remainder))
+	recipients = append(append(recipients, sample...), remainder...)
+
+	// Decide which content each recipient gets
+	assignments := make([]*campaignContent, len(sample))
+	if campaign.VariantMode == "" {
+		for i := range assignments {
+			assignments[i] = contents[0]
+		}
+	} else {
+		percents := make([]int, len(contents))
+		for i, content := range contents {
+			percents[i] = content.splitPercent
+		}
+		for i, variant := range SplitRecipients(len(sample), percents) {
+			assignments[i] = contents[variant]
+		}
+	}
+
+	return &campaignSendPlan{contacts: len(contacts), recipients: recipients, assignments: assignments}, nil
+}
+
+// sendContext holds what every email an organization sends on behalf of one agent shares
+type sendContext struct {
+	orgID           string
+	org             *models.Organization
+	agent           *models.User
+	brand           *models.OrganizationSettings
+	verifiedSenders map[string]bool
+	partials        []models.TemplatePartial
+}
+
+// campaignRun holds what every email in one campaign run shares
+type campaignRun struct {
+	*sendContext
+	campaign *models.Campaign
+	window   *SendWindow
+
+	// preview collects the messages of a dry run, which renders every email without logging
+	// or sending it
+	preview *models.CampaignPreview
+}
+
+// campaignContent is a template compiled for sending: the campaign's own template or one of
+// its A/B variants
+type campaignContent struct {
+	variantID    *string
+	splitPercent int
+	template     *models.EmailTemplate
+	localized    *LocalizedEmail
+	attachments  []EmailAttachment
+}
+
+// newCampaignRun loads the organization, sending agent, brand kit, partials and verified
+// senders for a campaign run
+func (s *BackgroundJobService) newCampaignRun(campaign *models.Campaign) (*campaignRun, error) {
+	sc, err := s.newSendContext(campaign.OrganizationID, campaign.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &campaignRun{sendContext: sc, campaign: campaign, window: NewSendWindow(sc.brand)}, nil
+}
+
+// newSendContext loads the organization, sending agent, brand kit, partials and verified
+// senders for emails sent on behalf of an agent
+func (s *BackgroundJobService) newSendContext(orgID, agentID string) (*sendContext, error) {
+	sc := &sendContext{orgID: orgID}
+
+	// Partials are resolved now, so edits to a shared footer reach every campaign
+	partials, err := s.partialRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return nil, errors.New("Failed to load template partials")
+	}
+	sc.partials = partials
+
+	// Organization and sending agent are the same for every recipient
+	if orgUUID, err := uuid.Parse(orgID); err == nil {
+		sc.org, _ = s.orgRepo.FindByID(orgUUID)
+	}
+	if agentUUID, err := uuid.Parse(agentID); err == nil {
+		sc.agent, _ = s.userRepo.FindByID(agentUUID)
+	}
+	sc.brand, _ = s.settingsService.GetSettings(orgID)
+
+	// Reply-To addresses must be verified sender identities
+	verifiedSenders, err := s.senderService.VerifiedAddresses(orgID)
+	if err != nil {
+		return nil, errors.New("Failed to load sender identities")
+	}
+	sc.verifiedSenders = verifiedSenders
+
+	return sc, nil
+}
+
+// loadCampaignContents compiles the campaign's template, or each of its A/B variants
+func (s *BackgroundJobService) loadCampaignContents(run *campaignRun) ([]*campaignContent, error) {
+	campaign := run.campaign
+
+	if campaign.VariantMode == "" {
+		// Get template content, at the campaign's pinned version unless it follows the latest
+		template, err := s.templateService.ResolveForCampaign(campaign)
+		if err != nil {
+			return nil, errors.New("Template not found")
+		}
+		content, err := s.compileCampaignContent(run.sendContext, template, nil)
+		if err != nil {
+			return nil, err
+		}
+		return []*campaignContent{content}, nil
+	}
+
+	variants, err := s.campaignVariantRepo.FindByCampaign(campaign.ID)
+	if err != nil || len(variants) == 0 {
+		return nil, errors.New("Failed to load campaign variants")
+	}
+
+	contents := make([]*campaignContent, 0, len(variants))
+	for i := range variants {
+		template, err := s.templateService.ResolveForVariant(&variants[i])
+		if err != nil {
+			return nil, errors.New("Template not found for variant " + variants[i].Label)
+		}
+		content, err := s.compileCampaignContent(run.sendContext, template, &variants[i])
+		if err != nil {
+			return nil, fmt.Errorf("Variant %s: %w", variants[i].Label, err)
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// compileCampaignContent parses a template with its locale variants and loads its attachments
+func (s *BackgroundJobService) compileCampaignContent(sc *sendContext, template *models.EmailTemplate, variant *models.CampaignVariant) (*campaignContent, error) {
+	content := &campaignContent{template: template}
+	if variant != nil {
+		content.variantID = &variant.ID
+		content.splitPercent = variant.SplitPercent
+	}
+
+	// Each contact gets the variant for their preferred language, or the template default
+	localeVariants, err := s.variantRepo.FindByTemplate(template.ID, sc.orgID)
+	if err != nil {
+		return nil, errors.New("Failed to load template variants")
+	}
+
+	content.localized, err = CompileLocalizedEmail(template, localeVariants, sc.partials)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid template: %w", err)
+	}
+
+	// Attachments are read once and added to every message; a pinned version sends the files it recorded
+	content.attachments, err = s.attachmentService.LoadForTemplate(template)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load attachments: %w", err)
+	}
+	return content, nil
+}
+
+// sendCampaignEmail renders, logs and sends one campaign email and reports whether it was sent
+func (s *BackgroundJobService) sendCampaignEmail(run *campaignRun, content *campaignContent, contact *models.Contact) bool {
+	return s.deliverCampaignEmail(run, content, contact, &models.CampaignLog{
+		CampaignID: run.campaign.ID,
+		ContactID:  contact.ID,
+		VariantID:  content.variantID,
+	})
+}
+
+// deliverCampaignEmail renders and sends the campaign email recorded by campaignLog: a new
+// entry, or a deferred one that is now due. An email that would land outside the send window
+// is saved as deferred until the next allowed time instead.
+func (s *BackgroundJobService) deliverCampaignEmail(run *campaignRun, content *campaignContent, contact *models.Contact, campaignLog *models.CampaignLog) bool {
+	now := time.Now()
+	var deferredUntil *time.Time
+	if sendAt := run.window.NextSendTime(now, contact.Timezone); sendAt.After(now) {
+		deferredUntil = &sendAt
+	}
+
+	campaignLog.RecipientEmail = contact.Email
+	if deferredUntil != nil && run.preview == nil {
+		campaignLog.Subject = content.template.Subject
+		campaignLog.Status = "deferred"
+		campaignLog.DeferredUntil = deferredUntil
+		s.saveCampaignLog(campaignLog)
+		return false
+	}
+
+	compiled, locale := content.localized.For(contact.PreferredLanguage)
+	rendered, renderErr := compiled.Render(BuildTemplateData(contact, run.org, run.agent, run.brand))
+	if renderErr == nil {
+		renderErr = CheckSender(rendered.FromName, rendered.ReplyTo, run.verifiedSenders)
+	}
+
+	if run.preview != nil {
+		message := models.CampaignPreviewMessage{
+			ContactID:      contact.ID,
+			RecipientEmail: contact.Email,
+			Subject:        content.template.Subject,
+			Locale:         locale,
+			VariantID:      content.variantID,
+			Status:         "rendered",
+			DeferredUntil:  deferredUntil,
+		}
+		if rendered != nil {
+			message.Subject = rendered.Subject
+			message.FromName = rendered.FromName
+			message.ReplyTo = rendered.ReplyTo
+		}
+		if renderErr != nil {
+			message.Status = "failed"
+			message.ErrorMessage = renderErr.Error()
+		}
+		run.addPreviewMessage(message)
+		return renderErr == nil
+	}
+
+	// Create or update the campaign log entry
+	campaignLog.Subject = content.template.Subject
+	campaignLog.Locale = locale
+	campaignLog.Status = "queued"
+	if rendered != nil {
+		campaignLog.Subject = rendered.Subject
+	}
+	s.saveCampaignLog(campaignLog)
+
+	if renderErr != nil {
+		s.campaignLogRepo.UpdateStatus(campaignLog.ID, "failed", renderErr.Error())
+		return false
+	}
+
+	// Send email from SMTP_FROM with the template's display name and Reply-To
+	message := rendered.Message(contact.Email)
+	message.HtmlBody = AddTracking(message.HtmlBody, campaignLog.ID)
+	message.ListUnsubscribe = BuildUnsubscribeURL(contact.ID)
+	message.Attachments = content.attachments
+	if err := s.emailService.SendMessage(message); err != nil {
+		// Update log as failed
+		s.campaignLogRepo.UpdateStatus(campaignLog.ID, "failed", err.Error())
+		return false
+	}
+
+	// Update log as sent
+	s.campaignLogRepo.UpdateStatus(campaignLog.ID, "sent", "")
+	return true
+}
+
+// saveCampaignLog creates a new campaign log entry or updates a deferred one
+func (s *BackgroundJobService) saveCampaignLog(campaignLog *models.CampaignLog) {
+	if campaignLog.ID == "" {
+		s.campaignLogRepo.Create(campaignLog)
+		return
+	}
+	s.campaignLogRepo.Update(campaignLog)
+}
+
+// FrequencyCapWindow is the rolling window the organization's campaign frequency cap covers
+const FrequencyCapWindow = 7 * 24 * time.Hour
+
+// applyFrequencyCap drops recipients who were already sent the organization's cap of
+// campaign emails within the FrequencyCapWindow, logging each of them as skipped
+func (s *BackgroundJobService) applyFrequencyCap(run *campaignRun, recipients []models.Contact) []models.Contact {
+	if run.brand == nil || run.brand.CampaignFrequencyCap <= 0 || len(recipients) == 0 {
+		return recipients
+	}
+	limit := int64(run.brand.CampaignFrequencyCap)
+
+	contactIDs := make([]string, len(recipients))
+	for i, contact := range recipients {
+		contactIDs[i] = contact.ID
+	}
+	counts, err := s.campaignLogRepo.CountSentSince(contactIDs, time.Now().Add(-FrequencyCapWindow))
+	if err != nil {
+		log.Printf("Error checking frequency cap for campaign %s: %v", run.campaign.ID, err)
+		return recipients
+	}
+
+	allowed := make([]models.Contact, 0, len(recipients))
+	for _, contact := range recipients {
+		if counts[contact.ID] < limit {
+			allowed = append(allowed, contact)
+			continue
+		}
+		reason := fmt.Sprintf("Already sent %d campaign emails in the last 7 days", counts[contact.ID])
+		if run.preview != nil {
+			run.addPreviewMessage(models.CampaignPreviewMessage{
+				ContactID:      contact.ID,
+				RecipientEmail: contact.Email,
+				Status:         "skipped",
+				SkipReason:     "frequency_cap",
+				ErrorMessage:   reason,
+			})
+			continue
+		}
+		s.campaignLogRepo.Create(&models.CampaignLog{
+			CampaignID:     run.campaign.ID,
+			ContactID:      contact.ID,
+			RecipientEmail: contact.Email,
+			Status:         "skipped",
+			SkipReason:     "frequency_cap",
+			ErrorMessage:   reason,
+		})
+	}
+	return allowed
+}
+
+// emailableContacts drops contacts without an email address and those who unsubscribed
+func emailableContacts(contacts []models.Contact) []models.Contact {
+	recipients := make([]models.Contact, 0, len(contacts))
+	for _, contact := range contacts {
+		if contactSkipReason(&contact) == "" {
+			recipients = append(recipients, contact)
+		}
+	}
+	return recipients
+}
+
+// contactSkipReason says why a contact can't be emailed: no_email or unsubscribed
+func contactSkipReason(contact *models.Contact) string {
+	switch {
+	case contact.Email == "":
+		return "no_email"
+	case contact.UnsubscribedAt != nil:
+		return "unsubscribed"
+	}
+	return ""
+}
+
+// QueueCampaignRun creates a campaign_run job and executes it in a goroutine
+func (s *BackgroundJobService) QueueCampaignRun(campaign *models.Campaign) error {
+	job := models.BackgroundJobLog{
+		JobType:        "campaign_run",
+		OrganizationID: campaign.OrganizationID,
+		ReferenceID:    &campaign.ID,
+		Status:         "queued",
+	}
+	if err := s.jobRepo.Create(&job); err != nil {
+		return err
+	}
+
+	go s.ProcessCampaignRun(job.ID, campaign.ID)
+	return nil
+}
+
 // ProcessCampaignScheduler checks for due campaigns and queues them
 func (s *BackgroundJobService) ProcessCampaignScheduler() {
 	log.Println("Running campaign scheduler...")
@@ -268,22 +847,48 @@ func (s *BackgroundJobService) ProcessCampaignScheduler() {
 			continue
 		}
 
+		// Claim it first; a campaign sent by hand in the meantime is already running
+		claimed, err := s.campaignRepo.ClaimForRun(campaign.ID, "scheduled")
+		if err != nil || !claimed {
+			continue
+		}
+
 		// Create background job for campaign execution
+		if err := s.QueueCampaignRun(&campaign); err != nil {
+			log.Printf("Error creating job for campaign %s: %v", campaign.ID, err)
+			s.campaignRepo.UpdateStatus(campaign.ID, "scheduled")
+		}
+	}
+
+	// Pick winners for A/B tests whose test period is over
+	testingCampaigns, err := s.campaignRepo.FindTestingCampaigns()
+	if err != nil {
+		log.Printf("Error finding campaigns in testing: %v", err)
+	}
+	for _, campaign := range testingCampaigns {
+		if currentTime.Before(campaign.TestSentAt.Add(time.Duration(campaign.TestDurationHours) * time.Hour)) {
+			continue
+		}
+
 		job := models.BackgroundJobLog{
-			JobType:        "campaign_run",
+			JobType:        "campaign_winner",
 			OrganizationID: campaign.OrganizationID,
 			ReferenceID:    &campaign.ID,
 			Status:         "queued",
 		}
 		if err := s.jobRepo.Create(&job); err != nil {
-			log.Printf("Error creating job for campaign %s: %v", campaign.ID, err)
+			log.Printf("Error creating winner job for campaign %s: %v", campaign.ID, err)
 			continue
 		}
 
-		// Execute campaign in goroutine
-		go s.ProcessCampaignRun(job.ID, campaign.ID)
+		// Claim the campaign now so the next tick doesn't queue it again
+		s.campaignRepo.UpdateStatus(campaign.ID, "running")
+		go s.ProcessCampaignWinner(job.ID, campaign.ID)
 	}
 
+	// Send emails the send window held back whose time has come
+	s.queueDeferredCampaignEmails(currentTime)
+
 	// Handle recurring campaigns
 	recurringCampaigns, err := s.campaignRepo.FindRecurringCampaigns()
 	if err != nil {
@@ -307,23 +912,303 @@ func (s *BackgroundJobService) ProcessCampaignScheduler() {
 		// Check if it's time to run based on recurrence settings
 		if s.shouldRunRecurringCampaign(&campaign, currentTime) {
 			// Create background job
-			job := models.BackgroundJobLog{
-				JobType:        "campaign_run",
-				OrganizationID: campaign.OrganizationID,
-				ReferenceID:    &campaign.ID,
-				Status:         "queued",
-			}
-			if err := s.jobRepo.Create(&job); err != nil {
+			if err := s.QueueCampaignRun(&campaign); err != nil {
 				log.Printf("Error creating job for recurring campaign %s: %v", campaign.ID, err)
+			}
+		}
+	}
+
+	log.Println("Campaign scheduler completed")
+}
+
+// queueDeferredCampaignEmails claims every deferred campaign email that is now due and
+// starts one campaign_deferred job per campaign to send them
+func (s *BackgroundJobService) queueDeferredCampaignEmails(currentTime time.Time) {
+	dueLogs, err := s.campaignLogRepo.FindDueDeferred(currentTime)
+	if err != nil {
+		log.Printf("Error finding deferred campaign emails: %v", err)
+		return
+	}
+
+	// Claim each email so the next tick doesn't pick it up again
+	byCampaign := make(map[string][]models.CampaignLog)
+	var campaignIDs []string
+	for _, campaignLog := range dueLogs {
+		claimed, err := s.campaignLogRepo.ClaimDeferred(campaignLog.ID)
+		if err != nil || !claimed {
+			continue
+		}
+		if _, ok := byCampaign[campaignLog.CampaignID]; !ok {
+			campaignIDs = append(campaignIDs, campaignLog.CampaignID)
+		}
+		byCampaign[campaignLog.CampaignID] = append(byCampaign[campaignLog.CampaignID], campaignLog)
+	}
+
+	for _, campaignID := range campaignIDs {
+		logs := byCampaign[campaignID]
+		campaign, err := s.campaignRepo.FindByIDOnly(campaignID)
+		if err != nil {
+			log.Printf("Error loading campaign %s for deferred emails: %v", campaignID, err)
+			s.releaseDeferredCampaignEmails(logs)
+			continue
+		}
+
+		job := models.BackgroundJobLog{
+			JobType:        "campaign_deferred",
+			OrganizationID: campaign.OrganizationID,
+			ReferenceID:    &campaign.ID,
+			Status:         "queued",
+		}
+		if err := s.jobRepo.Create(&job); err != nil {
+			log.Printf("Error creating deferred send job for campaign %s: %v", campaignID, err)
+			s.releaseDeferredCampaignEmails(logs)
+			continue
+		}
+		go s.ProcessDeferredCampaignEmails(job.ID, campaignID, logs)
+	}
+}
+
+// ProcessDeferredCampaignEmails sends a campaign's deferred emails once their time has come.
+// An email still outside the send window, for example after the settings changed, is
+// deferred again.
+func (s *BackgroundJobService) ProcessDeferredCampaignEmails(jobID, campaignID string, logs []models.CampaignLog) {
+	s.StartJob(jobID)
+
+	failAll := func(message string) {
+		for _, campaignLog := range logs {
+			s.campaignLogRepo.UpdateStatus(campaignLog.ID, "failed", message)
+		}
+		s.FailJob(jobID, message)
+		s.finishDeferredCampaign(campaignID)
+	}
+
+	campaign, err := s.campaignRepo.FindByIDOnly(campaignID)
+	if err != nil {
+		failAll("Campaign not found")
+		return
+	}
+
+	run, err := s.newCampaignRun(campaign)
+	if err != nil {
+		s.releaseDeferredCampaignEmails(logs)
+		s.FailJob(jobID, err.Error())
+		return
+	}
+
+	contents, err := s.loadCampaignContents(run)
+	if err != nil {
+		failAll(err.Error())
+		return
+	}
+
+	contactIDs := make([]string, len(logs))
+	for i, campaignLog := range logs {
+		contactIDs[i] = campaignLog.ContactID
+	}
+	contacts, err := s.contactRepo.FindByIDs(contactIDs, campaign.OrganizationID)
+	if err != nil {
+		s.releaseDeferredCampaignEmails(logs)
+		s.FailJob(jobID, "Failed to fetch contact details")
+		return
+	}
+	contactsByID := make(map[string]*models.Contact, len(contacts))
+	for i := range contacts {
+		contactsByID[contacts[i].ID] = &contacts[i]
+	}
+
+	job, _ := s.jobRepo.FindByID(jobID)
+	if job != nil {
+		totalRecords := len(logs)
+		job.TotalRecords = &totalRecords
+		s.jobRepo.Update(job)
+	}
+
+	sentCount := 0
+	for i := range logs {
+		campaignLog := &logs[i]
+
+		// The contact may have unsubscribed or lost their address while the email waited
+		contact := contactsByID[campaignLog.ContactID]
+		if contact == nil {
+			s.campaignLogRepo.UpdateStatus(campaignLog.ID, "failed", "Contact not found")
+			continue
+		}
+		if reason := contactSkipReason(contact); reason != "" {
+			campaignLog.Status = "skipped"
+			campaignLog.SkipReason = reason
+			s.campaignLogRepo.Update(campaignLog)
+			continue
+		}
+
+		// Send the variant the recipient was assigned, or the first if it's gone
+		content := contents[0]
+		for _, candidate := range contents {
+			if campaignLog.VariantID != nil && candidate.variantID != nil && *candidate.variantID == *campaignLog.VariantID {
+				content = candidate
+				break
+			}
+		}
+
+		if s.deliverCampaignEmail(run, content, contact, campaignLog) {
+			sentCount++
+		}
+	}
+
+	if job != nil {
+		job.ProcessedRecords = &sentCount
+		s.jobRepo.Update(job)
+	}
+	s.FinishJob(jobID)
+	log.Printf("Campaign %s: %d deferred emails sent", campaignID, sentCount)
+
+	s.finishDeferredCampaign(campaignID)
+}
+
+// finishDeferredCampaign wraps up a campaign once its last deferred email is out: a test
+// sample starts its clock, and a one-time or winner send left running completes and notifies
+// with everything the campaign sent
+func (s *BackgroundJobService) finishDeferredCampaign(campaignID string) {
+	pending, err := s.campaignLogRepo.CountPendingDeferred(campaignID)
+	if err != nil || pending > 0 {
+		return
+	}
+
+	campaign, err := s.campaignRepo.FindByIDOnly(campaignID)
+	if err != nil {
+		return
+	}
+
+	switch campaign.Status {
+	case "testing":
+		if campaign.TestSentAt == nil {
+			s.campaignRepo.StartTestClock(campaignID, time.Now())
+			log.Printf("Campaign %s test sent, picking a winner in %d hours", campaignID, campaign.TestDurationHours)
+		}
+	case "running":
+		// A recurring run notifies when it finishes
+		if campaign.ScheduleType != "once" && campaign.WinnerVariantID == nil {
+			return
+		}
+		completed, err := s.campaignRepo.CompleteRun(campaignID)
+		if err != nil || !completed {
+			return
+		}
+		stats, err := s.campaignLogRepo.GetStatsByCampaign(campaignID)
+		if err != nil {
+			log.Printf("Error loading stats for campaign %s: %v", campaignID, err)
+		}
+		s.notifService.NotifyCampaignSent(campaign.OrganizationID, campaign.CreatedBy, campaignID, int(stats["sent"]))
+		log.Printf("Campaign %s completed: %d emails sent", campaignID, stats["sent"])
+	}
+}
+
+// releaseDeferredCampaignEmails hands claimed emails back to the next scheduler tick
+func (s *BackgroundJobService) releaseDeferredCampaignEmails(logs []models.CampaignLog) {
+	for _, campaignLog := range logs {
+		s.campaignLogRepo.UpdateStatus(campaignLog.ID, "deferred", "")
+	}
+}
+
+// TaskDueReminderWindow is how far ahead of its due time a task_due reminder is sent
+const TaskDueReminderWindow = time.Hour
+
+// ProcessTaskReminders raises due and overdue task notifications and creates
+// follow-up tasks for contacts that have gone quiet
+func (s *BackgroundJobService) ProcessTaskReminders() {
+	currentTime := time.Now()
+
+	// Tasks coming up within the reminder window
+	dueTasks, err := s.taskRepo.FindDueSoon(currentTime, currentTime.Add(TaskDueReminderWindow))
+	if err != nil {
+		log.Printf("Error finding due tasks: %v", err)
+	} else {
+		for i := range dueTasks {
+			task := &dueTasks[i]
+			if err := s.notifService.NotifyTaskDue(task); err != nil {
+				log.Printf("Failed to notify due task %s: %v", task.ID, err)
 				continue
 			}
+			s.taskRepo.MarkNotified(task.ID, "due_notified_at", currentTime)
+		}
+	}
 
-			// Execute campaign in goroutine
-			go s.ProcessCampaignRun(job.ID, campaign.ID)
+	// Tasks that have passed their due time
+	overdueTasks, err := s.taskRepo.FindOverdue(currentTime)
+	if err != nil {
+		log.Printf("Error finding overdue tasks: %v", err)
+	} else {
+		for i := range overdueTasks {
+			task := &overdueTasks[i]
+			if err := s.notifService.NotifyTaskOverdue(task); err != nil {
+				log.Printf("Failed to notify overdue task %s: %v", task.ID, err)
+				continue
+			}
+			s.taskRepo.MarkNotified(task.ID, "overdue_notified_at", currentTime)
 		}
 	}
 
-	log.Println("Campaign scheduler completed")
+	s.createFollowUpTasks(currentTime)
+}
+
+// FollowUpTaskDueIn gives the agent time to act on an automatic follow-up task before it
+// counts as overdue
+const FollowUpTaskDueIn = 24 * time.Hour
+
+// createFollowUpTasks assigns a follow-up task to the agent of every contact with no
+// activity for the organization's FollowUpAfterDays
+func (s *BackgroundJobService) createFollowUpTasks(currentTime time.Time) {
+	orgs, err := s.orgRepo.FindAll()
+	if err != nil {
+		log.Printf("Error finding organizations for follow-up rule: %v", err)
+		return
+	}
+
+	for _, org := range orgs {
+		if !org.IsActive {
+			continue
+		}
+		orgID := org.ID.String()
+
+		settings, err := s.settingsService.GetSettings(orgID)
+		if err != nil {
+			log.Printf("Error loading settings for org %s: %v", orgID, err)
+			continue
+		}
+		if settings.FollowUpAfterDays <= 0 {
+			continue
+		}
+
+		cutoff := currentTime.AddDate(0, 0, -settings.FollowUpAfterDays)
+		contacts, err := s.contactRepo.FindNeedingFollowUp(orgID, cutoff)
+		if err != nil {
+			log.Printf("Error finding contacts needing follow-up for org %s: %v", orgID, err)
+			continue
+		}
+
+		for i := range contacts {
+			contact := &contacts[i]
+			name := strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+			if name == "" {
+				name = contact.Email
+			}
+
+			task := models.Task{
+				OrganizationID: orgID,
+				AssignedTo:     *contact.AssignedTo,
+				ContactID:      &contact.ID,
+				Title:          fmt.Sprintf("Follow up with %s", name),
+				Description:    fmt.Sprintf("No activity in the last %d days", settings.FollowUpAfterDays),
+				DueAt:          currentTime.Add(FollowUpTaskDueIn),
+				Priority:       "medium",
+				Status:         "open",
+				AutoGenerated:  true,
+				CreatedBy:      *contact.AssignedTo,
+			}
+			if err := s.taskRepo.Create(&task); err != nil {
+				log.Printf("Failed to create follow-up task for contact %s: %v", contact.ID, err)
+			}
+		}
+	}
 }
 
 // shouldRunRecurringCampaign determines if a recurring campaign should run now
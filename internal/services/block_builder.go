@@ -0,0 +1,569 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/datatypes"
+)
+
+// Template editor types
+const (
+	EditorTypeHTML   = "html"
+	EditorTypeBlocks = "blocks"
+)
+
+// Block builder limits
+const (
+	maxBuilderBlocks     = 100
+	maxBuilderTextLength = 10000
+	builderContentWidth  = 600
+	builderPadding       = 24
+)
+
+// BlockDocument is the editable source of a block-built template
+type BlockDocument struct {
+	Settings BlockSettings   `json:"settings"`
+	Blocks   []TemplateBlock `json:"blocks"`
+}
+
+// BlockSettings style the whole email. Colors are hex (#1a73e8) or a template tag such as
+// {{brand.primary_color}}.
+type BlockSettings struct {
+	BackgroundColor string `json:"background_color,omitempty"` // behind the content column
+	ContentColor    string `json:"content_color,omitempty"`    // content column
+	TextColor       string `json:"text_color,omitempty"`
+	PrimaryColor    string `json:"primary_color,omitempty"` // buttons and prices; the brand color by default
+	FontFamily      string `json:"font_family,omitempty"`   // sans-serif or serif
+}
+
+// TemplateBlock is one block of a block-built template. Which fields apply depends on Type:
+//
+//	heading        text, level (1-3), align, color
+//	text           text (blank lines start paragraphs, [label](url) makes a link), align
+//	image          image_url, alt, url (optional link), width, align
+//	button         text, url, align, color
+//	divider
+//	property_card  property
+//	columns        columns (2 or 3 lists of blocks; columns cannot nest)
+type TemplateBlock struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Level    int               `json:"level,omitempty"`
+	Align    string            `json:"align,omitempty"`
+	Color    string            `json:"color,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	ImageURL string            `json:"image_url,omitempty"`
+	Alt      string            `json:"alt,omitempty"`
+	Width    int               `json:"width,omitempty"`
+	Property *PropertyCard     `json:"property,omitempty"`
+	Columns  [][]TemplateBlock `json:"columns,omitempty"`
+}
+
+// PropertyCard is a listing summary. Every field may use template variables.
+type PropertyCard struct {
+	Title       string `json:"title"`
+	ImageURL    string `json:"image_url,omitempty"`
+	Price       string `json:"price,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Bedrooms    string `json:"bedrooms,omitempty"`
+	Bathrooms   string `json:"bathrooms,omitempty"`
+	Area        string `json:"area,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	ButtonText  string `json:"button_text,omitempty"`
+}
+
+var builderFonts = map[string]string{
+	"sans-serif": "Arial, Helvetica, sans-serif",
+	"serif":      "Georgia, 'Times New Roman', serif",
+}
+
+var headingSizes = map[int]int{1: 28, 2: 22, 3: 18}
+
+// ParseBlockDocument decodes and validates block JSON
+func ParseBlockDocument(raw []byte) (*BlockDocument, error) {
+	if len(bytes.TrimSpace(raw)) == 0 || string(bytes.TrimSpace(raw)) == "null" {
+		return nil, errors.New("builder_blocks is required")
+	}
+
+	var doc BlockDocument
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("builder_blocks is not a valid block document: %w", err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Validate checks block types, required fields, colors and links
+func (d *BlockDocument) Validate() error {
+	if len(d.Blocks) == 0 {
+		return errors.New("add at least one block")
+	}
+
+	settings := []struct{ name, value string }{
+		{"background_color", d.Settings.BackgroundColor},
+		{"content_color", d.Settings.ContentColor},
+		{"text_color", d.Settings.TextColor},
+		{"primary_color", d.Settings.PrimaryColor},
+	}
+	for _, setting := range settings {
+		if !isBuilderColor(setting.value) {
+			return fmt.Errorf("settings.%s must be a hex color or a template variable", setting.name)
+		}
+	}
+	if d.Settings.FontFamily != "" && builderFonts[d.Settings.FontFamily] == "" {
+		return errors.New("settings.font_family must be sans-serif or serif")
+	}
+
+	count := 0
+	for i := range d.Blocks {
+		if err := validateBlock(&d.Blocks[i], fmt.Sprintf("blocks[%d]", i), true, &count); err != nil {
+			return err
+		}
+	}
+	if count > maxBuilderBlocks {
+		return fmt.Errorf("a template can have at most %d blocks", maxBuilderBlocks)
+	}
+	return nil
+}
+
+func validateBlock(b *TemplateBlock, path string, allowColumns bool, count *int) error {
+	*count++
+
+	if b.Align != "" && b.Align != "left" && b.Align != "center" && b.Align != "right" {
+		return fmt.Errorf("%s: align must be left, center or right", path)
+	}
+	if !isBuilderColor(b.Color) {
+		return fmt.Errorf("%s: color must be a hex color or a template variable", path)
+	}
+	if len(b.Text) > maxBuilderTextLength {
+		return fmt.Errorf("%s: text is longer than %d characters", path, maxBuilderTextLength)
+	}
+
+	switch b.Type {
+	case "heading":
+		if strings.TrimSpace(b.Text) == "" {
+			return fmt.Errorf("%s: heading needs text", path)
+		}
+		if b.Level == 0 {
+			b.Level = 1
+		}
+		if headingSizes[b.Level] == 0 {
+			return fmt.Errorf("%s: heading level must be 1, 2 or 3", path)
+		}
+	case "text":
+		if strings.TrimSpace(b.Text) == "" {
+			return fmt.Errorf("%s: text block needs text", path)
+		}
+	case "image":
+		if !isBuilderURL(b.ImageURL) {
+			return fmt.Errorf("%s: image needs an absolute http(s) image_url", path)
+		}
+		if b.URL != "" && !isBuilderURL(b.URL) {
+			return fmt.Errorf("%s: url must be an absolute http(s), mailto or tel link", path)
+		}
+		if b.Width < 0 || b.Width > builderContentWidth {
+			return fmt.Errorf("%s: width must be between 1 and %d", path, builderContentWidth)
+		}
+	case "button":
+		if strings.TrimSpace(b.Text) == "" {
+			return fmt.Errorf("%s: button needs text", path)
+		}
+		if !isBuilderURL(b.URL) {
+			return fmt.Errorf("%s: button needs an absolute http(s), mailto or tel url", path)
+		}
+	case "divider":
+	case "property_card":
+		p := b.Property
+		if p == nil || strings.TrimSpace(p.Title) == "" {
+			return fmt.Errorf("%s: property card needs a property with a title", path)
+		}
+		if p.ImageURL != "" && !isBuilderURL(p.ImageURL) {
+			return fmt.Errorf("%s: property image_url must be an absolute http(s) URL", path)
+		}
+		if p.URL != "" && !isBuilderURL(p.URL) {
+			return fmt.Errorf("%s: property url must be an absolute http(s) URL", path)
+		}
+	case "columns":
+		if !allowColumns {
+			return fmt.Errorf("%s: columns cannot be nested", path)
+		}
+		if len(b.Columns) < 2 || len(b.Columns) > 3 {
+			return fmt.Errorf("%s: columns needs 2 or 3 columns", path)
+		}
+		for c, column := range b.Columns {
+			for i := range column {
+				if err := validateBlock(&column[i], fmt.Sprintf("%s.columns[%d][%d]", path, c, i), false, count); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("%s: unknown block type %q", path, b.Type)
+	}
+	return nil
+}
+
+// isBuilderColor accepts an empty value, #RGB, #RRGGBB or a single template tag
+func isBuilderColor(color string) bool {
+	if color == "" || isTemplateTag(color) {
+		return true
+	}
+	_, err := NormalizeBrandColor(color)
+	return err == nil
+}
+
+// isBuilderURL accepts absolute http(s), mailto and tel links, or a single template tag whose
+// value is checked by the url filter when the email is rendered
+func isBuilderURL(link string) bool {
+	link = strings.TrimSpace(link)
+	return isTemplateTag(link) || isSafeLink(link)
+}
+
+// isSafeLink accepts absolute http(s), mailto and tel links
+func isSafeLink(link string) bool {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return parsed.Host != ""
+	case "mailto", "tel":
+		return parsed.Opaque != ""
+	}
+	return false
+}
+
+func isTemplateTag(value string) bool {
+	return strings.HasPrefix(value, "{{") && strings.HasSuffix(value, "}}") && strings.Count(value, "{{") == 1
+}
+
+// builderURL is a block link or image URL as an attribute value. A link that is a single
+// template tag gets the url filter so its rendered value is checked too.
+func builderURL(link string) string {
+	link = strings.TrimSpace(link)
+	if isTemplateTag(link) {
+		link = strings.TrimSpace(strings.TrimSuffix(link, "}}")) + " | url}}"
+	}
+	return blockAttr(link)
+}
+
+// ApplyBlocks compiles block JSON onto a template, storing the normalized source alongside
+// the generated HTML and plain-text bodies
+func ApplyBlocks(tmpl *models.EmailTemplate, raw []byte) error {
+	doc, err := ParseBlockDocument(raw)
+	if err != nil {
+		return err
+	}
+	normalized, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpl.EditorType = EditorTypeBlocks
+	tmpl.BuilderBlocks = datatypes.JSON(normalized)
+	tmpl.HtmlBody, tmpl.PlainTextBody = CompileBlocks(doc)
+	return nil
+}
+
+// CompileBlocks turns a validated block document into table-based HTML with inline styles
+// and a matching plain-text body. Template tags in block fields are kept for render time, and
+// the HTML goes through SanitizeEmailHTML like hand-written HTML.
+func CompileBlocks(doc *BlockDocument) (string, string) {
+	c := &blockCompiler{
+		background: defaultString(doc.Settings.BackgroundColor, "#f4f4f4"),
+		content:    defaultString(doc.Settings.ContentColor, "#ffffff"),
+		text:       defaultString(doc.Settings.TextColor, "#333333"),
+		primary:    defaultString(doc.Settings.PrimaryColor, `{{brand.primary_color | default "#1a73e8"}}`),
+		font:       builderFonts[defaultString(doc.Settings.FontFamily, "sans-serif")],
+	}
+
+	var body strings.Builder
+	var text []string
+	for i := range doc.Blocks {
+		block := &doc.Blocks[i]
+		fmt.Fprintf(&body, `<tr><td style="padding:12px %dpx;">%s</td></tr>`, builderPadding, c.blockHTML(block, builderContentWidth-2*builderPadding))
+		if t := c.blockText(block); t != "" {
+			text = append(text, t)
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><meta http-equiv="X-UA-Compatible" content="IE=edge"><title></title>`)
+	out.WriteString(`<style>@media only screen and (max-width:620px){.email-container{width:100%!important;max-width:100%!important}.email-column{display:block!important;width:100%!important;max-width:100%!important}.email-fluid{width:100%!important;max-width:100%!important;height:auto!important}}</style></head>`)
+	fmt.Fprintf(&out, `<body style="margin:0;padding:0;background-color:%s;">`, blockAttr(c.background))
+	fmt.Fprintf(&out, `<table role="presentation" width="100%%" cellpadding="0" cellspacing="0" border="0" style="background-color:%s;"><tr><td align="center" style="padding:24px 12px;">`, blockAttr(c.background))
+	fmt.Fprintf(&out, `<table role="presentation" class="email-container" width="%d" cellpadding="0" cellspacing="0" border="0" style="width:%dpx;max-width:%dpx;background-color:%s;">`,
+		builderContentWidth, builderContentWidth, builderContentWidth, blockAttr(c.content))
+	out.WriteString(body.String())
+	out.WriteString(`</table></td></tr></table></body></html>`)
+
+	htmlBody, _ := SanitizeEmailHTML(out.String())
+	return htmlBody, strings.Join(text, "\n\n")
+}
+
+type blockCompiler struct {
+	background string
+	content    string
+	text       string
+	primary    string
+	font       string
+}
+
+// blockHTML renders a block for a cell that is width pixels wide
+func (c *blockCompiler) blockHTML(b *TemplateBlock, width int) string {
+	align := defaultString(b.Align, "left")
+
+	switch b.Type {
+	case "heading":
+		size := headingSizes[b.Level]
+		return fmt.Sprintf(`<h%d style="margin:0;font-family:%s;font-size:%dpx;line-height:1.3;font-weight:bold;color:%s;text-align:%s;">%s</h%d>`,
+			b.Level, c.font, size, blockAttr(defaultString(b.Color, c.text)), align, blockText(b.Text), b.Level)
+
+	case "text":
+		var out strings.Builder
+		for _, paragraph := range splitParagraphs(b.Text) {
+			fmt.Fprintf(&out, `<p style="margin:0 0 12px 0;font-family:%s;font-size:16px;line-height:1.5;color:%s;text-align:%s;">%s</p>`,
+				c.font, blockAttr(c.text), align, c.paragraphHTML(paragraph))
+		}
+		return out.String()
+
+	case "image":
+		imgWidth := width
+		if b.Width > 0 && b.Width < width {
+			imgWidth = b.Width
+		}
+		img := fmt.Sprintf(`<img class="email-fluid" src="%s" alt="%s" width="%d" style="display:block;width:100%%;max-width:%dpx;height:auto;border:0;outline:none;text-decoration:none;">`,
+			builderURL(b.ImageURL), blockAttr(b.Alt), imgWidth, imgWidth)
+		if b.URL != "" {
+			img = fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, builderURL(b.URL), img)
+		}
+		return fmt.Sprintf(`<table role="presentation" width="100%%" cellpadding="0" cellspacing="0" border="0"><tr><td align="%s">%s</td></tr></table>`, align, img)
+
+	case "button":
+		return c.buttonHTML(b.Text, b.URL, defaultString(b.Color, c.primary), align)
+
+	case "divider":
+		return `<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0"><tr><td style="border-top:1px solid #dddddd;font-size:0;line-height:0;height:1px;">&nbsp;</td></tr></table>`
+
+	case "property_card":
+		return c.propertyHTML(b.Property, width)
+
+	case "columns":
+		gutter := 8
+		columnWidth := (width - gutter*2*(len(b.Columns)-1)) / len(b.Columns)
+		percent := 100 / len(b.Columns)
+
+		var out strings.Builder
+		out.WriteString(`<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0"><tr>`)
+		for i, column := range b.Columns {
+			padding := fmt.Sprintf("0 %dpx", gutter)
+			if i == 0 {
+				padding = fmt.Sprintf("0 %dpx 0 0", gutter)
+			} else if i == len(b.Columns)-1 {
+				padding = fmt.Sprintf("0 0 0 %dpx", gutter)
+			}
+			fmt.Fprintf(&out, `<td class="email-column" width="%d%%" valign="top" style="width:%d%%;padding:%s;">`, percent, percent, padding)
+			out.WriteString(`<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0">`)
+			for j := range column {
+				fmt.Fprintf(&out, `<tr><td style="padding:6px 0;">%s</td></tr>`, c.blockHTML(&column[j], columnWidth))
+			}
+			out.WriteString(`</table></td>`)
+		}
+		out.WriteString(`</tr></table>`)
+		return out.String()
+	}
+	return ""
+}
+
+// buttonHTML is a "bulletproof" button: a table cell carries the color so it shows even
+// where padding on links is ignored
+func (c *blockCompiler) buttonHTML(label, link, color, align string) string {
+	return fmt.Sprintf(`<table role="presentation" width="100%%" cellpadding="0" cellspacing="0" border="0"><tr><td align="%s">`+
+		`<table role="presentation" cellpadding="0" cellspacing="0" border="0"><tr><td style="border-radius:4px;background-color:%s;">`+
+		`<a href="%s" target="_blank" style="display:inline-block;padding:12px 24px;font-family:%s;font-size:16px;font-weight:bold;line-height:1.2;color:#ffffff;text-decoration:none;border-radius:4px;">%s</a>`+
+		`</td></tr></table></td></tr></table>`,
+		align, blockAttr(color), builderURL(link), c.font, blockText(label))
+}
+
+func (c *blockCompiler) propertyHTML(p *PropertyCard, width int) string {
+	var out strings.Builder
+	out.WriteString(`<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="border:1px solid #e0e0e0;border-radius:6px;">`)
+	if p.ImageURL != "" {
+		fmt.Fprintf(&out, `<tr><td><img class="email-fluid" src="%s" alt="%s" width="%d" style="display:block;width:100%%;max-width:%dpx;height:auto;border:0;border-radius:6px 6px 0 0;"></td></tr>`,
+			builderURL(p.ImageURL), blockAttr(p.Title), width, width)
+	}
+	out.WriteString(`<tr><td style="padding:16px;">`)
+	fmt.Fprintf(&out, `<h3 style="margin:0 0 6px 0;font-family:%s;font-size:18px;line-height:1.3;color:%s;">%s</h3>`, c.font, blockAttr(c.text), blockText(p.Title))
+	if p.Price != "" {
+		fmt.Fprintf(&out, `<p style="margin:0 0 6px 0;font-family:%s;font-size:18px;font-weight:bold;color:%s;">%s</p>`, c.font, blockAttr(c.primary), blockText(p.Price))
+	}
+	if p.Location != "" {
+		fmt.Fprintf(&out, `<p style="margin:0 0 6px 0;font-family:%s;font-size:14px;color:#666666;">%s</p>`, c.font, blockText(p.Location))
+	}
+	if details := propertyDetails(p); details != "" {
+		fmt.Fprintf(&out, `<p style="margin:0 0 6px 0;font-family:%s;font-size:14px;color:#666666;">%s</p>`, c.font, blockText(details))
+	}
+	if p.Description != "" {
+		fmt.Fprintf(&out, `<p style="margin:6px 0 0 0;font-family:%s;font-size:15px;line-height:1.5;color:%s;">%s</p>`, c.font, blockAttr(c.text), blockText(p.Description))
+	}
+	if p.URL != "" {
+		out.WriteString(`<div style="padding-top:12px;">`)
+		out.WriteString(c.buttonHTML(defaultString(p.ButtonText, "View property"), p.URL, c.primary, "left"))
+		out.WriteString(`</div>`)
+	}
+	out.WriteString(`</td></tr></table>`)
+	return out.String()
+}
+
+func (c *blockCompiler) blockText(b *TemplateBlock) string {
+	switch b.Type {
+	case "heading":
+		return strings.TrimSpace(b.Text)
+	case "text":
+		return blockLinkPattern.ReplaceAllStringFunc(strings.TrimSpace(b.Text), func(match string) string {
+			parts := blockLinkPattern.FindStringSubmatch(match)
+			if !isBuilderURL(parts[2]) {
+				return match
+			}
+			return fmt.Sprintf("%s (%s)", parts[1], parts[2])
+		})
+	case "image":
+		if b.Alt == "" {
+			return ""
+		}
+		if b.URL != "" {
+			return fmt.Sprintf("[%s] %s", b.Alt, b.URL)
+		}
+		return fmt.Sprintf("[%s]", b.Alt)
+	case "button":
+		return fmt.Sprintf("%s: %s", strings.TrimSpace(b.Text), b.URL)
+	case "divider":
+		return strings.Repeat("-", 40)
+	case "property_card":
+		p := b.Property
+		lines := []string{p.Title}
+		for _, line := range []string{p.Price, p.Location, propertyDetails(p), p.Description} {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if p.URL != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", defaultString(p.ButtonText, "View property"), p.URL))
+		}
+		return strings.Join(lines, "\n")
+	case "columns":
+		var parts []string
+		for _, column := range b.Columns {
+			for i := range column {
+				if t := c.blockText(&column[i]); t != "" {
+					parts = append(parts, t)
+				}
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+	return ""
+}
+
+// blockLinkPattern matches [label](url) links in text blocks
+var blockLinkPattern = regexp.MustCompile(`\[([^\]\n]+)\]\(([^)\s]+)\)`)
+
+// paragraphHTML escapes a paragraph, turning line breaks into <br> and [label](url) into links
+func (c *blockCompiler) paragraphHTML(paragraph string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range blockLinkPattern.FindAllStringSubmatchIndex(paragraph, -1) {
+		label, link := paragraph[loc[2]:loc[3]], paragraph[loc[4]:loc[5]]
+		if !isBuilderURL(link) {
+			continue
+		}
+		out.WriteString(blockText(paragraph[last:loc[0]]))
+		fmt.Fprintf(&out, `<a href="%s" target="_blank" style="color:%s;text-decoration:underline;">%s</a>`, builderURL(link), blockAttr(c.primary), blockText(label))
+		last = loc[1]
+	}
+	out.WriteString(blockText(paragraph[last:]))
+	return strings.ReplaceAll(out.String(), "\n", "<br>")
+}
+
+// propertyDetails joins bedrooms, bathrooms and area, e.g. "3 bed · 2 bath · 1,450 sq ft"
+func propertyDetails(p *PropertyCard) string {
+	var parts []string
+	if p.Bedrooms != "" {
+		parts = append(parts, p.Bedrooms+" bed")
+	}
+	if p.Bathrooms != "" {
+		parts = append(parts, p.Bathrooms+" bath")
+	}
+	if p.Area != "" {
+		parts = append(parts, p.Area+" sq ft")
+	}
+	return strings.Join(parts, " · ")
+}
+
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return paragraphs
+}
+
+// blockText HTML-escapes block content but leaves {{...}} tags intact for the template engine,
+// which escapes the values it fills in
+func blockText(s string) string {
+	return escapeAroundTags(s, false)
+}
+
+// blockAttr is blockText for attribute values; double quotes inside tags become single quotes
+// so they don't end the attribute
+func blockAttr(s string) string {
+	return escapeAroundTags(s, true)
+}
+
+func escapeAroundTags(s string, attr bool) string {
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			break
+		}
+		end += start + 2
+
+		out.WriteString(html.EscapeString(s[:start]))
+		tag := s[start:end]
+		if attr {
+			tag = strings.ReplaceAll(tag, `"`, `'`)
+		}
+		out.WriteString(tag)
+		s = s[end:]
+	}
+	out.WriteString(html.EscapeString(s))
+	return out.String()
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
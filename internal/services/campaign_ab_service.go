@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+)
+
+// Campaign variant modes
+const (
+	VariantModeSplit      = "split"
+	VariantModeTestWinner = "test_winner"
+)
+
+// A/B campaigns compare 2 to 4 variants
+const (
+	MinCampaignVariants = 2
+	MaxCampaignVariants = 4
+)
+
+var variantLabels = []string{"A", "B", "C", "D"}
+
+// ValidateCampaignVariants checks a campaign's A/B settings and labels the variants. Variants
+// without a split percentage share the recipients evenly.
+func ValidateCampaignVariants(campaign *models.Campaign, variants []models.CampaignVariant) error {
+	if campaign.VariantMode == "" {
+		if len(variants) > 0 {
+			return errors.New("variant_mode is required with variants")
+		}
+		return nil
+	}
+	if campaign.VariantMode != VariantModeSplit && campaign.VariantMode != VariantModeTestWinner {
+		return errors.New("variant_mode must be 'split' or 'test_winner'")
+	}
+	if campaign.ScheduleType != "once" {
+		return errors.New("A/B tests are only supported for one-time campaigns")
+	}
+	if len(variants) < MinCampaignVariants || len(variants) > MaxCampaignVariants {
+		return fmt.Errorf("A/B tests need %d to %d variants", MinCampaignVariants, MaxCampaignVariants)
+	}
+
+	total := 0
+	unset := 0
+	for i := range variants {
+		variants[i].Label = variantLabels[i]
+		if variants[i].TemplateID == "" {
+			return fmt.Errorf("variant %s: template_id is required", variants[i].Label)
+		}
+		if variants[i].SplitPercent < 0 || variants[i].SplitPercent > 99 {
+			return fmt.Errorf("variant %s: split_percent must be between 1 and 99", variants[i].Label)
+		}
+		if variants[i].SplitPercent == 0 {
+			unset++
+		}
+		total += variants[i].SplitPercent
+	}
+	switch {
+	case unset == len(variants):
+		for i := range variants {
+			variants[i].SplitPercent = 100 / len(variants)
+		}
+		// The first variants absorb the rounding so the split still adds up to 100
+		for i := 0; i < 100%len(variants); i++ {
+			variants[i].SplitPercent++
+		}
+	case unset > 0:
+		return errors.New("set split_percent on every variant or on none")
+	case total != 100:
+		return errors.New("variant split_percent values must add up to 100")
+	}
+
+	if campaign.VariantMode == VariantModeTestWinner {
+		if campaign.TestPercent < 5 || campaign.TestPercent > 50 {
+			return errors.New("test_percent must be between 5 and 50")
+		}
+		if campaign.TestDurationHours < 1 || campaign.TestDurationHours > 168 {
+			return errors.New("test_duration_hours must be between 1 and 168")
+		}
+		if campaign.WinnerMetric != "open" && campaign.WinnerMetric != "click" {
+			return errors.New("winner_metric must be 'open' or 'click'")
+		}
+	}
+	return nil
+}
+
+// SplitRecipients assigns count recipients to variants by percentage and returns the variant
+// index for each position. Counts are rounded by largest remainder so every recipient is
+// assigned.
+func SplitRecipients(count int, percents []int) []int {
+	assigned := make([]int, 0, count)
+	if len(percents) == 0 {
+		return assigned
+	}
+
+	sizes := make([]int, len(percents))
+	remainders := make([]int, len(percents))
+	total := 0
+	for i, percent := range percents {
+		sizes[i] = count * percent / 100
+		remainders[i] = count * percent % 100
+		total += sizes[i]
+	}
+	for total < count {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		sizes[best]++
+		remainders[best] = -1
+		total++
+	}
+
+	for i, size := range sizes {
+		for j := 0; j < size; j++ {
+			assigned = append(assigned, i)
+		}
+	}
+	return assigned
+}
+
+// TestSampleSize is how many of count recipients get the test sends: TestPercent rounded up,
+// but at least one per variant and never more than everyone
+func TestSampleSize(count, testPercent, variants int) int {
+	size := (count*testPercent + 99) / 100
+	if size < variants {
+		size = variants
+	}
+	if size > count {
+		size = count
+	}
+	return size
+}
+
+// VariantRate is the share of sent emails that were opened or clicked
+func VariantRate(counts repository.CampaignVariantCounts, metric string) float64 {
+	if counts.Sent == 0 {
+		return 0
+	}
+	if metric == "click" {
+		return float64(counts.Clicked) / float64(counts.Sent)
+	}
+	return float64(counts.Opened) / float64(counts.Sent)
+}
+
+// PickWinner returns the variant with the best open or click rate. Ties go to the variant
+// listed first.
+func PickWinner(variants []models.CampaignVariant, stats map[string]repository.CampaignVariantCounts, metric string) *models.CampaignVariant {
+	var winner *models.CampaignVariant
+	bestRate := -1.0
+	for i := range variants {
+		rate := VariantRate(stats[variants[i].ID], metric)
+		if rate > bestRate {
+			winner = &variants[i]
+			bestRate = rate
+		}
+	}
+	return winner
+}
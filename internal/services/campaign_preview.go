@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/datatypes"
+)
+
+// CampaignRecipientCounts break down a campaign's audience into who would be emailed and why
+// the rest would not
+type CampaignRecipientCounts struct {
+	Total           int64 // distinct contacts in the campaign's audiences
+	Duplicate       int64 // extra audience memberships of contacts in more than one audience
+	Excluded        int64 // in an excluded audience, or inactive
+	NoEmail         int64
+	Suppressed      int64 // unsubscribed
+	FrequencyCapped int64 // already at the organization's frequency cap
+	Recipients      int64
+}
+
+// MaxPreviewMessages is the most messages a dry run stores. Its counts still cover every message.
+const MaxPreviewMessages = 1000
+
+// PreviewCampaignRecipients returns who a campaign would email if it ran now, sorted by name,
+// with counts of the contacts it would skip
+func (s *BackgroundJobService) PreviewCampaignRecipients(campaign *models.Campaign) (*CampaignRecipientCounts, []models.Contact, error) {
+	counts := &CampaignRecipientCounts{}
+
+	total, memberships, err := s.campaignRepo.CountAudienceMembers(campaign)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts.Total = total
+	counts.Duplicate = memberships - total
+
+	contactIDs, err := s.campaignRepo.GetRecipientContacts(campaign)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts.Excluded = total - int64(len(contactIDs))
+
+	contacts, err := s.contactRepo.FindByIDs(contactIDs, campaign.OrganizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, contact := range contacts {
+		switch contactSkipReason(&contact) {
+		case "no_email":
+			counts.NoEmail++
+		case "unsubscribed":
+			counts.Suppressed++
+		}
+	}
+
+	// The frequency cap check records skips on a throwaway preview instead of campaign_log
+	brand, _ := s.settingsService.GetSettings(campaign.OrganizationID)
+	run := &campaignRun{
+		sendContext: &sendContext{orgID: campaign.OrganizationID, brand: brand},
+		campaign:    campaign,
+		preview:     &models.CampaignPreview{},
+	}
+	recipients := s.applyFrequencyCap(run, emailableContacts(contacts))
+	counts.FrequencyCapped = int64(run.preview.Skipped)
+	counts.Recipients = int64(len(recipients))
+
+	sort.Slice(recipients, func(i, j int) bool {
+		a, b := recipients[i], recipients[j]
+		if a.FirstName != b.FirstName {
+			return a.FirstName < b.FirstName
+		}
+		if a.LastName != b.LastName {
+			return a.LastName < b.LastName
+		}
+		return a.Email < b.Email
+	})
+	return counts, recipients, nil
+}
+
+// QueueCampaignDryRun creates a preview report and a campaign_dry_run job that fills it in
+func (s *BackgroundJobService) QueueCampaignDryRun(campaign *models.Campaign, userID string) (*models.CampaignPreview, error) {
+	preview := models.CampaignPreview{
+		CampaignID:     campaign.ID,
+		OrganizationID: campaign.OrganizationID,
+		Status:         "running",
+		Messages:       datatypes.JSONSlice[models.CampaignPreviewMessage]{},
+		CreatedBy:      userID,
+	}
+	if err := s.previewRepo.Create(&preview); err != nil {
+		return nil, err
+	}
+
+	job := models.BackgroundJobLog{
+		JobType:        "campaign_dry_run",
+		OrganizationID: campaign.OrganizationID,
+		ReferenceID:    &campaign.ID,
+		Status:         "queued",
+	}
+	if err := s.jobRepo.Create(&job); err != nil {
+		return nil, err
+	}
+
+	go s.ProcessCampaignDryRun(job.ID, preview.ID)
+	return &preview, nil
+}
+
+// ProcessCampaignDryRun walks a campaign through the same steps as ProcessCampaignRun and
+// renders every message, recording the results in the preview instead of sending them. The
+// campaign itself and its logs are left untouched. For a test-then-winner campaign the
+// remainder is rendered with every variant, since any of them could win.
+func (s *BackgroundJobService) ProcessCampaignDryRun(jobID, previewID string) {
+	s.StartJob(jobID)
+
+	preview, err := s.previewRepo.FindByIDOnly(previewID)
+	if err != nil {
+		s.FailJob(jobID, "Campaign preview not found")
+		return
+	}
+
+	campaign, err := s.campaignRepo.FindByIDOnly(preview.CampaignID)
+	if err != nil {
+		s.failCampaignDryRun(jobID, preview, errors.New("Campaign not found"))
+		return
+	}
+
+	run, err := s.newCampaignRun(campaign)
+	if err != nil {
+		s.failCampaignDryRun(jobID, preview, err)
+		return
+	}
+	run.preview = preview
+
+	contents, err := s.loadCampaignContents(run)
+	if err != nil {
+		s.failCampaignDryRun(jobID, preview, err)
+		return
+	}
+
+	plan, err := s.planCampaignRun(run, contents)
+	if errors.Is(err, errNoCampaignRecipients) {
+		plan = &campaignSendPlan{}
+	} else if err != nil {
+		s.failCampaignDryRun(jobID, preview, err)
+		return
+	}
+
+	start := len(preview.Messages)
+	for i, content := range plan.assignments {
+		s.sendCampaignEmail(run, content, &plan.recipients[i])
+	}
+	if campaign.VariantMode == VariantModeTestWinner {
+		setPreviewStage(preview.Messages[start:], "test")
+
+		start = len(preview.Messages)
+		remainder := s.applyFrequencyCap(run, plan.recipients[len(plan.assignments):])
+		for i := range remainder {
+			for _, content := range contents {
+				s.sendCampaignEmail(run, content, &remainder[i])
+			}
+		}
+		setPreviewStage(preview.Messages[start:], "winner")
+		plan.recipients = append(plan.recipients[:len(plan.assignments)], remainder...)
+	}
+
+	now := time.Now()
+	preview.Recipients = len(plan.recipients)
+	preview.Status = "completed"
+	preview.CompletedAt = &now
+	if err := s.previewRepo.Update(preview); err != nil {
+		log.Printf("Failed to save preview %s for campaign %s: %v", preview.ID, campaign.ID, err)
+		s.FailJob(jobID, "Failed to save campaign preview")
+		return
+	}
+	s.FinishJob(jobID)
+	log.Printf("Campaign %s dry run: %d rendered, %d failed, %d skipped", campaign.ID, preview.Rendered, preview.Failed, preview.Skipped)
+}
+
+// failCampaignDryRun marks a dry run and its job as failed
+func (s *BackgroundJobService) failCampaignDryRun(jobID string, preview *models.CampaignPreview, err error) {
+	now := time.Now()
+	preview.Status = "failed"
+	preview.ErrorMessage = err.Error()
+	preview.CompletedAt = &now
+	s.previewRepo.Update(preview)
+	s.FailJob(jobID, err.Error())
+}
+
+// addPreviewMessage records one message of a dry run, up to MaxPreviewMessages, and counts
+// it by status
+func (run *campaignRun) addPreviewMessage(message models.CampaignPreviewMessage) {
+	if len(run.preview.Messages) < MaxPreviewMessages {
+		run.preview.Messages = append(run.preview.Messages, message)
+	} else {
+		run.preview.MessagesTruncated = true
+	}
+	switch message.Status {
+	case "rendered":
+		run.preview.Rendered++
+	case "failed":
+		run.preview.Failed++
+	case "skipped":
+		run.preview.Skipped++
+	}
+}
+
+func setPreviewStage(messages []models.CampaignPreviewMessage, stage string) {
+	for i := range messages {
+		messages[i].Stage = stage
+	}
+}
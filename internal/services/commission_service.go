@@ -0,0 +1,256 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNoCommissionRule is returned when a deal has no rule and the organization has no default
+var ErrNoCommissionRule = errors.New("no commission rule configured")
+
+// CommissionPayout is one recipient's share of a deal's commission
+type CommissionPayout struct {
+	Recipient string  `json:"recipient"` // listing_agent | buyer_agent | brokerage
+	AgentID   *string `json:"agent_id"`
+	Amount    float64 `json:"amount"`
+}
+
+// AgentPayout totals an agent's commission over a period
+type AgentPayout struct {
+	AgentID      string  `json:"agent_id"`
+	AgentName    string  `json:"agent_name"`
+	DealCount    int     `json:"deal_count"`
+	ListingSides int     `json:"listing_sides"`
+	BuyerSides   int     `json:"buyer_sides"`
+	Amount       float64 `json:"amount"`
+}
+
+// PayoutReport summarises commission payouts for deals closed in a period
+type PayoutReport struct {
+	From            time.Time     `json:"from"`
+	To              time.Time     `json:"to"`
+	DealCount       int           `json:"deal_count"`
+	TotalVolume     float64       `json:"total_volume"`
+	TotalCommission float64       `json:"total_commission"`
+	BrokerageTotal  float64       `json:"brokerage_total"`
+	Agents          []AgentPayout `json:"agents"`
+	SkippedDealIDs  []string      `json:"skipped_deal_ids"`
+}
+
+type CommissionService struct {
+	dealRepo *repository.DealRepository
+	ruleRepo *repository.CommissionRuleRepository
+	userRepo *repository.UserRepository
+}
+
+func NewCommissionService() *CommissionService {
+	return &CommissionService{
+		dealRepo: &repository.DealRepository{},
+		ruleRepo: &repository.CommissionRuleRepository{},
+		userRepo: &repository.UserRepository{},
+	}
+}
+
+// ValidateCommissionRule checks rates, tiers and split percentages of a rule
+func ValidateCommissionRule(rule *models.CommissionRule) error {
+	switch rule.Type {
+	case "percentage":
+		if rule.Rate <= 0 || rule.Rate > 100 {
+			return errors.New("rate must be between 0 and 100")
+		}
+	case "tiered":
+		if len(rule.Tiers) == 0 {
+			return errors.New("tiered rules need at least one tier")
+		}
+		var previous float64
+		for i, tier := range rule.Tiers {
+			if tier.Rate < 0 || tier.Rate > 100 {
+				return errors.New("tier rates must be between 0 and 100")
+			}
+			if tier.UpTo == nil {
+				if i != len(rule.Tiers)-1 {
+					return errors.New("only the last tier may be uncapped")
+				}
+				continue
+			}
+			if *tier.UpTo <= previous {
+				return errors.New("tier up_to values must be increasing")
+			}
+			previous = *tier.UpTo
+		}
+	default:
+		return errors.New("type must be 'percentage' or 'tiered'")
+	}
+
+	if rule.ListingSidePercent < 0 || rule.ListingSidePercent > 100 {
+		return errors.New("listing_side_percent must be between 0 and 100")
+	}
+	if rule.AgentSharePercent < 0 || rule.AgentSharePercent > 100 {
+		return errors.New("agent_share_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// CalculateGrossCommission returns the total commission on a sale price.
+// Tiered rules are marginal: each tier's rate applies only to the part of the price inside it.
+func CalculateGrossCommission(rule *models.CommissionRule, price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+
+	if rule.Type != "tiered" {
+		return roundCurrency(price * rule.Rate / 100)
+	}
+
+	var gross, lower float64
+	for _, tier := range rule.Tiers {
+		upper := price
+		if tier.UpTo != nil && *tier.UpTo < price {
+			upper = *tier.UpTo
+		}
+		if upper > lower {
+			gross += (upper - lower) * tier.Rate / 100
+		}
+		if tier.UpTo == nil || *tier.UpTo >= price {
+			break
+		}
+		lower = *tier.UpTo
+	}
+	return roundCurrency(gross)
+}
+
+// SplitCommission divides a gross commission between the listing agent, the buyer agent
+// and the brokerage. A side without an agent goes entirely to the brokerage.
+func SplitCommission(rule *models.CommissionRule, gross float64, listingAgentID, buyerAgentID *string) []CommissionPayout {
+	listingSide := gross * rule.ListingSidePercent / 100
+	buyerSide := gross - listingSide
+
+	var payouts []CommissionPayout
+	agentTotal := 0.0
+
+	if listingAgentID != nil {
+		amount := roundCurrency(listingSide * rule.AgentSharePercent / 100)
+		payouts = append(payouts, CommissionPayout{Recipient: "listing_agent", AgentID: listingAgentID, Amount: amount})
+		agentTotal += amount
+	}
+	if buyerAgentID != nil {
+		amount := roundCurrency(buyerSide * rule.AgentSharePercent / 100)
+		payouts = append(payouts, CommissionPayout{Recipient: "buyer_agent", AgentID: buyerAgentID, Amount: amount})
+		agentTotal += amount
+	}
+
+	// The brokerage takes the remainder so the parts always add up to the gross
+	payouts = append(payouts, CommissionPayout{Recipient: "brokerage", Amount: roundCurrency(gross - agentTotal)})
+	return payouts
+}
+
+// ResolveRule returns the deal's pinned rule or the organization's default
+func (s *CommissionService) ResolveRule(deal *models.Deal) (*models.CommissionRule, error) {
+	if deal.CommissionRuleID != nil {
+		return s.ruleRepo.FindByID(*deal.CommissionRuleID, deal.OrganizationID)
+	}
+
+	rule, err := s.ruleRepo.FindDefault(deal.OrganizationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoCommissionRule
+	}
+	return rule, err
+}
+
+// DealPayouts computes the gross commission and its split for a deal's accepted price
+func (s *CommissionService) DealPayouts(deal *models.Deal) (float64, []CommissionPayout, error) {
+	if deal.AcceptedPrice == nil {
+		return 0, nil, errors.New("deal has no accepted price")
+	}
+
+	rule, err := s.ResolveRule(deal)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	gross := CalculateGrossCommission(rule, *deal.AcceptedPrice)
+	return gross, SplitCommission(rule, gross, deal.ListingAgentID, deal.BuyerAgentID), nil
+}
+
+// PayoutsForPeriod totals agent and brokerage commission for deals won in [from, to)
+func (s *CommissionService) PayoutsForPeriod(orgID string, from, to time.Time) (*PayoutReport, error) {
+	deals, err := s.dealRepo.FindClosedWonBetween(orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PayoutReport{From: from, To: to, Agents: []AgentPayout{}, SkippedDealIDs: []string{}}
+	byAgent := make(map[string]*AgentPayout)
+
+	for i := range deals {
+		deal := &deals[i]
+
+		gross, payouts, err := s.DealPayouts(deal)
+		if errors.Is(err, ErrNoCommissionRule) {
+			return nil, err
+		}
+		if err != nil {
+			// Deals without a price cannot be paid out; surface them instead of failing the report
+			report.SkippedDealIDs = append(report.SkippedDealIDs, deal.ID)
+			continue
+		}
+
+		report.DealCount++
+		report.TotalVolume += *deal.AcceptedPrice
+		report.TotalCommission += gross
+
+		counted := make(map[string]bool)
+		for _, payout := range payouts {
+			if payout.AgentID == nil {
+				report.BrokerageTotal += payout.Amount
+				continue
+			}
+
+			agent, ok := byAgent[*payout.AgentID]
+			if !ok {
+				agent = &AgentPayout{AgentID: *payout.AgentID}
+				byAgent[*payout.AgentID] = agent
+			}
+			agent.Amount += payout.Amount
+			if payout.Recipient == "listing_agent" {
+				agent.ListingSides++
+			} else {
+				agent.BuyerSides++
+			}
+			// An agent on both sides of a deal still counts it once
+			if !counted[agent.AgentID] {
+				agent.DealCount++
+				counted[agent.AgentID] = true
+			}
+		}
+	}
+
+	for _, agent := range byAgent {
+		if agentUUID, err := uuid.Parse(agent.AgentID); err == nil {
+			if user, err := s.userRepo.FindByID(agentUUID); err == nil {
+				agent.AgentName = user.Name
+			}
+		}
+		agent.Amount = roundCurrency(agent.Amount)
+		report.Agents = append(report.Agents, *agent)
+	}
+	sort.Slice(report.Agents, func(i, j int) bool {
+		return report.Agents[i].Amount > report.Agents[j].Amount
+	})
+
+	report.TotalVolume = roundCurrency(report.TotalVolume)
+	report.TotalCommission = roundCurrency(report.TotalCommission)
+	report.BrokerageTotal = roundCurrency(report.BrokerageTotal)
+	return report, nil
+}
+
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -10,16 +10,21 @@ import (
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ContactService struct {
-	contactRepo *repository.ContactRepository
+	contactRepo     *repository.ContactRepository
+	userRepo        *repository.UserRepository
+	pipelineService *PipelineService
 }
 
 func NewContactService() *ContactService {
 	return &ContactService{
-		contactRepo: &repository.ContactRepository{},
+		contactRepo:     &repository.ContactRepository{},
+		userRepo:        &repository.UserRepository{},
+		pipelineService: NewPipelineService(),
 	}
 }
 
@@ -35,9 +40,66 @@ func (s *ContactService) ValidateContact(contact *models.Contact) error {
 		return errors.New("budget_min cannot be greater than budget_max")
 	}
 
+	language, err := NormalizeLocale(contact.PreferredLanguage)
+	if err != nil {
+		return err
+	}
+	contact.PreferredLanguage = language
+
+	timezone, err := NormalizeTimezone(contact.Timezone)
+	if err != nil {
+		return err
+	}
+	contact.Timezone = timezone
+
 	return nil
 }
 
+// ValidateAssignee checks that an agent exists, is active and belongs to the organization
+func (s *ContactService) ValidateAssignee(orgID, agentID string) error {
+	agentUUID, err := uuid.Parse(agentID)
+	if err != nil {
+		return errors.New("invalid agent ID")
+	}
+
+	agent, err := s.userRepo.FindByID(agentUUID)
+	if err != nil || agent.OrganizationID.String() != orgID {
+		return errors.New("agent not found in your organization")
+	}
+
+	if !agent.IsActive {
+		return errors.New("cannot assign contacts to an inactive agent")
+	}
+
+	return nil
+}
+
+// DeactivateAgent deactivates an agent and hands every one of their contacts to a colleague.
+// An empty toAgentID returns the contacts to the unassigned pool.
+func (s *ContactService) DeactivateAgent(orgID string, agent *models.User, toAgentID string) (int64, error) {
+	var reassignTo *string
+	if toAgentID != "" {
+		if toAgentID == agent.ID.String() {
+			return 0, errors.New("cannot reassign contacts to the same agent")
+		}
+		if err := s.ValidateAssignee(orgID, toAgentID); err != nil {
+			return 0, err
+		}
+		reassignTo = &toAgentID
+	}
+
+	var reassigned int64
+	err := s.userRepo.Deactivate(agent, func(tx *gorm.DB) error {
+		var err error
+		reassigned, err = s.contactRepo.ReassignFromAgent(tx, orgID, agent.ID.String(), reassignTo)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return reassigned, nil
+}
+
 // CreateContact creates a new contact with uniqueness check
 func (s *ContactService) CreateContact(contact *models.Contact) error {
 	// Validate contact
@@ -62,6 +124,15 @@ func (s *ContactService) CreateContact(contact *models.Contact) error {
 	// Set default active status
 	contact.IsActive = true
 
+	// New leads enter the first stage of the pipeline
+	if contact.StageID == nil {
+		stageID, err := s.pipelineService.DefaultStageID(contact.OrganizationID)
+		if err != nil {
+			return err
+		}
+		contact.StageID = stageID
+	}
+
 	return s.contactRepo.Create(contact)
 }
 
@@ -196,6 +267,18 @@ func (s *ContactService) ParseCSV(file io.Reader, orgID, createdBy string) ([]mo
 		if idx, ok := headerMap["preferred_location"]; ok && idx < len(record) {
 			contact.PreferredLocation = strings.TrimSpace(record[idx])
 		}
+		if idx, ok := headerMap["preferred_language"]; ok && idx < len(record) {
+			// An unrecognised language is dropped rather than failing the row
+			if language, err := NormalizeLocale(record[idx]); err == nil {
+				contact.PreferredLanguage = language
+			}
+		}
+		if idx, ok := headerMap["timezone"]; ok && idx < len(record) {
+			// An unknown time zone is dropped too
+			if timezone, err := NormalizeTimezone(record[idx]); err == nil {
+				contact.Timezone = timezone
+			}
+		}
 		if idx, ok := headerMap["notes"]; ok && idx < len(record) {
 			contact.Notes = strings.TrimSpace(record[idx])
 		}
@@ -221,14 +304,29 @@ func (s *ContactService) ParseCSV(file io.Reader, orgID, createdBy string) ([]mo
 	return contacts, nil
 }
 
-// BulkCreateContacts creates multiple contacts, skipping duplicates
+// BulkCreateContacts creates multiple contacts, skipping duplicates.
+// Created contacts are written back into the slice with their IDs; skipped ones keep an empty ID.
 func (s *ContactService) BulkCreateContacts(contacts []models.Contact) (int, int, error) {
 	successCount := 0
 	skipCount := 0
 
 	fmt.Printf("DEBUG: Starting bulk create for %d contacts\n", len(contacts))
 
+	// Imported leads enter the first stage of the pipeline
+	var defaultStageID *string
+	if len(contacts) > 0 {
+		stageID, err := s.pipelineService.DefaultStageID(contacts[0].OrganizationID)
+		if err != nil {
+			return 0, 0, err
+		}
+		defaultStageID = stageID
+	}
+
 	for i, contact := range contacts {
+		if contact.StageID == nil {
+			contact.StageID = defaultStageID
+		}
+
 		// Check for duplicates
 		existing, err := s.contactRepo.FindByEmailOrPhone(contact.Email, contact.Phone, contact.OrganizationID)
 		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -247,6 +345,8 @@ func (s *ContactService) BulkCreateContacts(contacts []models.Contact) (int, int
 			skipCount++
 			continue
 		}
+		// Keep the saved record so callers can see the generated ID
+		contacts[i] = contact
 		successCount++
 		if successCount%10 == 0 {
 			fmt.Printf("DEBUG: Created %d contacts so far...\n", successCount)
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+// TemplateWarning is a problem with a template that does not block saving it
+type TemplateWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RenderedEmail is an email template rendered for one recipient. HtmlBody already
+// carries the hidden preheader block.
+type RenderedEmail struct {
+	FromName  string         `json:"from_name"`
+	ReplyTo   string         `json:"reply_to"`
+	Subject   string         `json:"subject"`
+	Preheader string         `json:"preheader"`
+	HtmlBody  string         `json:"html_body"`
+	TextBody  string         `json:"text_body"`
+	Report    TemplateReport `json:"report"`
+}
+
+// Message addresses the rendered email to a recipient
+func (e *RenderedEmail) Message(to string) EmailMessage {
+	return EmailMessage{
+		To:       to,
+		FromName: e.FromName,
+		ReplyTo:  e.ReplyTo,
+		Subject:  e.Subject,
+		HtmlBody: e.HtmlBody,
+		TextBody: e.TextBody,
+	}
+}
+
+// CompiledEmail holds the parsed parts of an email template so campaigns parse once per run
+type CompiledEmail struct {
+	fromName  *Template
+	replyTo   *Template
+	subject   *Template
+	preheader *Template
+	htmlBody  *Template
+	textBody  *Template
+	partials  *CompiledPartials
+}
+
+// CompiledPartials holds an organization's partials parsed for the HTML body and for the
+// plain-text parts of an email
+type CompiledPartials struct {
+	html map[string]*Template
+	text map[string]*Template
+}
+
+// CompilePartials parses template partials. A partial without plain text gets one generated
+// from its HTML.
+func CompilePartials(partials []models.TemplatePartial) (*CompiledPartials, error) {
+	compiled := &CompiledPartials{
+		html: make(map[string]*Template, len(partials)),
+		text: make(map[string]*Template, len(partials)),
+	}
+	for _, partial := range partials {
+		htmlTmpl, err := ParseTemplate(partial.HtmlBody)
+		if err != nil {
+			return nil, fmt.Errorf("partial %s: %w", partial.Name, err)
+		}
+		textSrc := partial.PlainTextBody
+		if strings.TrimSpace(textSrc) == "" {
+			textSrc = HTMLToText(partial.HtmlBody)
+		}
+		textTmpl, err := ParseTemplate(textSrc)
+		if err != nil {
+			return nil, fmt.Errorf("partial %s: %w", partial.Name, err)
+		}
+		compiled.html[partial.Name] = htmlTmpl
+		compiled.text[partial.Name] = textTmpl
+	}
+	return compiled, nil
+}
+
+// PlainTextSource returns the template's plain-text body, generated from the HTML body
+// when none was written
+func PlainTextSource(tmpl *models.EmailTemplate) string {
+	if strings.TrimSpace(tmpl.PlainTextBody) != "" {
+		return tmpl.PlainTextBody
+	}
+	return HTMLToText(tmpl.HtmlBody)
+}
+
+// CompileEmailTemplate parses every renderable part of an email template along with the
+// organization's partials it may include
+func CompileEmailTemplate(tmpl *models.EmailTemplate, partials []models.TemplatePartial) (*CompiledEmail, error) {
+	compiledPartials, err := CompilePartials(partials)
+	if err != nil {
+		return nil, err
+	}
+	return compileEmailParts(tmpl, compiledPartials)
+}
+
+// compileEmailParts parses the template's parts against partials that are already compiled
+func compileEmailParts(tmpl *models.EmailTemplate, compiledPartials *CompiledPartials) (*CompiledEmail, error) {
+	compiled := &CompiledEmail{partials: compiledPartials}
+	parts := []struct {
+		name string
+		src  string
+		dst  **Template
+	}{
+		{"from_name", tmpl.FromName, &compiled.fromName},
+		{"reply_to", tmpl.ReplyTo, &compiled.replyTo},
+		{"subject", tmpl.Subject, &compiled.subject},
+		{"preheader", tmpl.Preheader, &compiled.preheader},
+		{"html_body", tmpl.HtmlBody, &compiled.htmlBody},
+		{"plain_text_body", PlainTextSource(tmpl), &compiled.textBody},
+	}
+
+	for _, part := range parts {
+		parsed, err := ParseTemplate(part.src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", part.name, err)
+		}
+		*part.dst = parsed
+	}
+	return compiled, nil
+}
+
+// Render renders the email for one recipient. Only the HTML body is escaped; the headers,
+// preheader and text body are plain text.
+func (e *CompiledEmail) Render(data map[string]interface{}) (*RenderedEmail, error) {
+	rendered := &RenderedEmail{}
+
+	steps := []struct {
+		tmpl *Template
+		mode TemplateMode
+		dst  *string
+	}{
+		{e.fromName, TemplateModeText, &rendered.FromName},
+		{e.replyTo, TemplateModeText, &rendered.ReplyTo},
+		{e.subject, TemplateModeText, &rendered.Subject},
+		{e.preheader, TemplateModeText, &rendered.Preheader},
+		{e.htmlBody, TemplateModeHTML, &rendered.HtmlBody},
+		{e.textBody, TemplateModeText, &rendered.TextBody},
+	}
+
+	for _, step := range steps {
+		partials := e.partials.text
+		if step.mode == TemplateModeHTML {
+			partials = e.partials.html
+		}
+		out, report, err := step.tmpl.RenderWithPartials(data, step.mode, partials)
+		if err != nil {
+			return nil, err
+		}
+		*step.dst = out
+		rendered.Report.Merge(report)
+	}
+
+	rendered.FromName = strings.TrimSpace(rendered.FromName)
+	rendered.ReplyTo = strings.TrimSpace(rendered.ReplyTo)
+	rendered.Subject = strings.TrimSpace(rendered.Subject)
+	rendered.Preheader = strings.TrimSpace(rendered.Preheader)
+	rendered.HtmlBody = InjectPreheader(rendered.HtmlBody, rendered.Preheader)
+	return rendered, nil
+}
+
+// preheaderPadding keeps inbox previews from running on into the body text after a short preheader
+var preheaderPadding = strings.Repeat("&#847;&zwnj;&nbsp;", 40)
+
+// InjectPreheader inserts a hidden preheader block right after the opening <body> tag, or at the
+// start of the HTML when there is none. Inbox previews show it next to the subject.
+func InjectPreheader(htmlBody, preheader string) string {
+	if preheader == "" {
+		return htmlBody
+	}
+
+	block := `<div style="display:none;font-size:1px;line-height:1px;max-height:0;max-width:0;opacity:0;overflow:hidden;mso-hide:all;">` +
+		html.EscapeString(preheader) + preheaderPadding + `</div>`
+
+	lower := strings.ToLower(htmlBody)
+	if start := strings.Index(lower, "<body"); start >= 0 {
+		if end := strings.Index(lower[start:], ">"); end >= 0 {
+			insertAt := start + end + 1
+			return htmlBody[:insertAt] + block + htmlBody[insertAt:]
+		}
+	}
+	return block + htmlBody
+}
+
+// RenderEmailTemplate compiles and renders an email template in one step
+func RenderEmailTemplate(tmpl *models.EmailTemplate, partials []models.TemplatePartial, data map[string]interface{}) (*RenderedEmail, error) {
+	compiled, err := CompileEmailTemplate(tmpl, partials)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.Render(data)
+}
+
+// ValidateEmailTemplate checks the template syntax of every renderable part. Partials that
+// don't exist are not an error here; LintEmailTemplate warns about them.
+func ValidateEmailTemplate(tmpl *models.EmailTemplate) error {
+	_, err := CompileEmailTemplate(tmpl, nil)
+	return err
+}
+
+// BuildTemplateData builds the variables available to a template: contact.*, organization.*,
+// agent.* (the sending agent), brand.* (the organization's brand kit) and unsubscribe_url, plus
+// the original bare first_name, last_name, email and phone names so existing templates keep
+// working. Any argument may be nil.
+func BuildTemplateData(contact *models.Contact, org *models.Organization, agent *models.User, settings *models.OrganizationSettings) map[string]interface{} {
+	contactData := map[string]interface{}{
+		"id":                 "",
+		"first_name":         "",
+		"last_name":          "",
+		"full_name":          "",
+		"email":              "",
+		"phone":              "",
+		"budget_min":         0.0,
+		"budget_max":         0.0,
+		"property_type":      "",
+		"bedrooms":           0,
+		"bathrooms":          0,
+		"square_feet":        0,
+		"preferred_location": "",
+		"preferred_language": "",
+		"notes":              "",
+		"created_at":         nil,
+	}
+	if contact != nil {
+		contactData["id"] = contact.ID
+		contactData["first_name"] = contact.FirstName
+		contactData["last_name"] = contact.LastName
+		contactData["full_name"] = strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+		contactData["email"] = contact.Email
+		contactData["phone"] = contact.Phone
+		contactData["budget_min"] = contact.BudgetMin
+		contactData["budget_max"] = contact.BudgetMax
+		contactData["property_type"] = contact.PropertyType
+		contactData["bedrooms"] = contact.Bedrooms
+		contactData["bathrooms"] = contact.Bathrooms
+		contactData["square_feet"] = contact.SquareFeet
+		contactData["preferred_location"] = contact.PreferredLocation
+		contactData["preferred_language"] = contact.PreferredLanguage
+		contactData["notes"] = contact.Notes
+		contactData["created_at"] = contact.CreatedAt
+	}
+
+	orgData := map[string]interface{}{
+		"name": "",
+	}
+	if org != nil {
+		orgData["name"] = org.Name
+	}
+
+	agentData := map[string]interface{}{
+		"name":  "",
+		"email": "",
+	}
+	if agent != nil {
+		agentData["name"] = agent.Name
+		agentData["email"] = agent.Email
+	}
+
+	brandData := map[string]interface{}{
+		"logo_url":       "",
+		"primary_color":  "",
+		"office_address": "",
+	}
+	if settings != nil {
+		brandData["logo_url"] = settings.BrandLogoURL
+		brandData["primary_color"] = settings.BrandPrimaryColor
+		brandData["office_address"] = settings.BrandOfficeAddress
+	}
+
+	// Previews of the sample contact get a link that goes nowhere
+	unsubscribeURL := BuildPublicURL("/public/unsubscribe/sample")
+	if contact != nil && contact.ID != "" {
+		unsubscribeURL = BuildUnsubscribeURL(contact.ID)
+	}
+
+	return map[string]interface{}{
+		"contact":         contactData,
+		"organization":    orgData,
+		"agent":           agentData,
+		"brand":           brandData,
+		"unsubscribe_url": unsubscribeURL,
+
+		// Legacy names from before the template engine
+		"first_name": contactData["first_name"],
+		"last_name":  contactData["last_name"],
+		"email":      contactData["email"],
+		"phone":      contactData["phone"],
+	}
+}
+
+// SampleContact is a synthetic contact for previewing templates without real data
+func SampleContact() *models.Contact {
+	return &models.Contact{
+		FirstName:         "Priya",
+		LastName:          "Sharma",
+		Email:             "priya.sharma@example.com",
+		Phone:             "+91 98765 43210",
+		BudgetMin:         5000000,
+		BudgetMax:         8500000,
+		PropertyType:      "apartment",
+		Bedrooms:          3,
+		Bathrooms:         2,
+		SquareFeet:        1450,
+		PreferredLocation: "Baner, Pune",
+		IsActive:          true,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"strings"
 	textTemplate "text/template"
 
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	"github.com/go-gomail/gomail"
 )
 
@@ -71,6 +73,15 @@ func BuildFrontendInviteLink(token string) string {
 	return fmt.Sprintf(frontend+"/auth/activate?token=%s", token)
 }
 
+// BuildPublicURL returns an absolute URL on this API for links that leave the app
+func BuildPublicURL(path string) string {
+	base := os.Getenv("BASE_URL")
+	if base == "" {
+		base = "http://localhost:8080" // fallback
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
 // SendInviteEmail sends an invite email to a new agent
 func (s *EmailService) SendInviteEmail(email, name, orgName, token string) error {
 	inviteLink := BuildFrontendInviteLink(token)
@@ -100,40 +111,167 @@ func (s *EmailService) SendInviteEmail(email, name, orgName, token string) error
 	return s.sendEmail(email, subject, htmlBody, textBody)
 }
 
+// EmailMessage is an outgoing email. FromName, ReplyTo and ListUnsubscribe are optional;
+// the sending address is always SMTP_FROM.
+type EmailMessage struct {
+	To              string
+	FromName        string
+	ReplyTo         string
+	Subject         string
+	HtmlBody        string
+	TextBody        string
+	ListUnsubscribe string // one-click unsubscribe URL for inbox unsubscribe buttons
+	Attachments     []EmailAttachment
+}
+
+// EmailAttachment is a file attached to an outgoing email
+type EmailAttachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
 // SendCampaignEmail sends a campaign email to a recipient
 func (s *EmailService) SendCampaignEmail(recipientEmail, subject, htmlBody, plainBody string) error {
+	return s.SendMessage(EmailMessage{
+		To:       recipientEmail,
+		Subject:  subject,
+		HtmlBody: htmlBody,
+		TextBody: plainBody,
+	})
+}
+
+// SendMessage sends an email with the message's display name and Reply-To
+func (s *EmailService) SendMessage(msg EmailMessage) error {
 	// If SMTP is not configured, just log
 	if s.smtpConfig == nil {
 		log.Printf("📧 CAMPAIGN EMAIL (SMTP not configured - logging only)")
-		log.Printf("   To: %s", recipientEmail)
-		log.Printf("   Subject: %s", subject)
+		log.Printf("   To: %s", msg.To)
+		if msg.FromName != "" {
+			log.Printf("   From: %s", msg.FromName)
+		}
+		if msg.ReplyTo != "" {
+			log.Printf("   Reply-To: %s", msg.ReplyTo)
+		}
+		log.Printf("   Subject: %s", msg.Subject)
+		for _, attachment := range msg.Attachments {
+			log.Printf("   Attachment: %s (%d bytes)", attachment.FileName, len(attachment.Data))
+		}
 		return nil
 	}
 
 	// Send email
-	return s.sendEmail(recipientEmail, subject, htmlBody, plainBody)
+	return s.sendMessage(msg)
+}
+
+// SendSenderVerificationEmail asks the owner of an address to confirm it as a sender identity
+func (s *EmailService) SendSenderVerificationEmail(email, orgName, token string) error {
+	verifyLink := BuildPublicURL("/public/sender-identities/verify/" + token)
+
+	// If SMTP is not configured, just log
+	if s.smtpConfig == nil {
+		log.Printf("📧 SENDER VERIFICATION EMAIL (SMTP not configured - logging only)")
+		log.Printf("   To: %s", email)
+		log.Printf("   Organization: %s", orgName)
+		log.Printf("   Verify Link: %s", verifyLink)
+		return nil
+	}
+
+	subject := fmt.Sprintf("Confirm %s as a sender for %s", email, orgName)
+	textBody := fmt.Sprintf("%s wants to send campaign emails with replies going to %s.\n\nConfirm this address: %s\n\nIf you did not expect this, ignore this email.\n", orgName, email, verifyLink)
+	htmlBody := fmt.Sprintf("<p>%s wants to send campaign emails with replies going to %s.</p><p><a href=\"%s\">Confirm this address</a></p><p>If you did not expect this, ignore this email.</p>",
+		template.HTMLEscapeString(orgName), template.HTMLEscapeString(email), template.HTMLEscapeString(verifyLink))
+
+	return s.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendAppointmentConfirmation sends a contact the details of a booked appointment
+func (s *EmailService) SendAppointmentConfirmation(recipientEmail, contactName, agentName string, appointment *models.Appointment) error {
+	subject := "Your appointment is confirmed"
+	if appointment.Title != "" {
+		subject = fmt.Sprintf("Confirmed: %s", appointment.Title)
+	}
+
+	when := fmt.Sprintf("%s - %s", appointment.StartAt.Format("Monday, Jan 2, 2006 3:04 PM"), appointment.EndAt.Format("3:04 PM MST"))
+	where := appointment.Location
+	if where == "" {
+		where = appointment.PropertyAddress
+	}
+
+	// If SMTP is not configured, just log
+	if s.smtpConfig == nil {
+		log.Printf("📧 APPOINTMENT CONFIRMATION (SMTP not configured - logging only)")
+		log.Printf("   To: %s", recipientEmail)
+		log.Printf("   When: %s", when)
+		return nil
+	}
+
+	greeting := "Hi,"
+	if contactName != "" {
+		greeting = fmt.Sprintf("Hi %s,", contactName)
+	}
+
+	textBody := fmt.Sprintf("%s\n\nYour appointment is booked for %s.\n", greeting, when)
+	htmlBody := fmt.Sprintf("<p>%s</p><p>Your appointment is booked for <strong>%s</strong>.</p>",
+		template.HTMLEscapeString(greeting), template.HTMLEscapeString(when))
+	if where != "" {
+		textBody += fmt.Sprintf("Location: %s\n", where)
+		htmlBody += fmt.Sprintf("<p>Location: %s</p>", template.HTMLEscapeString(where))
+	}
+	if agentName != "" {
+		textBody += fmt.Sprintf("Your agent: %s\n", agentName)
+		htmlBody += fmt.Sprintf("<p>Your agent: %s</p>", template.HTMLEscapeString(agentName))
+	}
+
+	return s.sendEmail(recipientEmail, subject, htmlBody, textBody)
 }
 
 // sendEmail is the internal method that actually sends via SMTP
 func (s *EmailService) sendEmail(to, subject, htmlBody, textBody string) error {
+	return s.sendMessage(EmailMessage{To: to, Subject: subject, HtmlBody: htmlBody, TextBody: textBody})
+}
+
+func (s *EmailService) sendMessage(msg EmailMessage) error {
 	m := gomail.NewMessage()
-	m.SetHeader("From", s.smtpConfig.From)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
+	if msg.FromName != "" {
+		m.SetAddressHeader("From", s.smtpConfig.From, msg.FromName)
+	} else {
+		m.SetHeader("From", s.smtpConfig.From)
+	}
+	if msg.ReplyTo != "" {
+		m.SetHeader("Reply-To", msg.ReplyTo)
+	}
+	if msg.ListUnsubscribe != "" {
+		m.SetHeader("List-Unsubscribe", "<"+msg.ListUnsubscribe+">")
+		m.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
 
 	// Set both plain text and HTML bodies
-	m.SetBody("text/plain", textBody)
-	m.AddAlternative("text/html", htmlBody)
+	m.SetBody("text/plain", msg.TextBody)
+	m.AddAlternative("text/html", msg.HtmlBody)
+
+	for _, attachment := range msg.Attachments {
+		data := attachment.Data
+		m.Attach(attachment.FileName,
+			gomail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+		)
+	}
 
 	// Create dialer and send
 	d := gomail.NewDialer(s.smtpConfig.Host, s.smtpConfig.Port, s.smtpConfig.User, s.smtpConfig.Pass)
 
 	if err := d.DialAndSend(m); err != nil {
-		log.Printf("❌ Failed to send email to %s: %v", to, err)
+		log.Printf("❌ Failed to send email to %s: %v", msg.To, err)
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
-	log.Printf("✅ Email sent successfully to %s", to)
+	log.Printf("✅ Email sent successfully to %s", msg.To)
 	return nil
 }
 
@@ -220,12 +358,17 @@ func GenerateInviteLink(baseURL, token string) string {
 	return fmt.Sprintf("%s/auth/activate?token=%s", baseURL, token)
 }
 
-// SubstituteTemplateVariables replaces template variables with actual values
+// SubstituteTemplateVariables renders a template against flat string variables as plain text.
+// Templates that fail to parse are returned unchanged.
 func SubstituteTemplateVariables(template string, variables map[string]string) string {
-	result := template
+	data := make(map[string]interface{}, len(variables))
 	for key, value := range variables {
-		placeholder := fmt.Sprintf("{{%s}}", key)
-		result = strings.ReplaceAll(result, placeholder, value)
+		data[key] = value
+	}
+
+	result, _, err := RenderTemplate(template, data, TemplateModeText)
+	if err != nil {
+		return template
 	}
 	return result
 }
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"gorm.io/datatypes"
+)
+
+// TemplateFieldDiff is the line diff of one template field between two versions
+type TemplateFieldDiff struct {
+	Field string     `json:"field"`
+	Lines []DiffLine `json:"lines"`
+}
+
+type EmailTemplateService struct {
+	templateRepo   *repository.EmailTemplateRepository
+	versionRepo    *repository.EmailTemplateVersionRepository
+	attachmentRepo *repository.EmailTemplateAttachmentRepository
+}
+
+func NewEmailTemplateService() *EmailTemplateService {
+	return &EmailTemplateService{
+		templateRepo:   &repository.EmailTemplateRepository{},
+		versionRepo:    &repository.EmailTemplateVersionRepository{},
+		attachmentRepo: &repository.EmailTemplateAttachmentRepository{},
+	}
+}
+
+// SaveVersion saves the template and snapshots its current content and attachments as a new version
+func (s *EmailTemplateService) SaveVersion(template *models.EmailTemplate, createdBy string, restoredFrom *int) (*models.EmailTemplateVersion, error) {
+	attachmentIDs := datatypes.JSONSlice[string]{}
+	if template.ID != "" {
+		attachments, err := s.attachmentRepo.FindByTemplate(template.ID, template.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		for _, attachment := range attachments {
+			attachmentIDs = append(attachmentIDs, attachment.ID)
+		}
+	}
+
+	version := &models.EmailTemplateVersion{
+		Name:          template.Name,
+		Subject:       template.Subject,
+		Preheader:     template.Preheader,
+		FromName:      template.FromName,
+		ReplyTo:       template.ReplyTo,
+		HtmlBody:      template.HtmlBody,
+		PlainTextBody: template.PlainTextBody,
+		EditorType:    template.EditorType,
+		BuilderBlocks: template.BuilderBlocks,
+		AttachmentIDs: attachmentIDs,
+		RestoredFrom:  restoredFrom,
+		CreatedBy:     createdBy,
+	}
+
+	if err := s.versionRepo.CreateForTemplate(template, version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// EnsureVersioned snapshots templates created before version history existed and
+// returns the template's current version number
+func (s *EmailTemplateService) EnsureVersioned(template *models.EmailTemplate) (int, error) {
+	if template.CurrentVersion > 0 {
+		return template.CurrentVersion, nil
+	}
+	version, err := s.SaveVersion(template, template.CreatedBy, nil)
+	if err != nil {
+		return 0, err
+	}
+	return version.Version, nil
+}
+
+// TemplateContentChanged reports whether two templates differ in anything a version records
+func TemplateContentChanged(a, b *models.EmailTemplate) bool {
+	return a.Name != b.Name ||
+		a.Subject != b.Subject ||
+		a.Preheader != b.Preheader ||
+		a.FromName != b.FromName ||
+		a.ReplyTo != b.ReplyTo ||
+		a.HtmlBody != b.HtmlBody ||
+		a.PlainTextBody != b.PlainTextBody ||
+		a.EditorType != b.EditorType ||
+		!bytes.Equal(a.BuilderBlocks, b.BuilderBlocks)
+}
+
+// ApplyVersion copies a version's content onto a template
+func ApplyVersion(template *models.EmailTemplate, version *models.EmailTemplateVersion) {
+	template.Name = version.Name
+	template.Subject = version.Subject
+	template.Preheader = version.Preheader
+	template.FromName = version.FromName
+	template.ReplyTo = version.ReplyTo
+	template.HtmlBody = version.HtmlBody
+	template.PlainTextBody = version.PlainTextBody
+	// Versions from before the block builder were all HTML
+	template.EditorType = version.EditorType
+	if template.EditorType == "" {
+		template.EditorType = EditorTypeHTML
+	}
+	template.BuilderBlocks = version.BuilderBlocks
+}
+
+// DiffVersions returns line diffs for the fields that differ between two versions
+func DiffVersions(from, to *models.EmailTemplateVersion) []TemplateFieldDiff {
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"name", from.Name, to.Name},
+		{"subject", from.Subject, to.Subject},
+		{"preheader", from.Preheader, to.Preheader},
+		{"from_name", from.FromName, to.FromName},
+		{"reply_to", from.ReplyTo, to.ReplyTo},
+		{"html_body", from.HtmlBody, to.HtmlBody},
+		{"plain_text_body", from.PlainTextBody, to.PlainTextBody},
+		{"editor_type", from.EditorType, to.EditorType},
+		{"builder_blocks", string(from.BuilderBlocks), string(to.BuilderBlocks)},
+		{"attachment_ids", strings.Join(from.AttachmentIDs, "\n"), strings.Join(to.AttachmentIDs, "\n")},
+	}
+
+	diffs := []TemplateFieldDiff{}
+	for _, field := range fields {
+		if field.from == field.to {
+			continue
+		}
+		diffs = append(diffs, TemplateFieldDiff{Field: field.name, Lines: DiffLines(field.from, field.to)})
+	}
+	return diffs
+}
+
+// ResolveForCampaign returns the template content a campaign should send: the pinned
+// version, or the template as it is now when the campaign follows the latest version
+func (s *EmailTemplateService) ResolveForCampaign(campaign *models.Campaign) (*models.EmailTemplate, error) {
+	if campaign.FollowLatestTemplate {
+		return s.ResolveTemplate(campaign.TemplateID, campaign.OrganizationID, nil)
+	}
+	return s.ResolveTemplate(campaign.TemplateID, campaign.OrganizationID, campaign.TemplateVersion)
+}
+
+// ResolveForVariant returns the template content an A/B variant sends, with the variant's
+// subject in place of the template's when it has one
+func (s *EmailTemplateService) ResolveForVariant(variant *models.CampaignVariant) (*models.EmailTemplate, error) {
+	template, err := s.ResolveTemplate(variant.TemplateID, variant.OrganizationID, variant.TemplateVersion)
+	if err != nil {
+		return nil, err
+	}
+	if variant.Subject != "" {
+		template.Subject = variant.Subject
+	}
+	return template, nil
+}
+
+// ResolveTemplate returns a template at a pinned version, or as it is now when version is nil.
+// CurrentVersion is set to the version resolved, which decides the attachments it sends.
+func (s *EmailTemplateService) ResolveTemplate(templateID, orgID string, version *int) (*models.EmailTemplate, error) {
+	template, err := s.templateRepo.FindByID(templateID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == nil {
+		return template, nil
+	}
+
+	pinned, err := s.versionRepo.FindByVersion(template.ID, orgID, *version)
+	if err != nil {
+		return nil, err
+	}
+	ApplyVersion(template, pinned)
+	template.CurrentVersion = pinned.Version
+	return template, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStorage stores uploaded files by key. Keys are slash-separated relative paths.
+type FileStorage interface {
+	Save(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalFileStorage keeps files in a directory on the server's filesystem. An empty Root
+// means UPLOAD_DIR, or ./uploads when that is unset.
+type LocalFileStorage struct {
+	Root string
+}
+
+func NewLocalFileStorage() *LocalFileStorage {
+	return &LocalFileStorage{}
+}
+
+func (s *LocalFileStorage) root() string {
+	if s.Root != "" {
+		return s.Root
+	}
+	if dir := os.Getenv("UPLOAD_DIR"); dir != "" {
+		return dir
+	}
+	return "uploads" // fallback
+}
+
+// path resolves a key inside Root, rejecting keys that would escape it
+func (s *LocalFileStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.root(), cleaned), nil
+}
+
+// Save writes a file, creating parent directories as needed
+func (s *LocalFileStorage) Save(key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// Open opens a stored file for reading
+func (s *LocalFileStorage) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Delete removes a stored file. Deleting a missing file is not an error.
+func (s *LocalFileStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
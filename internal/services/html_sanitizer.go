@@ -0,0 +1,405 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Email HTML sanitizer policy. Email needs tables, inline styles, <style> blocks and
+// Outlook conditional comments, so this removes only what can run code or submit data:
+// scripts and embedded frames/objects, forms and their controls, SVG animations that can
+// rewrite links, event handler attributes, and script URLs.
+
+// sanitizerDropWithContent elements are removed together with everything inside them
+var sanitizerDropWithContent = map[string]bool{
+	"script": true, "iframe": true, "frame": true, "frameset": true, "object": true,
+	"embed": true, "applet": true, "textarea": true, "select": true,
+	// Parsed differently depending on whether the client runs scripts
+	"noscript": true, "noembed": true, "noframes": true, "xmp": true, "plaintext": true,
+	// SVG animations can set href to a script URL after the markup was checked
+	"animate": true, "set": true, "animatemotion": true, "animatetransform": true,
+}
+
+// sanitizerDropTag elements lose their tags but keep any text inside them
+var sanitizerDropTag = map[string]bool{
+	"form": true, "input": true, "button": true, "option": true, "optgroup": true,
+	"label": true, "fieldset": true, "legend": true, "base": true,
+}
+
+// sanitizerURLAttributes hold URLs that a client may load or navigate to
+var sanitizerURLAttributes = map[string]bool{
+	"href": true, "src": true, "action": true, "formaction": true, "background": true,
+	"poster": true, "lowsrc": true, "dynsrc": true, "xlink:href": true, "cite": true,
+}
+
+// SanitizeReport lists what the sanitizer removed, counted by element and by "element attribute"
+type SanitizeReport struct {
+	RemovedElements   map[string]int `json:"removed_elements"`
+	RemovedAttributes map[string]int `json:"removed_attributes"`
+}
+
+// Empty reports whether nothing was removed
+func (r SanitizeReport) Empty() bool {
+	return len(r.RemovedElements) == 0 && len(r.RemovedAttributes) == 0
+}
+
+// SanitizeEmailHTML applies the email HTML policy and reports what it removed. The markup is
+// parsed into the same tree a browser builds (including SVG/MathML content and malformed
+// comments), cleaned, and serialized again, so nothing reaches the output unparsed.
+func SanitizeEmailHTML(src string) (string, SanitizeReport) {
+	out, report := sanitizeEmailHTML(src, false)
+	// A template tag restored inside an attribute value can close the quotes around it. If a
+	// browser would read an unsafe attribute out of the restored markup, drop those tags too.
+	if !restoredHTMLIsSafe(out) {
+		out, report = sanitizeEmailHTML(src, true)
+	}
+	return out, report
+}
+
+func sanitizeEmailHTML(src string, strict bool) (string, SanitizeReport) {
+	report := SanitizeReport{
+		RemovedElements:   map[string]int{},
+		RemovedAttributes: map[string]int{},
+	}
+
+	root, tags, err := parseEmailHTML(src, strict)
+	if err != nil {
+		return html.EscapeString(src), report
+	}
+	for key, count := range tags.removed {
+		report.RemovedAttributes[key] += count
+	}
+
+	s := emailSanitizer{report: report, untag: tags.remover}
+	s.sanitizeChildren(root)
+
+	var out strings.Builder
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&out, c); err != nil {
+			return html.EscapeString(src), report
+		}
+	}
+	return tags.restorer.Replace(out.String()), report
+}
+
+// parseEmailHTML parses email markup into the tree a browser builds, with its template tags
+// protected as protectTemplateTags describes. A full document is parsed as one; anything else
+// as the contents of a <body>. Lint, the text version and the sanitizer all read markup
+// through here so they agree on what is a tag and what is text.
+func parseEmailHTML(src string, strict bool) (*html.Node, protectedHTML, error) {
+	tags := protectTemplateTags(src, strict)
+	lower := strings.ToLower(tags.html)
+	if strings.Contains(lower, "<html") || strings.Contains(lower, "<!doctype") {
+		doc, err := html.Parse(strings.NewReader(tags.html))
+		return doc, tags, err
+	}
+
+	nodes, err := html.ParseFragment(strings.NewReader(tags.html), &html.Node{
+		Type: html.ElementNode, Data: "body", DataAtom: atom.Body,
+	})
+	if err != nil {
+		return nil, tags, err
+	}
+	root := &html.Node{Type: html.DocumentNode}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	return root, tags, nil
+}
+
+type emailSanitizer struct {
+	report SanitizeReport
+	untag  *strings.Replacer // drops template tag placeholders so checks see the literal text around them
+}
+
+func (s *emailSanitizer) sanitizeChildren(parent *html.Node) {
+	for c := parent.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		switch c.Type {
+		case html.CommentNode:
+			if strings.Contains(strings.ToLower(c.Data), "<script") {
+				s.report.RemovedElements["comment"]++
+				parent.RemoveChild(c)
+			}
+
+		case html.ElementNode:
+			name := strings.ToLower(c.Data)
+			switch {
+			case sanitizerDropWithContent[name], isRefreshMeta(c),
+				name == "style" && isDangerousCSS(s.untag.Replace(elementText(c))):
+				s.report.RemovedElements[name]++
+				parent.RemoveChild(c)
+
+			case sanitizerDropTag[name]:
+				s.report.RemovedElements[name]++
+				s.sanitizeChildren(c)
+				for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+					c.RemoveChild(gc)
+					parent.InsertBefore(gc, c)
+				}
+				parent.RemoveChild(c)
+
+			default:
+				kept := c.Attr[:0:0]
+				for _, attr := range c.Attr {
+					attrName := strings.ToLower(attr.Key)
+					if attr.Namespace != "" {
+						attrName = attr.Namespace + ":" + attrName
+					}
+					if unsafeAttribute(s.untag.Replace(attrName), s.untag.Replace(attr.Val)) {
+						s.report.RemovedAttributes[name+" "+attrName]++
+						continue
+					}
+					kept = append(kept, attr)
+				}
+				c.Attr = kept
+				s.sanitizeChildren(c)
+			}
+		}
+
+		c = next
+	}
+}
+
+// unsafeAttribute reports whether an attribute must be removed
+func unsafeAttribute(name, value string) bool {
+	if strings.HasPrefix(name, "on") {
+		return true
+	}
+	if name == "style" {
+		return isDangerousCSS(value)
+	}
+	if name == "srcdoc" || name == "formaction" {
+		return true
+	}
+	if sanitizerURLAttributes[name] {
+		return isDangerousURL(name, value)
+	}
+	return false
+}
+
+// isDangerousURL catches script URLs, including ones obfuscated with whitespace or control characters
+func isDangerousURL(attrName, value string) bool {
+	var b strings.Builder
+	for _, r := range value {
+		if r > ' ' {
+			b.WriteRune(r)
+		}
+	}
+	normalized := strings.ToLower(b.String())
+
+	switch {
+	case strings.HasPrefix(normalized, "javascript:"), strings.HasPrefix(normalized, "vbscript:"):
+		return true
+	case strings.HasPrefix(normalized, "data:"):
+		// Inline images are fine; other data URLs can carry HTML or script
+		return attrName != "src" || !strings.HasPrefix(normalized, "data:image/") || strings.HasPrefix(normalized, "data:image/svg")
+	}
+	return false
+}
+
+// isDangerousCSS catches CSS that can run script in old clients
+func isDangerousCSS(css string) bool {
+	lower := strings.ToLower(css)
+	return strings.Contains(lower, "expression(") ||
+		strings.Contains(lower, "javascript:") ||
+		strings.Contains(lower, "vbscript:") ||
+		strings.Contains(lower, "behavior:") ||
+		strings.Contains(lower, "-moz-binding")
+}
+
+func isRefreshMeta(n *html.Node) bool {
+	if strings.ToLower(n.Data) != "meta" {
+		return false
+	}
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && strings.EqualFold(attr.Key, "http-equiv") {
+			return strings.EqualFold(strings.TrimSpace(attr.Val), "refresh")
+		}
+	}
+	return false
+}
+
+// elementText joins the text directly inside an element, such as the CSS of a <style> block
+func elementText(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
+
+// restoredHTMLIsSafe reports whether a browser reading sanitized markup, template tags and
+// all, finds no attribute the policy would remove
+func restoredHTMLIsSafe(markup string) bool {
+	z := html.NewTokenizer(strings.NewReader(markup))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return true
+		case html.StartTagToken, html.SelfClosingTagToken:
+			for _, attr := range z.Token().Attr {
+				if unsafeAttribute(strings.ToLower(attr.Key), attr.Val) {
+					return false
+				}
+			}
+		}
+	}
+}
+
+// protectedHTML is markup whose template tags were swapped for inert placeholders before
+// parsing. Tags can hold quotes ({{x | default "#fff"}}) and sit between table rows, so
+// parsing them as text would split attributes or move them out of the table.
+type protectedHTML struct {
+	html     string
+	remover  *strings.Replacer // placeholder -> ""
+	restorer *strings.Replacer // placeholder, as serialized -> original tag
+	removed  map[string]int    // tags dropped from inside elements, by "element template tag"
+	text     map[string]string // comment placeholder data -> tag, for tags that sat in text
+}
+
+// attr returns an element's attribute value with its template tags restored
+func (p protectedHTML) attr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			return p.restorer.Replace(a.Val), true
+		}
+	}
+	return "", false
+}
+
+// protectTemplateTags puts a comment placeholder where a tag sits in text, which the parser
+// keeps in place even inside tables, and a bare lower-case word where it sits inside a tag
+// or comment. Tags containing < or > are left for the parser to see.
+//
+// A tag is written back verbatim, so one in an element's attribute list that holds quotes,
+// = or whitespace could add attributes of its own; such tags are removed. In strict mode so
+// are tags inside attribute values that hold a double quote, which closes the serialized value.
+func protectTemplateTags(src string, strict bool) protectedHTML {
+	nonce := templatePlaceholderNonce(src)
+	var out strings.Builder
+	var restore, remove []string
+	removed := map[string]int{}
+	text := map[string]string{}
+	inTag, inComment := false, false
+	var quote byte
+	var element string
+
+	for i := 0; i < len(src); {
+		if strings.HasPrefix(src[i:], "{{") {
+			if end := templateTagEnd(src, i); end > 0 && !strings.ContainsAny(src[i:end], "<>") {
+				tag := src[i:end]
+				if inTag && (quote == 0 && strings.ContainsAny(tag, "\"'= \t\n\r\f") ||
+					strict && strings.Contains(tag, `"`)) {
+					removed[element+" template tag"]++
+					i = end
+					continue
+				}
+				ph := nonce + strconv.Itoa(len(remove)/2) + "x"
+				restore = append(restore,
+					ph+`=""`, tag,
+					"<!--"+ph+"-->", tag,
+					"&lt;!--"+ph+"--&gt;", tag,
+					ph, tag,
+				)
+				remove = append(remove, ph, "")
+				if inTag || inComment {
+					out.WriteString(ph)
+				} else {
+					out.WriteString("<!--" + ph + "-->")
+					text[ph] = tag
+				}
+				i = end
+				continue
+			}
+		}
+
+		ch := src[i]
+		switch {
+		case inComment:
+			if strings.HasPrefix(src[i:], "-->") || strings.HasPrefix(src[i:], "--!>") {
+				inComment = false
+			}
+		case inTag:
+			switch {
+			case quote != 0:
+				if ch == quote {
+					quote = 0
+				}
+			case ch == '"' || ch == '\'':
+				quote = ch
+			case ch == '>':
+				inTag = false
+			}
+		case strings.HasPrefix(src[i:], "<!--"):
+			inComment = true
+			out.WriteString("<!--")
+			i += len("<!--")
+			continue
+		case ch == '<' && i+1 < len(src) && isTagStart(src[i+1]):
+			inTag = true
+			element = tagName(src[i+1:])
+		}
+		out.WriteByte(ch)
+		i++
+	}
+
+	return protectedHTML{
+		html:     out.String(),
+		remover:  strings.NewReplacer(remove...),
+		restorer: strings.NewReplacer(restore...),
+		removed:  removed,
+		text:     text,
+	}
+}
+
+// tagName reads the lower-case element name at the start of src
+func tagName(src string) string {
+	end := 0
+	for ; end < len(src); end++ {
+		c := src[end]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-') {
+			break
+		}
+	}
+	return strings.ToLower(src[:end])
+}
+
+// templateTagEnd returns the index just past the template tag starting at start, or -1 when
+// it is unclosed. It mirrors tokenizeTemplate, where long comments may contain "}}".
+func templateTagEnd(src string, start int) int {
+	closer := "}}"
+	if strings.HasPrefix(src[start:], "{{!--") {
+		closer = "--}}"
+	}
+	end := strings.Index(src[start+2:], closer)
+	if end < 0 {
+		return -1
+	}
+	return start + 2 + end + len(closer)
+}
+
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || c == '?' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// templatePlaceholderNonce picks a placeholder prefix that cannot already appear in src
+func templatePlaceholderNonce(src string) string {
+	lower := strings.ToLower(src)
+	for {
+		buf := make([]byte, 4)
+		_, _ = rand.Read(buf)
+		nonce := "tpl" + hex.EncodeToString(buf)
+		if !strings.Contains(lower, nonce) {
+			return nonce
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlBlockElements start and end on their own line in the text version
+var htmlBlockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "center": true, "div": true,
+	"dl": true, "dt": true, "dd": true, "footer": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "main": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "table": true, "tbody": true, "thead": true, "tfoot": true,
+	"tr": true, "ul": true,
+}
+
+// htmlHiddenElements have no visible text
+var htmlHiddenElements = map[string]bool{"head": true, "script": true, "style": true, "title": true}
+
+var (
+	textSpaceRun   = regexp.MustCompile(`[ \t\f\r\n]+`)
+	textBlankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText converts email HTML to a readable plain-text version. Links become numbered
+// footnotes ("Visit our site [1]" ... "[1] https://..."); template tags are kept as they are.
+func HTMLToText(src string) string {
+	root, tags, err := parseEmailHTML(src, false)
+	if err != nil {
+		return ""
+	}
+	w := &htmlTextWriter{tags: tags}
+	w.children(root)
+
+	// Tidy up whitespace line by line
+	lines := strings.Split(w.out.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	text := strings.TrimSpace(textBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+
+	if len(w.links) > 0 {
+		var footer strings.Builder
+		footer.WriteString("\n\nLinks:\n")
+		for i, link := range w.links {
+			footer.WriteString(fmt.Sprintf("[%d] %s\n", i+1, link))
+		}
+		text += strings.TrimRight(footer.String(), "\n")
+	}
+	return text
+}
+
+// htmlTextWriter builds the text version of a parsed email
+type htmlTextWriter struct {
+	out   strings.Builder
+	links []string
+	tags  protectedHTML
+	pre   int // open <pre> elements, whose whitespace is kept
+}
+
+func (w *htmlTextWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.TextNode:
+			w.text(w.tags.restorer.Replace(c.Data))
+		case html.CommentNode:
+			// Template tags in text were parsed as comment placeholders
+			if tag, ok := w.tags.text[c.Data]; ok {
+				w.text(tag)
+			}
+		case html.ElementNode:
+			w.element(c)
+		}
+	}
+}
+
+func (w *htmlTextWriter) element(n *html.Node) {
+	name := n.Data
+	if htmlHiddenElements[name] {
+		return
+	}
+
+	switch {
+	case name == "br":
+		w.out.WriteByte('\n')
+	case name == "hr":
+		w.newline(1)
+		w.out.WriteString("--------------------")
+		w.newline(1)
+	case name == "li":
+		w.newline(1)
+		w.out.WriteString("- ")
+	case name == "td" || name == "th":
+		if current := w.out.String(); current != "" && !strings.HasSuffix(current, "\n") && !strings.HasSuffix(current, " ") {
+			w.out.WriteByte(' ')
+		}
+	case name == "img":
+		if alt, ok := w.tags.attr(n, "alt"); ok && strings.TrimSpace(alt) != "" {
+			w.out.WriteString("[" + strings.TrimSpace(alt) + "]")
+		}
+	case name == "a":
+		w.children(n)
+		href, _ := w.tags.attr(n, "href")
+		href = strings.TrimSpace(href)
+		// A link whose text is already the URL needs no footnote
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasSuffix(strings.TrimSpace(w.out.String()), href) {
+			return
+		}
+		w.links = append(w.links, href)
+		w.out.WriteString(fmt.Sprintf(" [%d]", len(w.links)))
+		return
+	case htmlBlockElements[name]:
+		w.newline(2)
+		if name == "pre" {
+			w.pre++
+			defer func() { w.pre-- }()
+		}
+		defer w.newline(2)
+	}
+	w.children(n)
+}
+
+func (w *htmlTextWriter) text(text string) {
+	if w.pre == 0 {
+		text = textSpaceRun.ReplaceAllString(text, " ")
+		// Avoid leading spaces at the start of a line
+		if current := w.out.String(); current == "" || strings.HasSuffix(current, "\n") || strings.HasSuffix(current, " ") {
+			text = strings.TrimLeft(text, " ")
+		}
+	}
+	w.out.WriteString(text)
+}
+
+// newline ends the text so far with at least count line breaks
+func (w *htmlTextWriter) newline(count int) {
+	text := w.out.String()
+	if text == "" {
+		return
+	}
+	for trailing := len(text) - len(strings.TrimRight(text, "\n")); trailing < count; trailing++ {
+		w.out.WriteByte('\n')
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"strings"
+	"time"
+)
+
+// ICSEvent is a single VEVENT of an iCalendar feed
+type ICSEvent struct {
+	UID          string
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Status       string // TENTATIVE | CONFIRMED | CANCELLED
+	LastModified time.Time
+}
+
+const icsTimeFormat = "20060102T150405Z"
+
+// BuildICSCalendar renders events as an RFC 5545 calendar with CRLF line endings
+func BuildICSCalendar(calendarName string, events []ICSEvent) string {
+	var b strings.Builder
+
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//Real Estate CRM//Appointments//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+EscapeICSText(calendarName))
+
+	now := time.Now().UTC().Format(icsTimeFormat)
+	for _, event := range events {
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+event.UID)
+		writeICSLine(&b, "DTSTAMP:"+now)
+		writeICSLine(&b, "DTSTART:"+event.Start.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "DTEND:"+event.End.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "SUMMARY:"+EscapeICSText(event.Summary))
+		if event.Description != "" {
+			writeICSLine(&b, "DESCRIPTION:"+EscapeICSText(event.Description))
+		}
+		if event.Location != "" {
+			writeICSLine(&b, "LOCATION:"+EscapeICSText(event.Location))
+		}
+		if event.Status != "" {
+			writeICSLine(&b, "STATUS:"+event.Status)
+		}
+		if !event.LastModified.IsZero() {
+			writeICSLine(&b, "LAST-MODIFIED:"+event.LastModified.UTC().Format(icsTimeFormat))
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// EscapeICSText escapes a TEXT value: backslashes, semicolons, commas and newlines
+func EscapeICSText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// FoldICSLine splits a content line into 75-octet segments joined by CRLF and a space,
+// never breaking inside a multi-byte UTF-8 character
+func FoldICSLine(line string) string {
+	const maxOctets = 75
+	if len(line) <= maxOctets {
+		return line
+	}
+
+	var b strings.Builder
+	limit := maxOctets
+	segment := 0
+	for _, r := range line {
+		size := len(string(r))
+		if segment+size > limit {
+			b.WriteString("\r\n ")
+			segment = 0
+			// Continuation lines lose one octet to the leading space
+			limit = maxOctets - 1
+		}
+		b.WriteRune(r)
+		segment += size
+	}
+	return b.String()
+}
+
+func writeICSLine(b *strings.Builder, line string) {
+	b.WriteString(FoldICSLine(line))
+	b.WriteString("\r\n")
+}
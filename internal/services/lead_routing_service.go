@@ -0,0 +1,216 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+)
+
+type LeadRoutingService struct {
+	ruleRepo     *repository.LeadRoutingRuleRepository
+	contactRepo  *repository.ContactRepository
+	userRepo     *repository.UserRepository
+	notifService *NotificationService
+}
+
+func NewLeadRoutingService() *LeadRoutingService {
+	return &LeadRoutingService{
+		ruleRepo:     &repository.LeadRoutingRuleRepository{},
+		contactRepo:  &repository.ContactRepository{},
+		userRepo:     &repository.UserRepository{},
+		notifService: NewNotificationService(),
+	}
+}
+
+// IsValidRoutingStrategy checks a routing strategy value
+func IsValidRoutingStrategy(strategy string) bool {
+	return strategy == "round_robin" || strategy == "least_loaded"
+}
+
+// RuleMatchesContact reports whether a contact satisfies every criterion of a rule.
+// Empty criteria match any contact; the budget band matches when the ranges overlap.
+func RuleMatchesContact(rule *models.LeadRoutingRule, contact *models.Contact) bool {
+	if len(rule.Locations) > 0 && !containsFold(rule.Locations, contact.PreferredLocation) {
+		return false
+	}
+
+	if len(rule.PropertyTypes) > 0 && !containsFold(rule.PropertyTypes, contact.PropertyType) {
+		return false
+	}
+
+	if rule.BudgetMin != nil || rule.BudgetMax != nil {
+		// Contacts without a budget cannot be placed in a budget band
+		if contact.BudgetMin <= 0 && contact.BudgetMax <= 0 {
+			return false
+		}
+
+		contactMax := contact.BudgetMax
+		if contactMax <= 0 {
+			contactMax = contact.BudgetMin
+		}
+		if rule.BudgetMin != nil && contactMax < *rule.BudgetMin {
+			return false
+		}
+		if rule.BudgetMax != nil && contact.BudgetMin > *rule.BudgetMax {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RouteContacts assigns each unassigned contact using the organization's routing rules.
+// Returns the number of contacts that were assigned.
+func (s *LeadRoutingService) RouteContacts(orgID string, contacts []models.Contact) (int, error) {
+	rules, err := s.ruleRepo.FindActiveByOrg(orgID)
+	if err != nil {
+		return 0, err
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	assigned := 0
+	for i := range contacts {
+		contact := &contacts[i]
+
+		// Skip contacts that were not saved or already have an agent
+		if contact.ID == "" || contact.AssignedTo != nil {
+			continue
+		}
+
+		agentID, err := s.routeContact(orgID, rules, contact)
+		if err != nil {
+			log.Printf("Lead routing failed for contact %s: %v", contact.ID, err)
+			continue
+		}
+		if agentID == "" {
+			continue
+		}
+
+		contact.AssignedTo = &agentID
+		assigned++
+
+		if err := s.notifService.NotifyLeadAssigned(orgID, agentID, contact); err != nil {
+			log.Printf("Failed to notify agent %s about lead %s: %v", agentID, contact.ID, err)
+		}
+	}
+
+	return assigned, nil
+}
+
+// RouteUnassigned routes every active contact of an organization that has no agent
+func (s *LeadRoutingService) RouteUnassigned(orgID string) (int, error) {
+	contacts, err := s.contactRepo.FindUnassigned(orgID)
+	if err != nil {
+		return 0, err
+	}
+	return s.RouteContacts(orgID, contacts)
+}
+
+// routeContact evaluates rules in order and assigns the contact using the first matching rule
+// that still has an active agent. Returns an empty agent ID when no rule applies.
+func (s *LeadRoutingService) routeContact(orgID string, rules []models.LeadRoutingRule, contact *models.Contact) (string, error) {
+	for i := range rules {
+		rule := &rules[i]
+		if !RuleMatchesContact(rule, contact) {
+			continue
+		}
+
+		candidates, err := s.activeAgents(orgID, rule.AgentIDs)
+		if err != nil {
+			return "", err
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		var agentID string
+		if rule.Strategy == "least_loaded" {
+			agentID, err = s.pickLeastLoaded(orgID, candidates)
+			if err != nil {
+				return "", err
+			}
+		} else {
+			agentID = pickRoundRobin(candidates, rule.LastAssignedAgentID)
+		}
+
+		if _, err := s.contactRepo.UpdateAssignee([]string{contact.ID}, orgID, &agentID); err != nil {
+			return "", err
+		}
+
+		rule.LastAssignedAgentID = &agentID
+		if err := s.ruleRepo.UpdateLastAssigned(rule.ID, agentID); err != nil {
+			log.Printf("Failed to update round-robin cursor for rule %s: %v", rule.ID, err)
+		}
+
+		return agentID, nil
+	}
+
+	return "", nil
+}
+
+// activeAgents filters the rule's agent list down to active users, keeping the rule's order
+func (s *LeadRoutingService) activeAgents(orgID string, agentIDs []string) ([]string, error) {
+	if len(agentIDs) == 0 {
+		return nil, nil
+	}
+
+	users, err := s.userRepo.FindActiveByIDs(parseUUID(orgID), agentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool)
+	for _, user := range users {
+		active[user.ID.String()] = true
+	}
+
+	var candidates []string
+	for _, id := range agentIDs {
+		if active[id] {
+			candidates = append(candidates, id)
+		}
+	}
+	return candidates, nil
+}
+
+// pickLeastLoaded returns the candidate holding the fewest open leads (ties go to the earliest in the list)
+func (s *LeadRoutingService) pickLeastLoaded(orgID string, candidates []string) (string, error) {
+	counts, err := s.contactRepo.CountOpenLeadsByAgent(orgID, candidates)
+	if err != nil {
+		return "", err
+	}
+
+	best := candidates[0]
+	for _, id := range candidates[1:] {
+		if counts[id] < counts[best] {
+			best = id
+		}
+	}
+	return best, nil
+}
+
+// pickRoundRobin returns the candidate after the last assigned agent, wrapping around
+func pickRoundRobin(candidates []string, lastAssigned *string) string {
+	if lastAssigned != nil {
+		for i, id := range candidates {
+			if id == *lastAssigned {
+				return candidates[(i+1)%len(candidates)]
+			}
+		}
+	}
+	return candidates[0]
+}
+
+func containsFold(list []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
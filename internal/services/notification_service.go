@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
@@ -89,6 +90,57 @@ func (s *NotificationService) NotifyCampaignSent(orgID, userID, campaignID strin
 	return s.notificationRepo.Create(&notification)
 }
 
+// NotifyCampaignApprovalRequested asks the org admins to review a campaign submitted by an
+// org_user
+func (s *NotificationService) NotifyCampaignApprovalRequested(campaign *models.Campaign, submitterName string) error {
+	admins, err := s.userRepo.FindAdminsByOrg(parseUUID(campaign.OrganizationID))
+	if err != nil {
+		return err
+	}
+
+	var notifications []models.Notification
+	for _, admin := range admins {
+		notification := models.Notification{
+			OrganizationID:    campaign.OrganizationID,
+			UserID:            admin.ID.String(),
+			NotificationType:  "campaign_approval_requested",
+			Title:             "Campaign Awaiting Approval",
+			Message:           fmt.Sprintf("%s submitted campaign \"%s\" for approval", submitterName, campaign.Name),
+			RelatedUserID:     &campaign.CreatedBy,
+			RelatedCampaignID: &campaign.ID,
+			IsRead:            false,
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if len(notifications) > 0 {
+		return s.notificationRepo.CreateBulk(notifications)
+	}
+	return nil
+}
+
+// NotifyCampaignReviewed tells the campaign's creator that it was approved or rejected
+func (s *NotificationService) NotifyCampaignReviewed(campaign *models.Campaign) error {
+	title := "Campaign Approved"
+	message := fmt.Sprintf("Your campaign \"%s\" was approved and is scheduled", campaign.Name)
+	if campaign.ReviewDecision == "rejected" {
+		title = "Campaign Rejected"
+		message = fmt.Sprintf("Your campaign \"%s\" was rejected: %s", campaign.Name, campaign.ReviewComment)
+	}
+
+	notification := models.Notification{
+		OrganizationID:    campaign.OrganizationID,
+		UserID:            campaign.CreatedBy,
+		NotificationType:  "campaign_" + campaign.ReviewDecision,
+		Title:             title,
+		Message:           message,
+		RelatedUserID:     campaign.ReviewedBy,
+		RelatedCampaignID: &campaign.ID,
+		IsRead:            false,
+	}
+	return s.notificationRepo.Create(&notification)
+}
+
 // NotifyCSVImportCompleted creates a notification when CSV import completes
 func (s *NotificationService) NotifyCSVImportCompleted(orgID, userID string, importedCount int) error {
 	notification := models.Notification{
@@ -115,6 +167,58 @@ func (s *NotificationService) NotifyCSVImportFailed(orgID, userID, errorMessage
 	return s.notificationRepo.Create(&notification)
 }
 
+// NotifyLeadAssigned creates a notification for an agent who received a lead
+func (s *NotificationService) NotifyLeadAssigned(orgID, agentID string, contact *models.Contact) error {
+	name := strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+	if name == "" {
+		name = contact.Email
+	}
+	if name == "" {
+		name = contact.Phone
+	}
+
+	notification := models.Notification{
+		OrganizationID:   orgID,
+		UserID:           agentID,
+		NotificationType: "lead_assigned",
+		Title:            "New Lead Assigned",
+		Message:          fmt.Sprintf("Lead %s has been assigned to you", name),
+		RelatedContactID: &contact.ID,
+		IsRead:           false,
+	}
+	return s.notificationRepo.Create(&notification)
+}
+
+// NotifyTaskDue reminds the assignee that a task is coming up
+func (s *NotificationService) NotifyTaskDue(task *models.Task) error {
+	notification := models.Notification{
+		OrganizationID:   task.OrganizationID,
+		UserID:           task.AssignedTo,
+		NotificationType: "task_due",
+		Title:            "Task Due Soon",
+		Message:          fmt.Sprintf("Task \"%s\" is due at %s", task.Title, task.DueAt.Format("Jan 2, 3:04 PM")),
+		RelatedContactID: task.ContactID,
+		RelatedTaskID:    &task.ID,
+		IsRead:           false,
+	}
+	return s.notificationRepo.Create(&notification)
+}
+
+// NotifyTaskOverdue tells the assignee that a task has passed its due time
+func (s *NotificationService) NotifyTaskOverdue(task *models.Task) error {
+	notification := models.Notification{
+		OrganizationID:   task.OrganizationID,
+		UserID:           task.AssignedTo,
+		NotificationType: "task_overdue",
+		Title:            "Task Overdue",
+		Message:          fmt.Sprintf("Task \"%s\" is overdue", task.Title),
+		RelatedContactID: task.ContactID,
+		RelatedTaskID:    &task.ID,
+		IsRead:           false,
+	}
+	return s.notificationRepo.Create(&notification)
+}
+
 // Helper function to parse UUID string
 func parseUUID(uuidStr string) uuid.UUID {
 	parsed, _ := uuid.Parse(uuidStr)
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// DefaultFollowUpAfterDays applies to organizations that never saved settings. Automatic
+// follow-ups are off until an organization opts in.
+const DefaultFollowUpAfterDays = 0
+
+type OrganizationSettingsService struct {
+	settingsRepo *repository.OrganizationSettingsRepository
+}
+
+func NewOrganizationSettingsService() *OrganizationSettingsService {
+	return &OrganizationSettingsService{
+		settingsRepo: &repository.OrganizationSettingsRepository{},
+	}
+}
+
+// DefaultSettings returns the settings an organization has until it saves its own
+func DefaultSettings(orgID string) *models.OrganizationSettings {
+	return &models.OrganizationSettings{
+		OrganizationID:    orgID,
+		FollowUpAfterDays: DefaultFollowUpAfterDays,
+	}
+}
+
+// NormalizeBrandColor validates a #RGB or #RRGGBB color and returns it lowercased
+func NormalizeBrandColor(color string) (string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if color == "" {
+		return "", nil
+	}
+	if len(color) != 4 && len(color) != 7 || color[0] != '#' {
+		return "", errors.New("brand_primary_color must be a hex color like #1a73e8")
+	}
+	for _, r := range color[1:] {
+		if !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'f') {
+			return "", errors.New("brand_primary_color must be a hex color like #1a73e8")
+		}
+	}
+	return color, nil
+}
+
+// ValidateBrandLogoURL checks the logo is an absolute http(s) URL that inboxes can load
+func ValidateBrandLogoURL(logoURL string) error {
+	if logoURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(logoURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("brand_logo_url must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// GetSettings returns the organization's settings, falling back to defaults
+func (s *OrganizationSettingsService) GetSettings(orgID string) (*models.OrganizationSettings, error) {
+	settings, err := s.settingsRepo.FindByOrg(orgID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultSettings(orgID), nil
+	}
+	return settings, err
+}
+
+// SaveSettings persists the organization's settings
+func (s *OrganizationSettingsService) SaveSettings(settings *models.OrganizationSettings) error {
+	return s.settingsRepo.Save(settings)
+}
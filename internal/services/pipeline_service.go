@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+)
+
+// defaultPipelineStages is the pipeline every organization starts with
+var defaultPipelineStages = []struct {
+	Name    string
+	Outcome string
+}{
+	{"New", "open"},
+	{"Contacted", "open"},
+	{"Qualified", "open"},
+	{"Showing", "open"},
+	{"Offer", "open"},
+	{"Closed Won", "won"},
+	{"Closed Lost", "lost"},
+}
+
+type PipelineService struct {
+	stageRepo   *repository.PipelineStageRepository
+	contactRepo *repository.ContactRepository
+}
+
+func NewPipelineService() *PipelineService {
+	return &PipelineService{
+		stageRepo:   &repository.PipelineStageRepository{},
+		contactRepo: &repository.ContactRepository{},
+	}
+}
+
+// IsValidStageOutcome checks a stage outcome value
+func IsValidStageOutcome(outcome string) bool {
+	return outcome == "open" || outcome == "won" || outcome == "lost"
+}
+
+// GetStages returns the organization's pipeline, seeding the default stages on first use
+func (s *PipelineService) GetStages(orgID string) ([]models.PipelineStage, error) {
+	stages, err := s.stageRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) > 0 {
+		return stages, nil
+	}
+
+	for i, def := range defaultPipelineStages {
+		stages = append(stages, models.PipelineStage{
+			OrganizationID: orgID,
+			Name:           def.Name,
+			Position:       i + 1,
+			Outcome:        def.Outcome,
+		})
+	}
+	return s.stageRepo.CreateDefaults(orgID, stages)
+}
+
+// DefaultStageID returns the ID of the first stage in the organization's pipeline
+func (s *PipelineService) DefaultStageID(orgID string) (*string, error) {
+	stages, err := s.GetStages(orgID)
+	if err != nil {
+		return nil, err
+	}
+	return &stages[0].ID, nil
+}
+
+// MoveContact moves a contact to another stage and records who moved it
+func (s *PipelineService) MoveContact(contact *models.Contact, stageID, userID string) error {
+	stage, err := s.stageRepo.FindByID(stageID, contact.OrganizationID)
+	if err != nil {
+		return errors.New("pipeline stage not found")
+	}
+
+	// Nothing to record if the contact is already in this stage
+	if contact.StageID != nil && *contact.StageID == stage.ID {
+		return nil
+	}
+
+	now := time.Now()
+	history := models.ContactStageHistory{
+		OrganizationID: contact.OrganizationID,
+		ContactID:      contact.ID,
+		FromStageID:    contact.StageID,
+		ToStageID:      stage.ID,
+		ChangedBy:      userID,
+		ChangedAt:      now,
+	}
+
+	contact.StageID = &stage.ID
+	contact.StageChangedAt = &now
+
+	return s.contactRepo.UpdateStage(contact, &history)
+}
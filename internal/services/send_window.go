@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zone names resolve even where the host has no zoneinfo
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"gorm.io/datatypes"
+)
+
+// SendWindow is when an organization's campaign emails may go out: outside its quiet hours,
+// on one of its send days, in the recipient's local time
+type SendWindow struct {
+	location   *time.Location
+	quietStart int // minutes after midnight; equal to quietEnd when quiet hours are off
+	quietEnd   int
+	days       [7]bool
+}
+
+// NewSendWindow builds an organization's send window from its settings. It returns nil when
+// campaign emails may go out at any time.
+func NewSendWindow(settings *models.OrganizationSettings) *SendWindow {
+	if settings == nil {
+		return nil
+	}
+
+	window := &SendWindow{location: time.UTC}
+	if location, err := time.LoadLocation(settings.Timezone); err == nil {
+		window.location = location
+	}
+
+	// Settings are validated when saved, so a bad value here only turns that part off
+	start, startErr := parseClock(settings.QuietHoursStart)
+	end, endErr := parseClock(settings.QuietHoursEnd)
+	if startErr == nil && endErr == nil {
+		window.quietStart, window.quietEnd = start, end
+	}
+
+	allDays := true
+	for day := range window.days {
+		window.days[day] = len(settings.CampaignSendDays) == 0 || slices.Contains(settings.CampaignSendDays, day)
+		allDays = allDays && window.days[day]
+	}
+
+	if window.quietStart == window.quietEnd && allDays {
+		return nil
+	}
+	return window
+}
+
+// NextSendTime returns the first time at or after t that falls inside the window, in the
+// contact's time zone when one is set. It returns t itself when t is already allowed.
+func (w *SendWindow) NextSendTime(t time.Time, timezone string) time.Time {
+	if w == nil {
+		return t
+	}
+
+	location := w.location
+	if timezone != "" {
+		if contactLocation, err := time.LoadLocation(timezone); err == nil {
+			location = contactLocation
+		}
+	}
+
+	// Each step moves to the end of quiet hours or to the next midnight, so two weeks of steps
+	// always reach an open time
+	local := t.In(location)
+	for range 16 {
+		minute := local.Hour()*60 + local.Minute()
+		year, month, day := local.Date()
+		switch {
+		case !w.days[local.Weekday()]:
+			local = time.Date(year, month, day+1, 0, 0, 0, 0, location)
+		case w.inQuietHours(minute):
+			// Overnight quiet hours that started this evening end tomorrow morning
+			if w.quietStart > w.quietEnd && minute >= w.quietStart {
+				day++
+			}
+			local = time.Date(year, month, day, w.quietEnd/60, w.quietEnd%60, 0, 0, location)
+		default:
+			return local.In(t.Location())
+		}
+	}
+	return t
+}
+
+func (w *SendWindow) inQuietHours(minute int) bool {
+	if w.quietStart < w.quietEnd {
+		return minute >= w.quietStart && minute < w.quietEnd
+	}
+	if w.quietStart > w.quietEnd {
+		return minute >= w.quietStart || minute < w.quietEnd
+	}
+	return false
+}
+
+// ValidateSendWindow checks and normalizes the send window settings of an organization
+func ValidateSendWindow(settings *models.OrganizationSettings) error {
+	timezone, err := NormalizeTimezone(settings.Timezone)
+	if err != nil {
+		return err
+	}
+	settings.Timezone = timezone
+
+	settings.QuietHoursStart = strings.TrimSpace(settings.QuietHoursStart)
+	settings.QuietHoursEnd = strings.TrimSpace(settings.QuietHoursEnd)
+	if (settings.QuietHoursStart == "") != (settings.QuietHoursEnd == "") {
+		return errors.New("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if settings.QuietHoursStart != "" {
+		if _, err := parseClock(settings.QuietHoursStart); err != nil {
+			return fmt.Errorf("quiet_hours_start: %w", err)
+		}
+		if _, err := parseClock(settings.QuietHoursEnd); err != nil {
+			return fmt.Errorf("quiet_hours_end: %w", err)
+		}
+	}
+
+	days := datatypes.JSONSlice[int]{}
+	for _, day := range settings.CampaignSendDays {
+		if day < 0 || day > 6 {
+			return errors.New("campaign_send_days must be weekdays from 0 (Sunday) to 6 (Saturday)")
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+	slices.Sort(days)
+	settings.CampaignSendDays = days
+	return nil
+}
+
+// NormalizeTimezone checks an IANA time zone name such as "Asia/Kolkata". An empty name
+// stays empty.
+func NormalizeTimezone(timezone string) (string, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		return "", nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return "", fmt.Errorf("unknown time zone '%s'", timezone)
+	}
+	return location.String(), nil
+}
+
+// parseClock parses a "HH:MM" time of day into minutes after midnight
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, errors.New("time must be in HH:MM format")
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
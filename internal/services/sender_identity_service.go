@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+// SenderVerificationTTL is how long a sender verification link stays valid
+const SenderVerificationTTL = 7 * 24 * time.Hour
+
+// ErrVerificationExpired is returned for verification links older than SenderVerificationTTL
+var ErrVerificationExpired = errors.New("verification link has expired")
+
+type SenderIdentityService struct {
+	identityRepo *repository.SenderIdentityRepository
+	orgRepo      *repository.OrganizationRepository
+	emailService *EmailService
+}
+
+func NewSenderIdentityService() *SenderIdentityService {
+	return &SenderIdentityService{
+		identityRepo: &repository.SenderIdentityRepository{},
+		orgRepo:      &repository.OrganizationRepository{},
+		emailService: NewEmailService(),
+	}
+}
+
+// NormalizeEmailAddress parses a bare email address, rejecting display-name forms
+func NormalizeEmailAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Address != address {
+		return "", fmt.Errorf("%q is not a valid email address", address)
+	}
+	return strings.ToLower(parsed.Address), nil
+}
+
+// ValidateFromName checks a sender display name is safe to put in a header
+func ValidateFromName(name string) error {
+	if strings.ContainsAny(name, "\r\n") {
+		return errors.New("from_name cannot contain line breaks")
+	}
+	if len(name) > 100 {
+		return errors.New("from_name must be at most 100 characters")
+	}
+	return nil
+}
+
+// CheckSender validates a rendered display name and Reply-To against the organization's
+// verified addresses. An empty Reply-To is always allowed.
+func CheckSender(fromName, replyTo string, verified map[string]bool) error {
+	if err := ValidateFromName(fromName); err != nil {
+		return err
+	}
+	if replyTo == "" {
+		return nil
+	}
+	address, err := NormalizeEmailAddress(replyTo)
+	if err != nil {
+		return fmt.Errorf("reply_to: %w", err)
+	}
+	if !verified[address] {
+		return fmt.Errorf("reply_to %s is not a verified sender identity", address)
+	}
+	return nil
+}
+
+// Create registers an address for the organization and emails it a verification link
+func (s *SenderIdentityService) Create(orgID, email, displayName, createdBy string) (*models.SenderIdentity, error) {
+	address, err := NormalizeEmailAddress(email)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateFromName(displayName); err != nil {
+		return nil, err
+	}
+
+	identity := &models.SenderIdentity{
+		OrganizationID: orgID,
+		Email:          address,
+		DisplayName:    strings.TrimSpace(displayName),
+		CreatedBy:      createdBy,
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, err
+	}
+
+	if err := s.SendVerification(identity); err != nil {
+		return identity, err
+	}
+	return identity, nil
+}
+
+// SendVerification issues a fresh verification token and emails the link
+func (s *SenderIdentityService) SendVerification(identity *models.SenderIdentity) error {
+	token, err := utils.GenerateInviteToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	identity.VerificationToken = &token
+	identity.VerificationSentAt = &now
+	if err := s.identityRepo.Update(identity); err != nil {
+		return err
+	}
+
+	orgName := ""
+	if orgUUID, err := uuid.Parse(identity.OrganizationID); err == nil {
+		if org, err := s.orgRepo.FindByID(orgUUID); err == nil {
+			orgName = org.Name
+		}
+	}
+	return s.emailService.SendSenderVerificationEmail(identity.Email, orgName, token)
+}
+
+// Verify marks the identity holding this token as verified
+func (s *SenderIdentityService) Verify(token string) (*models.SenderIdentity, error) {
+	identity, err := s.identityRepo.FindByVerificationToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if identity.VerificationSentAt == nil || time.Since(*identity.VerificationSentAt) > SenderVerificationTTL {
+		return nil, ErrVerificationExpired
+	}
+
+	now := time.Now()
+	identity.VerifiedAt = &now
+	identity.VerificationToken = nil
+	if err := s.identityRepo.Update(identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// VerifiedAddresses returns the organization's verified addresses, lower-cased
+func (s *SenderIdentityService) VerifiedAddresses(orgID string) (map[string]bool, error) {
+	identities, err := s.identityRepo.FindVerifiedByOrg(orgID)
+	if err != nil {
+		return nil, err
+	}
+	verified := make(map[string]bool, len(identities))
+	for _, identity := range identities {
+		verified[strings.ToLower(identity.Email)] = true
+	}
+	return verified, nil
+}
+
+// TemplateWarnings reports sender settings on a template that would stop it from sending.
+// Reply-To values containing template tags are checked per recipient at send time instead.
+func (s *SenderIdentityService) TemplateWarnings(orgID string, template *models.EmailTemplate) []TemplateWarning {
+	warnings := []TemplateWarning{}
+
+	if !strings.Contains(template.FromName, "{{") {
+		if err := ValidateFromName(template.FromName); err != nil {
+			warnings = append(warnings, TemplateWarning{Code: "invalid_from_name", Message: err.Error()})
+		}
+	}
+
+	replyTo := strings.TrimSpace(template.ReplyTo)
+	if replyTo == "" || strings.Contains(replyTo, "{{") {
+		return warnings
+	}
+
+	address, err := NormalizeEmailAddress(replyTo)
+	if err != nil {
+		return append(warnings, TemplateWarning{Code: "invalid_reply_to", Message: err.Error()})
+	}
+	identity, err := s.identityRepo.FindByEmail(address, orgID)
+	if err != nil {
+		return append(warnings, TemplateWarning{
+			Code:    "unverified_reply_to",
+			Message: fmt.Sprintf("%s is not a sender identity; add and verify it before sending", address),
+		})
+	}
+	if identity.VerifiedAt == nil {
+		warnings = append(warnings, TemplateWarning{
+			Code:    "unverified_reply_to",
+			Message: fmt.Sprintf("%s has not been verified yet; emails using this template will not send", address),
+		})
+	}
+	return warnings
+}
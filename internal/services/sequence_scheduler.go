@@ -0,0 +1,329 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+// SequenceBatchSize caps how many due enrollments one scheduler pass advances
+const SequenceBatchSize = 500
+
+// sequenceRun caches what advancing the enrollments of one sequence needs
+type sequenceRun struct {
+	sequence *models.Sequence
+	steps    []models.SequenceStep
+	sender   *sendContext
+	// Compiled templates by ID, so each template is parsed once per pass
+	contents map[string]*campaignContent
+}
+
+// ProcessSequences enrolls contacts from sequence triggers and advances every enrollment
+// whose next step is due
+func (s *BackgroundJobService) ProcessSequences() {
+	currentTime := time.Now()
+	s.enrollTriggeredContacts(currentTime)
+	s.advanceSequenceEnrollments(currentTime)
+}
+
+// enrollTriggeredContacts enrolls the contacts each active sequence's trigger fired for since
+// the previous pass
+func (s *BackgroundJobService) enrollTriggeredContacts(currentTime time.Time) {
+	sequences, err := s.sequenceRepo.FindActiveTriggered()
+	if err != nil {
+		log.Printf("Error finding triggered sequences: %v", err)
+		return
+	}
+
+	for i := range sequences {
+		sequence := &sequences[i]
+
+		// Only events after activation enroll contacts
+		if sequence.TriggerCheckedAt == nil {
+			s.sequenceRepo.UpdateTriggerCheckedAt(sequence.ID, currentTime)
+			continue
+		}
+		since := *sequence.TriggerCheckedAt
+
+		var contactIDs []string
+		switch sequence.TriggerType {
+		case SequenceTriggerAudienceAdded:
+			contactIDs, err = s.audienceRepo.FindAddedContactIDs(*sequence.TriggerAudienceID, since, currentTime)
+		case SequenceTriggerContactCreated:
+			contactIDs, err = s.contactRepo.FindIDsCreatedBetween(sequence.OrganizationID, since, currentTime)
+		case SequenceTriggerStageChanged:
+			contactIDs, err = s.stageHistoryRepo.FindContactIDsMovedTo(*sequence.TriggerStageID, since, currentTime)
+		}
+		if err != nil {
+			log.Printf("Error checking trigger for sequence %s: %v", sequence.ID, err)
+			continue
+		}
+
+		if len(contactIDs) > 0 {
+			enrolled, err := s.sequenceService.Enroll(sequence, contactIDs)
+			if err != nil {
+				log.Printf("Error enrolling contacts in sequence %s: %v", sequence.ID, err)
+				continue
+			}
+			if enrolled > 0 {
+				log.Printf("Sequence %s: enrolled %d contacts", sequence.ID, enrolled)
+			}
+		}
+
+		s.sequenceRepo.UpdateTriggerCheckedAt(sequence.ID, currentTime)
+	}
+}
+
+// advanceSequenceEnrollments runs the due steps of active enrollments
+func (s *BackgroundJobService) advanceSequenceEnrollments(currentTime time.Time) {
+	enrollments, err := s.enrollmentRepo.FindDue(currentTime, SequenceBatchSize)
+	if err != nil {
+		log.Printf("Error finding due sequence enrollments: %v", err)
+		return
+	}
+
+	runs := make(map[string]*sequenceRun)
+	for i := range enrollments {
+		enrollment := &enrollments[i]
+
+		run, ok := runs[enrollment.SequenceID]
+		if !ok {
+			run, err = s.newSequenceRun(enrollment.SequenceID)
+			if err != nil {
+				log.Printf("Error loading sequence %s: %v", enrollment.SequenceID, err)
+			}
+			runs[enrollment.SequenceID] = run
+		}
+		if run == nil {
+			continue
+		}
+
+		s.advanceEnrollment(run, enrollment, currentTime)
+	}
+}
+
+// newSequenceRun loads a sequence's steps and the context its emails are sent with
+func (s *BackgroundJobService) newSequenceRun(sequenceID string) (*sequenceRun, error) {
+	sequence, err := s.sequenceRepo.FindByIDOnly(sequenceID)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := s.sequenceRepo.FindSteps(sequence.ID)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := s.newSendContext(sequence.OrganizationID, sequence.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &sequenceRun{
+		sequence: sequence,
+		steps:    steps,
+		sender:   sender,
+		contents: make(map[string]*campaignContent),
+	}, nil
+}
+
+// advanceEnrollment checks an enrollment's exit conditions and runs its steps until it
+// reaches a wait step or the end of the sequence
+func (s *BackgroundJobService) advanceEnrollment(run *sequenceRun, enrollment *models.SequenceEnrollment, currentTime time.Time) {
+	contact, err := s.contactRepo.FindByID(enrollment.ContactID, enrollment.OrganizationID)
+	if err != nil {
+		s.exitEnrollment(enrollment, "removed", currentTime)
+		return
+	}
+	if reason := s.sequenceExitReason(run.sequence, enrollment, contact); reason != "" {
+		s.exitEnrollment(enrollment, reason, currentTime)
+		return
+	}
+
+	for enrollment.CurrentStep >= 1 && enrollment.CurrentStep <= len(run.steps) {
+		step := &run.steps[enrollment.CurrentStep-1]
+		next := step.Position + 1
+
+		switch step.Type {
+		case SequenceStepSendEmail:
+			s.sendSequenceEmail(run, step, enrollment, contact)
+		case SequenceStepWait:
+			enrollment.CurrentStep = next
+			enrollment.NextRunAt = currentTime.AddDate(0, 0, step.WaitDays)
+			if err := s.enrollmentRepo.Update(enrollment); err != nil {
+				log.Printf("Failed to update sequence enrollment %s: %v", enrollment.ID, err)
+			}
+			return
+		case SequenceStepBranch:
+			if s.lastSequenceMessageMatches(enrollment, step.Condition) {
+				if step.YesStep != 0 {
+					next = step.YesStep
+				}
+			} else if step.NoStep != 0 {
+				next = step.NoStep
+			}
+		case SequenceStepAddToAudience:
+			if err := s.audienceRepo.AddContacts(*step.AudienceID, []string{contact.ID}, nil); err != nil {
+				log.Printf("Sequence %s: failed to add contact %s to audience: %v", run.sequence.ID, contact.ID, err)
+			}
+		case SequenceStepCreateTask:
+			s.createSequenceTask(run, step, contact, currentTime)
+		case SequenceStepChangeStage:
+			if err := s.pipelineService.MoveContact(contact, *step.StageID, run.sequence.CreatedBy); err != nil {
+				log.Printf("Sequence %s: failed to move contact %s: %v", run.sequence.ID, contact.ID, err)
+			}
+		}
+
+		enrollment.CurrentStep = next
+	}
+
+	completedAt := currentTime
+	enrollment.Status = "completed"
+	enrollment.CompletedAt = &completedAt
+	if err := s.enrollmentRepo.Update(enrollment); err != nil {
+		log.Printf("Failed to complete sequence enrollment %s: %v", enrollment.ID, err)
+	}
+}
+
+// sequenceExitReason returns the first exit condition of a sequence the contact meets
+func (s *BackgroundJobService) sequenceExitReason(sequence *models.Sequence, enrollment *models.SequenceEnrollment, contact *models.Contact) string {
+	conditions := sequence.ExitConditions
+	if slices.Contains(conditions, SequenceExitUnsubscribed) && contact.UnsubscribedAt != nil {
+		return SequenceExitUnsubscribed
+	}
+	// A reply is logged by the agent as an email activity on the contact
+	if slices.Contains(conditions, SequenceExitReplied) {
+		if replied, _ := s.activityRepo.HasActivitySince(contact.ID, "email", enrollment.EnrolledAt); replied {
+			return SequenceExitReplied
+		}
+	}
+	if slices.Contains(conditions, SequenceExitDealClosed) {
+		if closed, _ := s.dealRepo.HasClosedWonDeal(contact.ID); closed {
+			return SequenceExitDealClosed
+		}
+	}
+	return ""
+}
+
+// exitEnrollment takes a contact out of a sequence before its last step
+func (s *BackgroundJobService) exitEnrollment(enrollment *models.SequenceEnrollment, reason string, currentTime time.Time) {
+	completedAt := currentTime
+	enrollment.Status = "exited"
+	enrollment.ExitReason = reason
+	enrollment.CompletedAt = &completedAt
+	if err := s.enrollmentRepo.Update(enrollment); err != nil {
+		log.Printf("Failed to exit sequence enrollment %s: %v", enrollment.ID, err)
+	}
+}
+
+// lastSequenceMessageMatches reports whether the last email the sequence sent the contact
+// was opened or clicked
+func (s *BackgroundJobService) lastSequenceMessageMatches(enrollment *models.SequenceEnrollment, condition string) bool {
+	if enrollment.LastMessageID == nil {
+		return false
+	}
+	message, err := s.sequenceMessageRepo.FindByID(*enrollment.LastMessageID)
+	if err != nil {
+		return false
+	}
+	if condition == "clicked" {
+		return message.ClickedAt != nil
+	}
+	return message.OpenedAt != nil
+}
+
+// sendSequenceEmail renders, logs and sends a send_email step. The template is sent as it is
+// at send time, so edits reach contacts who have not reached the step yet.
+func (s *BackgroundJobService) sendSequenceEmail(run *sequenceRun, step *models.SequenceStep, enrollment *models.SequenceEnrollment, contact *models.Contact) {
+	// Unsubscribed contacts stay enrolled unless that is an exit condition, but get no email
+	if contact.Email == "" || contact.UnsubscribedAt != nil {
+		return
+	}
+
+	message := models.SequenceMessage{
+		SequenceID:     run.sequence.ID,
+		EnrollmentID:   enrollment.ID,
+		StepID:         step.ID,
+		ContactID:      contact.ID,
+		TemplateID:     *step.TemplateID,
+		RecipientEmail: contact.Email,
+		Status:         "queued",
+	}
+
+	content, err := s.sequenceContent(run, *step.TemplateID)
+	var rendered *RenderedEmail
+	if err == nil {
+		compiled, _ := content.localized.For(contact.PreferredLanguage)
+		rendered, err = compiled.Render(BuildTemplateData(contact, run.sender.org, run.sender.agent, run.sender.brand))
+		if err == nil {
+			err = CheckSender(rendered.FromName, rendered.ReplyTo, run.sender.verifiedSenders)
+		}
+		message.Subject = content.template.Subject
+	}
+	if rendered != nil {
+		message.Subject = rendered.Subject
+	}
+	if createErr := s.sequenceMessageRepo.Create(&message); createErr != nil {
+		log.Printf("Failed to log sequence email for enrollment %s: %v", enrollment.ID, createErr)
+		return
+	}
+	enrollment.LastMessageID = &message.ID
+
+	if err != nil {
+		s.sequenceMessageRepo.UpdateStatus(message.ID, "failed", err.Error())
+		return
+	}
+
+	email := rendered.Message(contact.Email)
+	email.HtmlBody = AddTracking(email.HtmlBody, message.ID)
+	email.ListUnsubscribe = BuildUnsubscribeURL(contact.ID)
+	email.Attachments = content.attachments
+	if err := s.emailService.SendMessage(email); err != nil {
+		s.sequenceMessageRepo.UpdateStatus(message.ID, "failed", err.Error())
+		return
+	}
+	s.sequenceMessageRepo.UpdateStatus(message.ID, "sent", "")
+}
+
+// sequenceContent compiles a template for a sequence run, once per run
+func (s *BackgroundJobService) sequenceContent(run *sequenceRun, templateID string) (*campaignContent, error) {
+	if content, ok := run.contents[templateID]; ok {
+		return content, nil
+	}
+	template, err := s.templateService.ResolveTemplate(templateID, run.sequence.OrganizationID, nil)
+	if err != nil {
+		return nil, errors.New("Template not found")
+	}
+	content, err := s.compileCampaignContent(run.sender, template, nil)
+	if err != nil {
+		return nil, err
+	}
+	run.contents[templateID] = content
+	return content, nil
+}
+
+// createSequenceTask creates a create_task step's task for the contact's agent, or for the
+// sequence owner when the contact is unassigned
+func (s *BackgroundJobService) createSequenceTask(run *sequenceRun, step *models.SequenceStep, contact *models.Contact, currentTime time.Time) {
+	assignee := run.sequence.CreatedBy
+	if contact.AssignedTo != nil {
+		assignee = *contact.AssignedTo
+	}
+
+	task := models.Task{
+		OrganizationID: contact.OrganizationID,
+		AssignedTo:     assignee,
+		ContactID:      &contact.ID,
+		Title:          step.TaskTitle,
+		Description:    fmt.Sprintf("Created by sequence %s", run.sequence.Name),
+		DueAt:          currentTime.AddDate(0, 0, step.TaskDueDays),
+		Priority:       "medium",
+		Status:         "open",
+		AutoGenerated:  true,
+		CreatedBy:      run.sequence.CreatedBy,
+	}
+	if err := s.taskRepo.Create(&task); err != nil {
+		log.Printf("Sequence %s: failed to create task for contact %s: %v", run.sequence.ID, contact.ID, err)
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+)
+
+// Sequence step types
+const (
+	SequenceStepSendEmail     = "send_email"
+	SequenceStepWait          = "wait"
+	SequenceStepBranch        = "branch"
+	SequenceStepAddToAudience = "add_to_audience"
+	SequenceStepCreateTask    = "create_task"
+	SequenceStepChangeStage   = "change_stage"
+)
+
+// Sequence entry triggers
+const (
+	SequenceTriggerAudienceAdded  = "audience_added"
+	SequenceTriggerContactCreated = "contact_created"
+	SequenceTriggerStageChanged   = "stage_changed"
+)
+
+// Sequence exit conditions
+const (
+	SequenceExitUnsubscribed = "unsubscribed"
+	SequenceExitReplied      = "replied"
+	SequenceExitDealClosed   = "deal_closed"
+)
+
+// MaxSequenceSteps caps the length of a sequence
+const MaxSequenceSteps = 50
+
+// MaxSequenceWaitDays caps a single wait step
+const MaxSequenceWaitDays = 365
+
+var sequenceExitConditions = []string{SequenceExitUnsubscribed, SequenceExitReplied, SequenceExitDealClosed}
+
+type SequenceService struct {
+	sequenceRepo   *repository.SequenceRepository
+	enrollmentRepo *repository.SequenceEnrollmentRepository
+	templateRepo   *repository.EmailTemplateRepository
+	audienceRepo   *repository.AudienceRepository
+	stageRepo      *repository.PipelineStageRepository
+	contactRepo    *repository.ContactRepository
+}
+
+func NewSequenceService() *SequenceService {
+	return &SequenceService{
+		sequenceRepo:   &repository.SequenceRepository{},
+		enrollmentRepo: &repository.SequenceEnrollmentRepository{},
+		templateRepo:   &repository.EmailTemplateRepository{},
+		audienceRepo:   &repository.AudienceRepository{},
+		stageRepo:      &repository.PipelineStageRepository{},
+		contactRepo:    &repository.ContactRepository{},
+	}
+}
+
+// ValidateSequenceSettings checks a sequence's entry trigger and exit conditions
+func ValidateSequenceSettings(sequence *models.Sequence) error {
+	if sequence.Name == "" {
+		return errors.New("name is required")
+	}
+
+	switch sequence.TriggerType {
+	case "":
+		sequence.TriggerAudienceID = nil
+		sequence.TriggerStageID = nil
+	case SequenceTriggerAudienceAdded:
+		if sequence.TriggerAudienceID == nil || *sequence.TriggerAudienceID == "" {
+			return errors.New("trigger_audience_id is required for the audience_added trigger")
+		}
+		sequence.TriggerStageID = nil
+	case SequenceTriggerContactCreated:
+		sequence.TriggerAudienceID = nil
+		sequence.TriggerStageID = nil
+	case SequenceTriggerStageChanged:
+		if sequence.TriggerStageID == nil || *sequence.TriggerStageID == "" {
+			return errors.New("trigger_stage_id is required for the stage_changed trigger")
+		}
+		sequence.TriggerAudienceID = nil
+	default:
+		return errors.New("trigger_type must be 'audience_added', 'contact_created' or 'stage_changed'")
+	}
+
+	for _, condition := range sequence.ExitConditions {
+		if !slices.Contains(sequenceExitConditions, condition) {
+			return fmt.Errorf("invalid exit condition '%s'", condition)
+		}
+	}
+	return nil
+}
+
+// ValidateSequenceSteps checks and numbers a sequence's steps. Branches may only jump
+// forward, so every enrollment finishes in at most one pass over the steps.
+func ValidateSequenceSteps(steps []models.SequenceStep) error {
+	if len(steps) == 0 {
+		return errors.New("a sequence needs at least one step")
+	}
+	if len(steps) > MaxSequenceSteps {
+		return fmt.Errorf("a sequence can have at most %d steps", MaxSequenceSteps)
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		step.Position = i + 1
+
+		switch step.Type {
+		case SequenceStepSendEmail:
+			if step.TemplateID == nil || *step.TemplateID == "" {
+				return fmt.Errorf("step %d: template_id is required", step.Position)
+			}
+		case SequenceStepWait:
+			if step.WaitDays < 1 || step.WaitDays > MaxSequenceWaitDays {
+				return fmt.Errorf("step %d: wait_days must be between 1 and %d", step.Position, MaxSequenceWaitDays)
+			}
+		case SequenceStepBranch:
+			if step.Condition != "opened" && step.Condition != "clicked" {
+				return fmt.Errorf("step %d: condition must be 'opened' or 'clicked'", step.Position)
+			}
+			if !slices.ContainsFunc(steps[:i], func(s models.SequenceStep) bool { return s.Type == SequenceStepSendEmail }) {
+				return fmt.Errorf("step %d: a branch must follow a send_email step", step.Position)
+			}
+			for _, target := range []int{step.YesStep, step.NoStep} {
+				if target != 0 && (target <= step.Position || target > len(steps)) {
+					return fmt.Errorf("step %d: branch targets must be later steps", step.Position)
+				}
+			}
+		case SequenceStepAddToAudience:
+			if step.AudienceID == nil || *step.AudienceID == "" {
+				return fmt.Errorf("step %d: audience_id is required", step.Position)
+			}
+		case SequenceStepCreateTask:
+			if step.TaskTitle == "" {
+				return fmt.Errorf("step %d: task_title is required", step.Position)
+			}
+			if step.TaskDueDays < 0 {
+				return fmt.Errorf("step %d: task_due_days cannot be negative", step.Position)
+			}
+		case SequenceStepChangeStage:
+			if step.StageID == nil || *step.StageID == "" {
+				return fmt.Errorf("step %d: stage_id is required", step.Position)
+			}
+		default:
+			return fmt.Errorf("step %d: invalid step type '%s'", step.Position, step.Type)
+		}
+	}
+	return nil
+}
+
+// CheckReferences checks that the templates, audiences and stages a sequence uses belong to
+// the organization
+func (s *SequenceService) CheckReferences(orgID string, sequence *models.Sequence, steps []models.SequenceStep) error {
+	if sequence.TriggerAudienceID != nil {
+		if _, err := s.audienceRepo.FindByID(*sequence.TriggerAudienceID, orgID); err != nil {
+			return errors.New("trigger audience not found")
+		}
+	}
+	if sequence.TriggerStageID != nil {
+		if _, err := s.stageRepo.FindByID(*sequence.TriggerStageID, orgID); err != nil {
+			return errors.New("trigger pipeline stage not found")
+		}
+	}
+
+	for _, step := range steps {
+		switch step.Type {
+		case SequenceStepSendEmail:
+			if _, err := s.templateRepo.FindByID(*step.TemplateID, orgID); err != nil {
+				return fmt.Errorf("step %d: template not found", step.Position)
+			}
+		case SequenceStepAddToAudience:
+			if _, err := s.audienceRepo.FindByID(*step.AudienceID, orgID); err != nil {
+				return fmt.Errorf("step %d: audience not found", step.Position)
+			}
+		case SequenceStepChangeStage:
+			if _, err := s.stageRepo.FindByID(*step.StageID, orgID); err != nil {
+				return fmt.Errorf("step %d: pipeline stage not found", step.Position)
+			}
+		}
+	}
+	return nil
+}
+
+// Enroll starts contacts at the first step of a sequence. Contacts that are already or were
+// ever enrolled are skipped; the number of new enrollments is returned.
+func (s *SequenceService) Enroll(sequence *models.Sequence, contactIDs []string) (int, error) {
+	contacts, err := s.contactRepo.FindByIDs(contactIDs, sequence.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	enrolled := 0
+	for _, contact := range contacts {
+		enrollment := models.SequenceEnrollment{
+			SequenceID:     sequence.ID,
+			OrganizationID: sequence.OrganizationID,
+			ContactID:      contact.ID,
+			Status:         "active",
+			CurrentStep:    1,
+			NextRunAt:      now,
+			EnrolledAt:     now,
+		}
+		created, err := s.enrollmentRepo.Enroll(&enrollment)
+		if err != nil {
+			return enrolled, err
+		}
+		if created {
+			enrolled++
+		}
+	}
+	return enrolled, nil
+}
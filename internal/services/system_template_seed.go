@@ -0,0 +1,124 @@
+package services
+
+import "github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+
+// Shared blocks for the built-in library. Listing details are sample content that agents
+// replace after cloning.
+
+var seedHeader = TemplateBlock{Type: "heading", Level: 3, Text: "{{organization.name}}", Color: "{{brand.primary_color | default \"#1a73e8\"}}"}
+
+var seedSignature = TemplateBlock{Type: "text", Text: "Warm regards,\n{{agent.name}}\n{{agent.email}}"}
+
+var seedFooter = TemplateBlock{Type: "text", Align: "center", Text: "{{brand.office_address}}\n\nYou are receiving this because you enquired with {{organization.name}}. [Unsubscribe]({{unsubscribe_url}})"}
+
+// DefaultSystemTemplates returns the built-in library seeded on startup
+func DefaultSystemTemplates() []models.SystemTemplate {
+	return []models.SystemTemplate{
+		blockSystemTemplate("new-listing", "new_listing",
+			"New listing",
+			"Introduce a freshly listed property with a photo, price and visit button.",
+			"Just listed: a home we think you'll like",
+			"Fresh on the market and a close match for what you're looking for.",
+			BlockDocument{Blocks: []TemplateBlock{
+				seedHeader,
+				{Type: "heading", Text: "Just listed"},
+				{Type: "text", Text: "Hi {{contact.first_name | default \"there\"}},\n\nA new home matching your search just came on the market. Here are the details:"},
+				{Type: "property_card", Property: &PropertyCard{
+					Title:       "3 BHK apartment with garden view",
+					ImageURL:    "https://placehold.co/600x360?text=Property+photo",
+					Price:       "₹1.25 Cr",
+					Location:    "{{contact.preferred_location | default \"Baner, Pune\"}}",
+					Bedrooms:    "3",
+					Bathrooms:   "2",
+					Area:        "1,450",
+					Description: "East-facing, high floor, two covered parking spots, walking distance to schools.",
+					URL:         "https://example.com/listings/new",
+				}},
+				{Type: "button", Text: "Book a site visit", URL: "mailto:{{agent.email}}", Align: "center"},
+				seedSignature,
+				{Type: "divider"},
+				seedFooter,
+			}}),
+
+		blockSystemTemplate("price-drop", "price_drop",
+			"Price drop",
+			"Tell a contact that a property they looked at is now cheaper.",
+			"Price drop on a home you viewed",
+			"Good news: the price just came down.",
+			BlockDocument{Blocks: []TemplateBlock{
+				seedHeader,
+				{Type: "heading", Text: "The price just dropped"},
+				{Type: "text", Text: "Hi {{contact.first_name | default \"there\"}},\n\nA home you showed interest in has been reduced. Homes at the new price tend to move quickly."},
+				{Type: "property_card", Property: &PropertyCard{
+					Title:      "2 BHK apartment, ready to move",
+					ImageURL:   "https://placehold.co/600x360?text=Property+photo",
+					Price:      "Now ₹82 L (was ₹89 L)",
+					Location:   "Wakad, Pune",
+					Bedrooms:   "2",
+					Bathrooms:  "2",
+					Area:       "1,050",
+					URL:        "https://example.com/listings/price-drop",
+					ButtonText: "See the new price",
+				}},
+				{Type: "text", Text: "Reply to this email or call me if you'd like to take another look."},
+				seedSignature,
+				{Type: "divider"},
+				seedFooter,
+			}}),
+
+		blockSystemTemplate("open-house", "open_house",
+			"Open house invitation",
+			"Invite contacts to an open house with the date, time and address.",
+			"You're invited: open house this weekend",
+			"Walk through the home, meet the team and get your questions answered.",
+			BlockDocument{Blocks: []TemplateBlock{
+				seedHeader,
+				{Type: "image", ImageURL: "https://placehold.co/600x300?text=Open+house", Alt: "Open house"},
+				{Type: "heading", Text: "Open house this weekend"},
+				{Type: "text", Text: "Hi {{contact.first_name | default \"there\"}},\n\nWe're opening the doors of a new home and would love to show you around."},
+				{Type: "columns", Columns: [][]TemplateBlock{
+					{{Type: "heading", Level: 3, Text: "When"}, {Type: "text", Text: "Saturday and Sunday\n11 AM to 5 PM"}},
+					{{Type: "heading", Level: 3, Text: "Where"}, {Type: "text", Text: "Tower B, Green Meadows\nBaner, Pune"}},
+				}},
+				{Type: "button", Text: "Reserve a slot", URL: "mailto:{{agent.email}}?subject=Open%20house", Align: "center"},
+				seedSignature,
+				{Type: "divider"},
+				seedFooter,
+			}}),
+
+		blockSystemTemplate("market-update", "market_update",
+			"Monthly market update",
+			"Share prices and trends for the contact's preferred area.",
+			"{{contact.preferred_location | default \"Your area\"}}: this month's property market",
+			"Prices, demand and what it means for you.",
+			BlockDocument{Blocks: []TemplateBlock{
+				seedHeader,
+				{Type: "heading", Text: "Market update: {{contact.preferred_location | default \"your area\"}}"},
+				{Type: "text", Text: "Hi {{contact.first_name | default \"there\"}},\n\nHere is a quick look at how the market moved this month."},
+				{Type: "columns", Columns: [][]TemplateBlock{
+					{{Type: "heading", Level: 2, Text: "₹9,800", Align: "center"}, {Type: "text", Text: "Average price per sq ft", Align: "center"}},
+					{{Type: "heading", Level: 2, Text: "+4.2%", Align: "center"}, {Type: "text", Text: "Change since last year", Align: "center"}},
+					{{Type: "heading", Level: 2, Text: "38 days", Align: "center"}, {Type: "text", Text: "Average time to sell", Align: "center"}},
+				}},
+				{Type: "text", Text: "Thinking of buying or selling? I'm happy to prepare a detailed report for your property."},
+				{Type: "button", Text: "Get a free valuation", URL: "mailto:{{agent.email}}?subject=Valuation", Align: "center"},
+				seedSignature,
+				{Type: "divider"},
+				seedFooter,
+			}}),
+
+		blockSystemTemplate("home-anniversary", "anniversary",
+			"Home anniversary",
+			"Congratulate past clients on the anniversary of their purchase.",
+			"Happy home anniversary, {{contact.first_name | default \"friend\"}}!",
+			"Another year of memories in your home.",
+			BlockDocument{Blocks: []TemplateBlock{
+				seedHeader,
+				{Type: "heading", Text: "Happy home anniversary!", Align: "center"},
+				{Type: "text", Align: "center", Text: "Hi {{contact.first_name | default \"there\"}},\n\nIt's been another year since you got the keys to your home. We hope it has been full of good memories.\n\nIf you ever want to know what your home is worth today, or need a recommendation for anything home related, just reply."},
+				seedSignature,
+				{Type: "divider"},
+				seedFooter,
+			}}),
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// SystemTemplateCategories are the library sections organizations browse
+var SystemTemplateCategories = map[string]bool{
+	"new_listing":   true,
+	"price_drop":    true,
+	"open_house":    true,
+	"market_update": true,
+	"anniversary":   true,
+	"general":       true,
+}
+
+type SystemTemplateService struct {
+	systemRepo      *repository.SystemTemplateRepository
+	templateService *EmailTemplateService
+}
+
+func NewSystemTemplateService() *SystemTemplateService {
+	return &SystemTemplateService{
+		systemRepo:      &repository.SystemTemplateRepository{},
+		templateService: NewEmailTemplateService(),
+	}
+}
+
+// SystemTemplateContent returns a system template's content as an email template so it can
+// be compiled, validated and linted like one
+func SystemTemplateContent(st *models.SystemTemplate) *models.EmailTemplate {
+	return &models.EmailTemplate{
+		Name:          st.Name,
+		Subject:       st.Subject,
+		Preheader:     st.Preheader,
+		HtmlBody:      st.HtmlBody,
+		PlainTextBody: st.PlainTextBody,
+		EditorType:    st.EditorType,
+		BuilderBlocks: st.BuilderBlocks,
+	}
+}
+
+// Clone copies a system template into an organization's templates, variables and blocks
+// intact, as version 1 of a new template
+func (s *SystemTemplateService) Clone(st *models.SystemTemplate, orgID, userID, name string) (*models.EmailTemplate, error) {
+	template := SystemTemplateContent(st)
+	template.OrganizationID = orgID
+	template.CreatedBy = userID
+	template.SourceSystemTemplateID = &st.ID
+	if name != "" {
+		template.Name = name
+	}
+
+	if _, err := s.templateService.SaveVersion(template, userID, nil); err != nil {
+		return nil, err
+	}
+	if err := s.systemRepo.IncrementCloneCount(st.ID); err != nil {
+		log.Printf("Failed to count clone of system template %s: %v", st.ID, err)
+	}
+	return template, nil
+}
+
+// SeedSystemTemplates adds each built-in library template once. Existing ones, including ones
+// a super admin edited, are left alone, and ones they deleted or re-slugged stay that way.
+func (s *SystemTemplateService) SeedSystemTemplates() error {
+	seeded, err := s.systemRepo.SeededSlugs()
+	if err != nil {
+		return err
+	}
+
+	for _, seed := range DefaultSystemTemplates() {
+		if seeded[seed.Slug] {
+			continue
+		}
+		// Libraries seeded before slugs were recorded already have the template
+		_, err := s.systemRepo.FindBySlug(seed.Slug)
+		if err == nil {
+			if err := s.systemRepo.MarkSeeded(seed.Slug); err != nil {
+				return err
+			}
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := s.systemRepo.CreateSeed(&seed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blockSystemTemplate builds a published library template from blocks
+func blockSystemTemplate(slug, category, name, description, subject, preheader string, doc BlockDocument) models.SystemTemplate {
+	source, _ := json.MarshalIndent(doc, "", "  ")
+	htmlBody, textBody := CompileBlocks(&doc)
+	return models.SystemTemplate{
+		Slug:          slug,
+		Category:      category,
+		Name:          name,
+		Description:   description,
+		Subject:       subject,
+		Preheader:     preheader,
+		HtmlBody:      htmlBody,
+		PlainTextBody: textBody,
+		EditorType:    EditorTypeBlocks,
+		BuilderBlocks: datatypes.JSON(source),
+		IsPublished:   true,
+	}
+}
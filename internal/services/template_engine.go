@@ -0,0 +1,875 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TemplateMode controls how values are written into rendered output
+type TemplateMode int
+
+const (
+	// TemplateModeText writes values as-is (subjects, plain-text bodies)
+	TemplateModeText TemplateMode = iota
+	// TemplateModeHTML HTML-escapes every value so contact data cannot inject markup
+	TemplateModeHTML
+)
+
+// Sandbox limits: templates are written by users, so rendering must stay bounded
+const (
+	maxTemplateDepth     = 16
+	maxTemplateEachItems = 500
+	maxTemplateOutput    = 1 << 20 // 1 MiB
+)
+
+// TemplateReport lists variables that could not be filled while rendering
+type TemplateReport struct {
+	// UnknownVariables are referenced by the template but do not exist
+	UnknownVariables []string `json:"unknown_variables"`
+	// UnresolvedVariables exist but had no value for this recipient and no default
+	UnresolvedVariables []string `json:"unresolved_variables"`
+	// UnknownPartials are included with {{> name}} but the organization has no such partial
+	UnknownPartials []string `json:"unknown_partials"`
+}
+
+// Merge adds the variables of another report, keeping the lists sorted and unique
+func (r *TemplateReport) Merge(other TemplateReport) {
+	r.UnknownVariables = mergeSortedUnique(r.UnknownVariables, other.UnknownVariables)
+	r.UnresolvedVariables = mergeSortedUnique(r.UnresolvedVariables, other.UnresolvedVariables)
+	r.UnknownPartials = mergeSortedUnique(r.UnknownPartials, other.UnknownPartials)
+}
+
+// Template is a parsed template that can be rendered many times
+type Template struct {
+	nodes []*templateNode
+}
+
+type templateNodeKind int
+
+const (
+	nodeText templateNodeKind = iota
+	nodeOutput
+	nodeIf
+	nodeEach
+	nodePartial
+)
+
+type templateNode struct {
+	kind     templateNodeKind
+	text     string // literal text, or the partial name
+	expr     *templateExpr
+	negate   bool // {{#unless}}
+	body     []*templateNode
+	elseBody []*templateNode
+}
+
+type templateExpr struct {
+	path    string
+	filters []templateFilter
+}
+
+type templateFilter struct {
+	name string
+	args []string
+}
+
+// templateFilters are the only functions a template can call
+var templateFilters = map[string]bool{
+	"default":    true,
+	"currency":   true,
+	"number":     true,
+	"upper":      true,
+	"lower":      true,
+	"capitalize": true,
+	"trim":       true,
+	"url":        true,
+}
+
+var currencySymbols = map[string]string{
+	"INR": "₹",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"AED": "AED ",
+}
+
+// ParseTemplate parses Handlebars-style template source:
+//
+//	{{contact.first_name}}                    value (HTML-escaped in HTML mode)
+//	{{contact.first_name | default "there"}}  filters: default, currency, number, upper, lower, capitalize, trim, url
+//	{{#if x}}...{{else if y}}...{{else}}...{{/if}}
+//	{{#unless x}}...{{else}}...{{/unless}}
+//	{{#each list}}{{this.name}} {{@index}}{{else}}empty{{/each}}
+//	{{> footer}}                              include a named partial, resolved at render time
+//	{{! comment }} or {{!-- comment --}}
+func ParseTemplate(src string) (*Template, error) {
+	tokens, err := tokenizeTemplate(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &templateParser{tokens: tokens}
+	nodes, stop, err := p.parseUntil(nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if stop != "" {
+		return nil, fmt.Errorf("unexpected {{%s}}", stop)
+	}
+	return &Template{nodes: nodes}, nil
+}
+
+// ValidateTemplate reports a syntax error in template source, if any
+func ValidateTemplate(src string) error {
+	_, err := ParseTemplate(src)
+	return err
+}
+
+// RenderTemplate parses and renders template source in one step
+func RenderTemplate(src string, data map[string]interface{}, mode TemplateMode) (string, TemplateReport, error) {
+	tmpl, err := ParseTemplate(src)
+	if err != nil {
+		return "", TemplateReport{}, err
+	}
+	return tmpl.Render(data, mode)
+}
+
+// Render executes the template against data. Partial includes render as unknown partials.
+func (t *Template) Render(data map[string]interface{}, mode TemplateMode) (string, TemplateReport, error) {
+	return t.RenderWithPartials(data, mode, nil)
+}
+
+// RenderWithPartials executes the template against data, expanding {{> name}} from partials.
+// Partials render in the same mode and may include other partials.
+func (t *Template) RenderWithPartials(data map[string]interface{}, mode TemplateMode, partials map[string]*Template) (string, TemplateReport, error) {
+	r := &templateRenderer{
+		root:            data,
+		mode:            mode,
+		partials:        partials,
+		unknown:         make(map[string]bool),
+		unresolved:      make(map[string]bool),
+		unknownPartials: make(map[string]bool),
+	}
+
+	if err := r.renderNodes(t.nodes); err != nil {
+		return "", TemplateReport{}, err
+	}
+
+	report := TemplateReport{
+		UnknownVariables:    sortedKeys(r.unknown),
+		UnresolvedVariables: sortedKeys(r.unresolved),
+		UnknownPartials:     sortedKeys(r.unknownPartials),
+	}
+	return r.out.String(), report, nil
+}
+
+// Variables returns every variable path the template references, in order of first use
+func (t *Template) Variables() []string {
+	return t.VariablesWithPartials(nil)
+}
+
+// VariablesWithPartials is Variables including the variables of any partials the template uses
+func (t *Template) VariablesWithPartials(partials map[string]*Template) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	expanding := make(map[string]bool)
+
+	var walk func(nodes []*templateNode)
+	walk = func(nodes []*templateNode) {
+		for _, node := range nodes {
+			if node.expr != nil && !seen[node.expr.path] {
+				seen[node.expr.path] = true
+				paths = append(paths, node.expr.path)
+			}
+			if node.kind == nodePartial {
+				if partial, ok := partials[node.text]; ok && !expanding[node.text] {
+					expanding[node.text] = true
+					walk(partial.nodes)
+					expanding[node.text] = false
+				}
+			}
+			walk(node.body)
+			walk(node.elseBody)
+		}
+	}
+	walk(t.nodes)
+	return paths
+}
+
+// Partials returns the names of the partials the template includes directly, in order of first use
+func (t *Template) Partials() []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	var walk func(nodes []*templateNode)
+	walk = func(nodes []*templateNode) {
+		for _, node := range nodes {
+			if node.kind == nodePartial && !seen[node.text] {
+				seen[node.text] = true
+				names = append(names, node.text)
+			}
+			walk(node.body)
+			walk(node.elseBody)
+		}
+	}
+	walk(t.nodes)
+	return names
+}
+
+// --- Tokenizer ---
+
+type templateToken struct {
+	tag  bool
+	text string // raw text or trimmed tag content
+}
+
+func tokenizeTemplate(src string) ([]templateToken, error) {
+	var tokens []templateToken
+	pos := 0
+
+	for pos < len(src) {
+		start := strings.Index(src[pos:], "{{")
+		if start < 0 {
+			tokens = append(tokens, templateToken{text: src[pos:]})
+			break
+		}
+		start += pos
+		if start > pos {
+			tokens = append(tokens, templateToken{text: src[pos:start]})
+		}
+
+		// Long comments may contain "}}"
+		if strings.HasPrefix(src[start:], "{{!--") {
+			end := strings.Index(src[start:], "--}}")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed comment at position %d", start)
+			}
+			pos = start + end + len("--}}")
+			continue
+		}
+
+		end := strings.Index(src[start+2:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed tag at position %d", start)
+		}
+		content := strings.TrimSpace(src[start+2 : start+2+end])
+		pos = start + 2 + end + 2
+
+		if strings.HasPrefix(content, "!") {
+			continue
+		}
+		if content == "" {
+			return nil, fmt.Errorf("empty tag at position %d", start)
+		}
+		tokens = append(tokens, templateToken{tag: true, text: content})
+	}
+
+	return tokens, nil
+}
+
+// --- Parser ---
+
+type templateParser struct {
+	tokens []templateToken
+	pos    int
+}
+
+// parseUntil collects nodes until one of the stop tags (an exact match, or a prefix match
+// for "else") and returns the tag it stopped at
+func (p *templateParser) parseUntil(stops []string, depth int) ([]*templateNode, string, error) {
+	if depth > maxTemplateDepth {
+		return nil, "", errors.New("blocks are nested too deeply")
+	}
+
+	var nodes []*templateNode
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		p.pos++
+
+		if !tok.tag {
+			nodes = append(nodes, &templateNode{kind: nodeText, text: tok.text})
+			continue
+		}
+
+		for _, stop := range stops {
+			if tok.text == stop || (stop == "else" && strings.HasPrefix(tok.text, "else ")) {
+				return nodes, tok.text, nil
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(tok.text, "#"):
+			node, err := p.parseBlock(tok.text[1:], depth)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, node)
+		case strings.HasPrefix(tok.text, ">"):
+			name := strings.TrimSpace(tok.text[1:])
+			if !IsTemplatePartialName(name) {
+				return nil, "", fmt.Errorf("invalid partial name %q", name)
+			}
+			nodes = append(nodes, &templateNode{kind: nodePartial, text: name})
+		case strings.HasPrefix(tok.text, "/") || tok.text == "else" || strings.HasPrefix(tok.text, "else "):
+			return nil, "", fmt.Errorf("unexpected {{%s}}", tok.text)
+		default:
+			expr, err := parseTemplateExpr(tok.text)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, &templateNode{kind: nodeOutput, expr: expr})
+		}
+	}
+
+	if len(stops) > 0 {
+		return nil, "", fmt.Errorf("missing {{%s}}", stops[len(stops)-1])
+	}
+	return nodes, "", nil
+}
+
+func (p *templateParser) parseBlock(open string, depth int) (*templateNode, error) {
+	name, arg, _ := strings.Cut(open, " ")
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return nil, fmt.Errorf("{{#%s}} needs an argument", name)
+	}
+
+	switch name {
+	case "if":
+		return p.parseIf(arg, false, "/if", depth)
+	case "unless":
+		return p.parseIf(arg, true, "/unless", depth)
+	case "each":
+		expr, err := parseTemplateExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		node := &templateNode{kind: nodeEach, expr: expr}
+		body, stop, err := p.parseUntil([]string{"else", "/each"}, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.body = body
+		if stop != "/each" {
+			if stop != "else" {
+				return nil, fmt.Errorf("unexpected {{%s}} inside {{#each}}", stop)
+			}
+			if node.elseBody, _, err = p.parseUntil([]string{"/each"}, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	default:
+		return nil, fmt.Errorf("unknown block helper {{#%s}}", name)
+	}
+}
+
+// parseIf parses an if/unless body; "else if" chains become nested ifs sharing one closing tag
+func (p *templateParser) parseIf(cond string, negate bool, closing string, depth int) (*templateNode, error) {
+	expr, err := parseTemplateExpr(cond)
+	if err != nil {
+		return nil, err
+	}
+	node := &templateNode{kind: nodeIf, expr: expr, negate: negate}
+
+	body, stop, err := p.parseUntil([]string{"else", closing}, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	node.body = body
+
+	switch {
+	case stop == closing:
+		return node, nil
+	case stop == "else":
+		if node.elseBody, _, err = p.parseUntil([]string{closing}, depth+1); err != nil {
+			return nil, err
+		}
+	case strings.HasPrefix(stop, "else if ") && !negate:
+		nested, err := p.parseIf(strings.TrimSpace(strings.TrimPrefix(stop, "else if ")), false, closing, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.elseBody = []*templateNode{nested}
+	default:
+		return nil, fmt.Errorf("unexpected {{%s}}", stop)
+	}
+	return node, nil
+}
+
+// parseTemplateExpr parses "path | filter arg | filter"
+func parseTemplateExpr(src string) (*templateExpr, error) {
+	parts, err := splitTemplateArgs(src, '|')
+	if err != nil {
+		return nil, err
+	}
+
+	path := strings.TrimSpace(parts[0])
+	if !isTemplatePath(path) {
+		return nil, fmt.Errorf("invalid variable name %q", path)
+	}
+
+	expr := &templateExpr{path: path}
+	for _, part := range parts[1:] {
+		fields, err := splitTemplateArgs(strings.TrimSpace(part), ' ')
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 || fields[0] == "" {
+			return nil, fmt.Errorf("empty filter in %q", src)
+		}
+		name := fields[0]
+		if !templateFilters[name] {
+			return nil, fmt.Errorf("unknown filter %q", name)
+		}
+
+		var args []string
+		for _, field := range fields[1:] {
+			if field == "" {
+				continue
+			}
+			args = append(args, unquoteTemplateArg(field))
+		}
+		expr.filters = append(expr.filters, templateFilter{name: name, args: args})
+	}
+	return expr, nil
+}
+
+// splitTemplateArgs splits on sep outside double or single quotes
+func splitTemplateArgs(src string, sep rune) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	var quote rune
+
+	for _, r := range src {
+		switch {
+		case quote != 0:
+			current.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			current.WriteRune(r)
+		case r == sep:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string in %q", src)
+	}
+	parts = append(parts, current.String())
+	return parts, nil
+}
+
+func unquoteTemplateArg(arg string) string {
+	if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') && arg[len(arg)-1] == arg[0] {
+		return arg[1 : len(arg)-1]
+	}
+	return arg
+}
+
+// IsTemplatePartialName reports whether name can be included as {{> name}}: 1-50 lowercase
+// letters, digits, underscores or hyphens
+func IsTemplatePartialName(name string) bool {
+	if name == "" || len(name) > 50 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isTemplatePath(path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			return false
+		}
+		for i, r := range segment {
+			if r == '@' && i == 0 {
+				continue
+			}
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// --- Renderer ---
+
+type templateScope struct {
+	item  interface{}
+	index int
+}
+
+type templateRenderer struct {
+	root            map[string]interface{}
+	scopes          []templateScope
+	mode            TemplateMode
+	partials        map[string]*Template
+	partialDepth    int
+	out             strings.Builder
+	unknown         map[string]bool
+	unresolved      map[string]bool
+	unknownPartials map[string]bool
+}
+
+func (r *templateRenderer) renderNodes(nodes []*templateNode) error {
+	for _, node := range nodes {
+		switch node.kind {
+		case nodeText:
+			r.out.WriteString(node.text)
+
+		case nodeOutput:
+			value, found := r.lookup(node.expr.path)
+			if !found {
+				r.unknown[node.expr.path] = true
+			}
+			hasDefault := false
+			for _, f := range node.expr.filters {
+				if f.name == "default" {
+					hasDefault = true
+				}
+				value = applyTemplateFilter(f, value)
+			}
+			text := formatTemplateValue(value)
+			if found && text == "" && !hasDefault {
+				r.unresolved[node.expr.path] = true
+			}
+			if r.mode == TemplateModeHTML {
+				text = html.EscapeString(text)
+			}
+			r.out.WriteString(text)
+
+		case nodeIf:
+			value, found := r.lookup(node.expr.path)
+			if !found {
+				r.unknown[node.expr.path] = true
+			}
+			for _, f := range node.expr.filters {
+				value = applyTemplateFilter(f, value)
+			}
+			branch := node.body
+			if isTemplateTruthy(value) == node.negate {
+				branch = node.elseBody
+			}
+			if err := r.renderNodes(branch); err != nil {
+				return err
+			}
+
+		case nodeEach:
+			value, found := r.lookup(node.expr.path)
+			if !found {
+				r.unknown[node.expr.path] = true
+			}
+			items := templateList(value)
+			if len(items) == 0 {
+				if err := r.renderNodes(node.elseBody); err != nil {
+					return err
+				}
+				continue
+			}
+			if len(items) > maxTemplateEachItems {
+				items = items[:maxTemplateEachItems]
+			}
+			for i, item := range items {
+				r.scopes = append(r.scopes, templateScope{item: item, index: i})
+				err := r.renderNodes(node.body)
+				r.scopes = r.scopes[:len(r.scopes)-1]
+				if err != nil {
+					return err
+				}
+			}
+
+		case nodePartial:
+			partial, ok := r.partials[node.text]
+			if !ok {
+				r.unknownPartials[node.text] = true
+				continue
+			}
+			// Also stops a partial that includes itself
+			if r.partialDepth >= maxTemplateDepth {
+				return fmt.Errorf("partial {{> %s}} is nested too deeply", node.text)
+			}
+			r.partialDepth++
+			err := r.renderNodes(partial.nodes)
+			r.partialDepth--
+			if err != nil {
+				return err
+			}
+		}
+
+		if r.out.Len() > maxTemplateOutput {
+			return errors.New("rendered template is too large")
+		}
+	}
+	return nil
+}
+
+// lookup resolves a dotted path against the innermost loop item first, then the root data
+func (r *templateRenderer) lookup(path string) (interface{}, bool) {
+	segments := strings.Split(path, ".")
+
+	if segments[0] == "@index" {
+		if len(r.scopes) == 0 {
+			return nil, false
+		}
+		return r.scopes[len(r.scopes)-1].index, true
+	}
+
+	if segments[0] == "this" {
+		if len(r.scopes) == 0 {
+			return nil, false
+		}
+		return descendTemplateValue(r.scopes[len(r.scopes)-1].item, segments[1:])
+	}
+
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if item, ok := r.scopes[i].item.(map[string]interface{}); ok {
+			if _, has := item[segments[0]]; has {
+				return descendTemplateValue(item, segments)
+			}
+		}
+	}
+
+	return descendTemplateValue(r.root, segments)
+}
+
+func descendTemplateValue(value interface{}, segments []string) (interface{}, bool) {
+	for _, segment := range segments {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[segment]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func templateList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i := range v {
+			items[i] = v[i]
+		}
+		return items
+	case []string:
+		items := make([]interface{}, len(v))
+		for i := range v {
+			items[i] = v[i]
+		}
+		return items
+	}
+	return nil
+}
+
+func isTemplateTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.TrimSpace(v) != ""
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	case []map[string]interface{}:
+		return len(v) > 0
+	case []string:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	case time.Time:
+		return !v.IsZero()
+	}
+	return true
+}
+
+func formatTemplateValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("Jan 2, 2006")
+	case []string:
+		return strings.Join(v, ", ")
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, formatTemplateValue(item))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return ""
+}
+
+func applyTemplateFilter(f templateFilter, value interface{}) interface{} {
+	arg := func(i int) string {
+		if i < len(f.args) {
+			return f.args[i]
+		}
+		return ""
+	}
+
+	switch f.name {
+	case "default":
+		if !isTemplateTruthy(value) {
+			return arg(0)
+		}
+		return value
+	case "upper":
+		return strings.ToUpper(formatTemplateValue(value))
+	case "lower":
+		return strings.ToLower(formatTemplateValue(value))
+	case "trim":
+		return strings.TrimSpace(formatTemplateValue(value))
+	case "url":
+		// Links filled in at render time must still be http(s), mailto or tel
+		link := strings.TrimSpace(formatTemplateValue(value))
+		if !isSafeLink(link) {
+			return ""
+		}
+		return link
+	case "capitalize":
+		text := formatTemplateValue(value)
+		for i, r := range text {
+			return text[:i] + string(unicode.ToUpper(r)) + text[i+len(string(r)):]
+		}
+		return text
+	case "number":
+		n, ok := templateNumber(value)
+		if !ok {
+			return value
+		}
+		decimals, _ := strconv.Atoi(arg(0))
+		return FormatGroupedNumber(n, decimals)
+	case "currency":
+		n, ok := templateNumber(value)
+		if !ok {
+			return value
+		}
+		code := strings.ToUpper(arg(0))
+		if code == "" {
+			code = "INR"
+		}
+		symbol, known := currencySymbols[code]
+		if !known {
+			symbol = code + " "
+		}
+		decimals := 0
+		if n != math.Trunc(n) {
+			decimals = 2
+		}
+		if n < 0 {
+			return "-" + symbol + FormatGroupedNumber(-n, decimals)
+		}
+		return symbol + FormatGroupedNumber(n, decimals)
+	}
+	return value
+}
+
+func templateNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// FormatGroupedNumber formats a number with thousands separators and a fixed number of decimals
+func FormatGroupedNumber(n float64, decimals int) string {
+	if decimals < 0 || decimals > 6 {
+		decimals = 0
+	}
+
+	formatted := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(formatted, ".")
+
+	var b strings.Builder
+	if n < 0 {
+		b.WriteByte('-')
+	}
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if fracPart != "" {
+		b.WriteByte('.')
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func mergeSortedUnique(a, b []string) []string {
+	set := make(map[string]bool)
+	for _, v := range a {
+		set[v] = true
+	}
+	for _, v := range b {
+		set[v] = true
+	}
+	return sortedKeys(set)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"golang.org/x/net/html"
+)
+
+// MaxEmailHTMLSize is where Gmail starts clipping messages
+const MaxEmailHTMLSize = 102 * 1024
+
+// LintEmailTemplate reports problems that won't stop a template from saving but will hurt
+// delivery or rendering: unknown variables or partials, no unsubscribe link, broken or relative
+// links, images without alt text and oversized HTML. partials are the organization's partials;
+// an unsubscribe link in an included footer counts.
+func LintEmailTemplate(tmpl *models.EmailTemplate, partials []models.TemplatePartial) []TemplateWarning {
+	warnings := []TemplateWarning{}
+	seen := make(map[string]bool)
+	add := func(code, message string) {
+		if !seen[code+message] {
+			seen[code+message] = true
+			warnings = append(warnings, TemplateWarning{Code: code, Message: message})
+		}
+	}
+
+	compiled, err := CompileEmailTemplate(tmpl, partials)
+	if err != nil {
+		add("invalid_template", err.Error())
+		return warnings
+	}
+
+	if rendered, err := compiled.Render(BuildTemplateData(SampleContact(), nil, nil, nil)); err == nil {
+		for _, variable := range rendered.Report.UnknownVariables {
+			add("unknown_variable", fmt.Sprintf("Unknown variable {{%s}}", variable))
+		}
+		for _, name := range rendered.Report.UnknownPartials {
+			add("unknown_partial", fmt.Sprintf("No partial named %q; {{> %s}} will render empty", name, name))
+		}
+	} else {
+		add("invalid_template", err.Error())
+	}
+
+	hasUnsubscribe := false
+	for _, variable := range compiled.htmlBody.VariablesWithPartials(compiled.partials.html) {
+		if variable == "unsubscribe_url" {
+			hasUnsubscribe = true
+		}
+	}
+	if !hasUnsubscribe {
+		add("missing_unsubscribe", "Add an unsubscribe link, e.g. <a href=\"{{unsubscribe_url}}\">Unsubscribe</a>")
+	}
+
+	if root, tags, err := parseEmailHTML(tmpl.HtmlBody, false); err == nil {
+		for n := range root.Descendants() {
+			if n.Type != html.ElementNode {
+				continue
+			}
+			switch n.Data {
+			case "a", "area":
+				if href, ok := tags.attr(n, "href"); ok {
+					if code, message := checkEmailLink(href); code != "" {
+						add(code, message)
+					}
+				}
+			case "img":
+				src, _ := tags.attr(n, "src")
+				if code, message := checkEmailLink(src); code != "" {
+					add(code, message)
+				}
+				if _, ok := tags.attr(n, "alt"); !ok {
+					add("image_missing_alt", fmt.Sprintf("Image %q has no alt text", src))
+				}
+			}
+		}
+	}
+
+	if len(tmpl.HtmlBody) > MaxEmailHTMLSize {
+		add("html_too_large", fmt.Sprintf("HTML is %d KB; Gmail clips messages over %d KB", len(tmpl.HtmlBody)/1024, MaxEmailHTMLSize/1024))
+	}
+
+	return warnings
+}
+
+// checkEmailLink returns a warning code and message for a link that won't work from an inbox
+func checkEmailLink(link string) (string, string) {
+	link = strings.TrimSpace(link)
+
+	// Template variables are filled in at send time
+	if strings.Contains(link, "{{") {
+		return "", ""
+	}
+	if link == "" || link == "#" {
+		return "broken_link", "Link or image has an empty URL"
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return "broken_link", fmt.Sprintf("%q is not a valid URL", link)
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		if parsed.Host == "" {
+			return "broken_link", fmt.Sprintf("%q has no host", link)
+		}
+	case "mailto", "tel", "cid", "data":
+	case "":
+		if strings.HasPrefix(link, "#") {
+			return "", ""
+		}
+		return "relative_link", fmt.Sprintf("%q is relative and won't work in an inbox; use an absolute URL", link)
+	default:
+		return "broken_link", fmt.Sprintf("%q uses an unsupported scheme", link)
+	}
+	return "", ""
+}
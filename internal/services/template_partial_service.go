@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+)
+
+type TemplatePartialService struct {
+	partialRepo  *repository.TemplatePartialRepository
+	templateRepo *repository.EmailTemplateRepository
+}
+
+func NewTemplatePartialService() *TemplatePartialService {
+	return &TemplatePartialService{
+		partialRepo:  &repository.TemplatePartialRepository{},
+		templateRepo: &repository.EmailTemplateRepository{},
+	}
+}
+
+// ValidatePartials checks that a set of partials parses and that no partial includes itself,
+// directly or through other partials
+func ValidatePartials(partials []models.TemplatePartial) error {
+	for _, partial := range partials {
+		if !IsTemplatePartialName(partial.Name) {
+			return fmt.Errorf("invalid partial name %q: use lowercase letters, digits, '_' or '-'", partial.Name)
+		}
+	}
+
+	compiled, err := CompilePartials(partials)
+	if err != nil {
+		return err
+	}
+
+	includes := make(map[string][]string, len(partials))
+	for name := range compiled.html {
+		includes[name] = append(compiled.html[name].Partials(), compiled.text[name].Partials()...)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(includes))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("partial %s includes itself", name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, included := range includes[name] {
+			if _, exists := includes[included]; exists {
+				if err := visit(included); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for name := range includes {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateChange validates the organization's partials as they would be after saving partial
+func (s *TemplatePartialService) ValidateChange(partial *models.TemplatePartial) error {
+	existing, err := s.partialRepo.FindAllByOrg(partial.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	candidate := []models.TemplatePartial{*partial}
+	for _, other := range existing {
+		if other.ID != partial.ID {
+			candidate = append(candidate, other)
+		}
+	}
+	return ValidatePartials(candidate)
+}
+
+// UsedBy returns the names of the templates and other partials that include a partial
+func (s *TemplatePartialService) UsedBy(orgID, name string) ([]string, error) {
+	var users []string
+
+	templates, err := s.templateRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		compiled, err := CompileEmailTemplate(&templates[i], nil)
+		if err != nil {
+			continue
+		}
+		if compiled.includes(name) {
+			users = append(users, templates[i].Name)
+		}
+	}
+
+	partials, err := s.partialRepo.FindAllByOrg(orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, partial := range partials {
+		if partial.Name == name {
+			continue
+		}
+		compiled, err := CompilePartials([]models.TemplatePartial{partial})
+		if err != nil {
+			continue
+		}
+		if slices.Contains(compiled.html[partial.Name].Partials(), name) || slices.Contains(compiled.text[partial.Name].Partials(), name) {
+			users = append(users, "partial "+partial.Name)
+		}
+	}
+	return users, nil
+}
+
+// includes reports whether any part of the email includes the named partial directly
+func (e *CompiledEmail) includes(name string) bool {
+	for _, part := range []*Template{e.fromName, e.replyTo, e.subject, e.preheader, e.htmlBody, e.textBody} {
+		if slices.Contains(part.Partials(), name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+)
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// NormalizeLocale lowercases a language tag such as "en", "hi" or "pt_BR" to the form stored
+// on contacts and template variants ("pt-br"). An empty tag stays empty.
+func NormalizeLocale(locale string) (string, error) {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if locale == "" {
+		return "", nil
+	}
+	if !localePattern.MatchString(locale) {
+		return "", errors.New("language must be a language code such as en, hi or pt-BR")
+	}
+	return locale, nil
+}
+
+// ApplyVariant returns a copy of the template with a variant's content in place of the
+// template's default content
+func ApplyVariant(tmpl *models.EmailTemplate, variant *models.EmailTemplateVariant) *models.EmailTemplate {
+	localized := *tmpl
+	localized.Subject = variant.Subject
+	localized.Preheader = variant.Preheader
+	localized.HtmlBody = variant.HtmlBody
+	localized.PlainTextBody = variant.PlainTextBody
+	return &localized
+}
+
+// MatchVariant picks the variant for a contact's language: an exact match, then the base
+// language ("pt" for "pt-br"). nil means the template's default content.
+func MatchVariant(variants []models.EmailTemplateVariant, locale string) *models.EmailTemplateVariant {
+	if locale == "" {
+		return nil
+	}
+	for i := range variants {
+		if variants[i].Locale == locale {
+			return &variants[i]
+		}
+	}
+	base, _, found := strings.Cut(locale, "-")
+	if !found {
+		return nil
+	}
+	for i := range variants {
+		if variants[i].Locale == base {
+			return &variants[i]
+		}
+	}
+	return nil
+}
+
+// LocalizedEmail holds a template and its locale variants compiled once for a campaign run
+type LocalizedEmail struct {
+	defaultEmail *CompiledEmail
+	variants     []models.EmailTemplateVariant
+	compiled     map[string]*CompiledEmail
+}
+
+// CompileLocalizedEmail parses the template's default content and every variant. A syntax
+// error in any variant fails the whole compile.
+func CompileLocalizedEmail(tmpl *models.EmailTemplate, variants []models.EmailTemplateVariant, partials []models.TemplatePartial) (*LocalizedEmail, error) {
+	compiledPartials, err := CompilePartials(partials)
+	if err != nil {
+		return nil, err
+	}
+
+	localized := &LocalizedEmail{
+		variants: variants,
+		compiled: make(map[string]*CompiledEmail, len(variants)),
+	}
+	if localized.defaultEmail, err = compileEmailParts(tmpl, compiledPartials); err != nil {
+		return nil, err
+	}
+	for i := range variants {
+		compiled, err := compileEmailParts(ApplyVariant(tmpl, &variants[i]), compiledPartials)
+		if err != nil {
+			return nil, fmt.Errorf("variant %s: %w", variants[i].Locale, err)
+		}
+		localized.compiled[variants[i].Locale] = compiled
+	}
+	return localized, nil
+}
+
+// For returns the compiled email for a contact's language along with the variant locale it
+// uses, empty for the template default
+func (e *LocalizedEmail) For(locale string) (*CompiledEmail, string) {
+	if variant := MatchVariant(e.variants, locale); variant != nil {
+		return e.compiled[variant.Locale], variant.Locale
+	}
+	return e.defaultEmail, ""
+}
@@ -0,0 +1,77 @@
+package services
+
+import "strings"
+
+// maxDiffLines bounds the LCS table; longer inputs fall back to a whole-block replacement
+const maxDiffLines = 2000
+
+// DiffLine is one line of a line-based diff
+type DiffLine struct {
+	Op   string `json:"op"` // equal | add | remove
+	Text string `json:"text"`
+}
+
+// DiffLines computes a line diff from a to b using the longest common subsequence
+func DiffLines(a, b string) []DiffLine {
+	aLines := splitDiffLines(a)
+	bLines := splitDiffLines(b)
+
+	if len(aLines) > maxDiffLines || len(bLines) > maxDiffLines {
+		diff := make([]DiffLine, 0, len(aLines)+len(bLines))
+		for _, line := range aLines {
+			diff = append(diff, DiffLine{Op: "remove", Text: line})
+		}
+		for _, line := range bLines {
+			diff = append(diff, DiffLine{Op: "add", Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the LCS length of aLines[i:] and bLines[j:]
+	lcs := make([][]int, len(aLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bLines)+1)
+	}
+	for i := len(aLines) - 1; i >= 0; i-- {
+		for j := len(bLines) - 1; j >= 0; j-- {
+			if aLines[i] == bLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []DiffLine
+	i, j := 0, 0
+	for i < len(aLines) && j < len(bLines) {
+		switch {
+		case aLines[i] == bLines[j]:
+			diff = append(diff, DiffLine{Op: "equal", Text: aLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: "remove", Text: aLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: "add", Text: bLines[j]})
+			j++
+		}
+	}
+	for ; i < len(aLines); i++ {
+		diff = append(diff, DiffLine{Op: "remove", Text: aLines[i]})
+	}
+	for ; j < len(bLines); j++ {
+		diff = append(diff, DiffLine{Op: "add", Text: bLines[j]})
+	}
+	return diff
+}
+
+func splitDiffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
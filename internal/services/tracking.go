@@ -0,0 +1,84 @@
+package services
+
+import (
+	"encoding/base64"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/utils"
+)
+
+// Tracking links carry their own prefixes so tokens minted for other links don't verify
+const (
+	openTrackingPrefix  = "open:"
+	clickTrackingPrefix = "click:"
+)
+
+// BuildOpenTrackingURL returns the signed tracking pixel URL for a campaign email
+func BuildOpenTrackingURL(logID string) string {
+	return BuildPublicURL("/public/track/open/" + utils.SignValue(openTrackingPrefix+logID, linkSigningSecret()))
+}
+
+// BuildClickTrackingURL returns a signed link that records a click and redirects to target.
+// The target is signed with the log ID so the link can't be turned into an open redirect.
+func BuildClickTrackingURL(logID, target string) string {
+	value := clickTrackingPrefix + logID + ":" + base64.RawURLEncoding.EncodeToString([]byte(target))
+	return BuildPublicURL("/public/track/click/" + utils.SignValue(value, linkSigningSecret()))
+}
+
+// ParseOpenTrackingToken returns the campaign log ID from a tracking pixel token
+func ParseOpenTrackingToken(token string) (string, bool) {
+	value, ok := utils.VerifySignedValue(token, linkSigningSecret())
+	if !ok || !strings.HasPrefix(value, openTrackingPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, openTrackingPrefix), true
+}
+
+// ParseClickTrackingToken returns the campaign log ID and destination from a click token
+func ParseClickTrackingToken(token string) (string, string, bool) {
+	value, ok := utils.VerifySignedValue(token, linkSigningSecret())
+	if !ok || !strings.HasPrefix(value, clickTrackingPrefix) {
+		return "", "", false
+	}
+	logID, encoded, found := strings.Cut(strings.TrimPrefix(value, clickTrackingPrefix), ":")
+	if !found {
+		return "", "", false
+	}
+	target, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+	return logID, string(target), true
+}
+
+var trackedLinkPattern = regexp.MustCompile(`(?i)(<a\b[^>]*?\bhref\s*=\s*)("[^"]*"|'[^']*')`)
+
+// AddTracking rewrites the http(s) links in a rendered campaign email to go through click
+// tracking and appends the open tracking pixel. Unsubscribe links are left alone so opting
+// out never depends on the tracker.
+func AddTracking(htmlBody, logID string) string {
+	unsubscribeBase := BuildPublicURL("/public/unsubscribe/")
+
+	tracked := trackedLinkPattern.ReplaceAllStringFunc(htmlBody, func(tag string) string {
+		parts := trackedLinkPattern.FindStringSubmatch(tag)
+		quote := parts[2][:1]
+		target := strings.TrimSpace(html.UnescapeString(parts[2][1 : len(parts[2])-1]))
+
+		lower := strings.ToLower(target)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+			return tag
+		}
+		if strings.HasPrefix(target, unsubscribeBase) {
+			return tag
+		}
+		return parts[1] + quote + html.EscapeString(BuildClickTrackingURL(logID, target)) + quote
+	})
+
+	pixel := `<img src="` + html.EscapeString(BuildOpenTrackingURL(logID)) + `" width="1" height="1" alt="" style="display:block;border:0;width:1px;height:1px;">`
+	if end := strings.LastIndex(strings.ToLower(tracked), "</body>"); end >= 0 {
+		return tracked[:end] + pixel + tracked[end:]
+	}
+	return tracked + pixel
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/utils"
+)
+
+// unsubscribePrefix scopes signed values so tokens minted for other links can't unsubscribe anyone
+const unsubscribePrefix = "unsubscribe:"
+
+// ErrNoLinkSigningSecret means unsubscribe and tracking links can't be signed
+var ErrNoLinkSigningSecret = errors.New("JWT_SECRET must be set to sign unsubscribe and tracking links")
+
+func linkSigningSecret() string {
+	return os.Getenv("JWT_SECRET")
+}
+
+// CheckLinkSigningSecret fails when there is no secret to sign public links with, so the
+// server refuses to start rather than hand out links anyone could forge
+func CheckLinkSigningSecret() error {
+	if linkSigningSecret() == "" {
+		return ErrNoLinkSigningSecret
+	}
+	return nil
+}
+
+// BuildUnsubscribeURL returns the signed unsubscribe link for a contact
+func BuildUnsubscribeURL(contactID string) string {
+	return BuildPublicURL("/public/unsubscribe/" + utils.SignValue(unsubscribePrefix+contactID, linkSigningSecret()))
+}
+
+// ParseUnsubscribeToken returns the contact ID from a signed unsubscribe token
+func ParseUnsubscribeToken(token string) (string, bool) {
+	value, ok := utils.VerifySignedValue(token, linkSigningSecret())
+	if !ok || !strings.HasPrefix(value, unsubscribePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, unsubscribePrefix), true
+}
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"strings"
 	"time"
 )
 
@@ -27,3 +31,24 @@ func IsTokenExpired(expiresAt *time.Time) bool {
 	}
 	return time.Now().After(*expiresAt)
 }
+
+// SignValue appends an HMAC-SHA256 signature to a value so it can travel in a public link
+func SignValue(value, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(value))
+	return value + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignedValue checks a token produced by SignValue and returns the original value.
+// Without a secret anyone could sign a token, so none verifies.
+func VerifySignedValue(token, secret string) (string, bool) {
+	dot := strings.LastIndexByte(token, '.')
+	if dot <= 0 || secret == "" {
+		return "", false
+	}
+	value := token[:dot]
+	if !hmac.Equal([]byte(SignValue(value, secret)), []byte(token)) {
+		return "", false
+	}
+	return value, true
+}
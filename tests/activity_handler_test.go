@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateContactActivity_InvalidType(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      user.ID.String(),
+		Email:          "john@test.com",
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	body, _ := json.Marshal(map[string]interface{}{"type": "fax", "body": "Sent a fax"})
+	req := httptest.NewRequest("POST", "/api/contacts/"+contact.ID+"/activities", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestGetContactTimeline_MergesEvents(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      user.ID.String(),
+		Email:          "john@test.com",
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	audience := models.Audience{
+		OrganizationID: org.ID.String(),
+		Name:           "Buyers",
+		CreatedBy:      user.ID.String(),
+	}
+	db.Create(&audience)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	// A call logged yesterday
+	body, _ := json.Marshal(map[string]interface{}{
+		"type":        "call",
+		"body":        "Discussed budget",
+		"outcome":     "interested",
+		"occurred_at": time.Now().Add(-24 * time.Hour),
+	})
+	req := httptest.NewRequest("POST", "/api/contacts/"+contact.ID+"/activities", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	// Adding the contact to an audience is recorded as a system event
+	body, _ = json.Marshal(map[string]interface{}{"contact_ids": []string{contact.ID}})
+	req = httptest.NewRequest("POST", "/api/audiences/"+audience.ID+"/contacts", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	req = httptest.NewRequest("GET", "/api/contacts/"+contact.ID+"/timeline", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var response struct {
+		Events []struct {
+			EventType string `json:"event_type"`
+			SubType   string `json:"sub_type"`
+			Title     string `json:"title"`
+		} `json:"events"`
+		Total int64 `json:"total"`
+	}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &response)
+
+	assert.Equal(t, int64(2), response.Total)
+	if assert.Len(t, response.Events, 2) {
+		// Newest first
+		assert.Equal(t, "audience_membership", response.Events[0].EventType)
+		assert.Equal(t, "Buyers", response.Events[0].Title)
+		assert.Equal(t, "activity", response.Events[1].EventType)
+		assert.Equal(t, "call", response.Events[1].SubType)
+	}
+}
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildICSCalendar_EscapesAndFolds(t *testing.T) {
+	start := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	feed := services.BuildICSCalendar("Agent", []services.ICSEvent{{
+		UID:         "abc@real-estate-crm",
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Summary:     "Showing; 3BHK, Baner",
+		Description: strings.Repeat("Long description ", 10) + "\nSecond line",
+	}})
+
+	assert.Contains(t, feed, "DTSTART:20250314T100000Z\r\n")
+	assert.Contains(t, feed, `SUMMARY:Showing\; 3BHK\, Baner`)
+	assert.Contains(t, feed, `\nSecond line`)
+
+	// Every physical line stays within 75 octets
+	for _, line := range strings.Split(feed, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+}
+
+func TestCreateAppointment_ConflictReturns409(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      user.ID.String(),
+		FirstName:      "John",
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	db.Create(&models.Appointment{
+		OrganizationID: org.ID.String(),
+		AgentID:        user.ID.String(),
+		ContactID:      contact.ID,
+		StartAt:        start,
+		EndAt:          start.Add(time.Hour),
+		Status:         "scheduled",
+		CreatedBy:      user.ID.String(),
+	})
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	// Overlaps the second half of the existing appointment
+	body, _ := json.Marshal(map[string]interface{}{
+		"contact_id": contact.ID,
+		"start_at":   start.Add(30 * time.Minute),
+		"end_at":     start.Add(90 * time.Minute),
+	})
+	req := httptest.NewRequest("POST", "/api/appointments", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	// Back-to-back is fine
+	body, _ = json.Marshal(map[string]interface{}{
+		"contact_id": contact.ID,
+		"start_at":   start.Add(time.Hour),
+		"end_at":     start.Add(2 * time.Hour),
+	})
+	req = httptest.NewRequest("POST", "/api/appointments", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+}
+
+func TestAppointmentBook_ConcurrentBookingsTakeSlotOnce(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	service := services.NewAppointmentService()
+	var wg sync.WaitGroup
+	var booked atomic.Int32
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			appointment := models.Appointment{
+				OrganizationID: org.ID.String(),
+				AgentID:        user.ID.String(),
+				ContactID:      uuid.NewString(),
+				StartAt:        start,
+				EndAt:          start.Add(time.Hour),
+				Status:         "scheduled",
+				CreatedBy:      user.ID.String(),
+			}
+			if _, err := service.Book(&appointment); err == nil {
+				booked.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), booked.Load())
+	var count int64
+	db.Model(&models.Appointment{}).Where("agent_id = ?", user.ID.String()).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestGetCalendarFeed_ServesAgentAppointments(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      user.ID.String(),
+		FirstName:      "John",
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	db.Create(&models.Appointment{
+		OrganizationID: org.ID.String(),
+		AgentID:        user.ID.String(),
+		ContactID:      contact.ID,
+		Title:          "Showing at Baner",
+		StartAt:        start,
+		EndAt:          start.Add(time.Hour),
+		Status:         "confirmed",
+		CreatedBy:      user.ID.String(),
+	})
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	req := httptest.NewRequest("GET", "/api/calendar/feed-url", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var response struct {
+		URL string `json:"url"`
+	}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &response)
+
+	path := response.URL[strings.Index(response.URL, "/public/"):]
+
+	// The feed itself needs no Authorization header
+	req = httptest.NewRequest("GET", path, nil)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/calendar")
+
+	bodyBytes, _ = io.ReadAll(resp.Body)
+	assert.Contains(t, string(bodyBytes), "SUMMARY:Showing at Baner")
+	assert.Contains(t, string(bodyBytes), "STATUS:CONFIRMED")
+
+	// Unknown tokens are rejected
+	req = httptest.NewRequest("GET", "/public/calendar/not-a-token.ics", nil)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
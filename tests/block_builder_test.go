@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleBlocks = `{
+	"settings": {"background_color": "#eeeeee"},
+	"blocks": [
+		{"type": "heading", "text": "New launch in {{contact.preferred_location | default \"Pune\"}}"},
+		{"type": "text", "text": "Hi {{contact.first_name}},\n\nPrices from <b>₹85L</b> & up."},
+		{"type": "image", "image_url": "https://cdn.example.com/hero.jpg", "alt": "Tower view"},
+		{"type": "columns", "columns": [
+			[{"type": "property_card", "property": {"title": "3 BHK, Baner", "price": "₹1.2 Cr", "bedrooms": "3", "area": "1,450", "url": "https://example.com/p/1"}}],
+			[{"type": "button", "text": "Book a visit", "url": "https://example.com/visit", "color": "#ff6600"}]
+		]},
+		{"type": "divider"},
+		{"type": "text", "text": "Not interested? [Unsubscribe]({{unsubscribe_url}})"}
+	]
+}`
+
+func TestApplyBlocks_CompilesHTMLAndText(t *testing.T) {
+	template := &models.EmailTemplate{Subject: "New launch"}
+	assert.NoError(t, services.ApplyBlocks(template, []byte(sampleBlocks)))
+
+	assert.Equal(t, services.EditorTypeBlocks, template.EditorType)
+	assert.NotEmpty(t, template.BuilderBlocks)
+	assert.Contains(t, template.HtmlBody, `role="presentation"`)
+	assert.Contains(t, template.HtmlBody, `class="email-column"`)
+	assert.Contains(t, template.HtmlBody, "&lt;b&gt;₹85L&lt;/b&gt; &amp; up.")
+	assert.Contains(t, template.HtmlBody, `<a href="{{unsubscribe_url | url}}"`)
+	assert.Contains(t, template.PlainTextBody, "Not interested? Unsubscribe ({{unsubscribe_url}})")
+	assert.Empty(t, services.LintEmailTemplate(template, nil))
+
+	// Compiled output passes the sanitizer untouched
+	sanitized, report := services.SanitizeEmailHTML(template.HtmlBody)
+	assert.Equal(t, template.HtmlBody, sanitized)
+	assert.True(t, report.Empty())
+
+	brand := &models.OrganizationSettings{BrandPrimaryColor: "#123456"}
+	rendered, err := services.RenderEmailTemplate(template, nil, services.BuildTemplateData(services.SampleContact(), nil, nil, brand))
+	assert.NoError(t, err)
+	assert.Contains(t, rendered.HtmlBody, "New launch in Baner, Pune")
+	assert.Contains(t, rendered.HtmlBody, "background-color:#ff6600")
+	assert.Contains(t, rendered.HtmlBody, "color:#123456")
+	assert.True(t, strings.HasPrefix(rendered.TextBody, "New launch in Baner, Pune\n\nHi Priya,\n\nPrices from <b>₹85L</b> & up."))
+	assert.Contains(t, rendered.TextBody, "3 BHK, Baner\n₹1.2 Cr\n3 bed · 1,450 sq ft\nView property: https://example.com/p/1")
+	assert.Contains(t, rendered.TextBody, "Book a visit: https://example.com/visit")
+}
+
+func TestParseBlockDocument_Rejects(t *testing.T) {
+	invalid := []string{
+		`{"blocks": []}`,
+		`{"blocks": [{"type": "marquee", "text": "x"}]}`,
+		`{"blocks": [{"type": "button", "text": "Go", "url": "javascript:alert(1)"}]}`,
+		`{"blocks": [{"type": "heading", "text": "x", "color": "red;background:url(x)"}]}`,
+		`{"blocks": [{"type": "columns", "columns": [[{"type": "columns", "columns": [[], []]}], []]}]}`,
+		`{"blocks": [{"type": "text", "text": "x", "onclick": "y"}]}`,
+		`{"blocks": [{"type": "button", "text": "Go", "url": "{{contact.notes}}javascript:alert(1)"}]}`,
+	}
+	for _, doc := range invalid {
+		_, err := services.ParseBlockDocument([]byte(doc))
+		assert.Error(t, err, doc)
+	}
+}
+
+func TestApplyBlocks_ChecksTemplateLinksWhenRendered(t *testing.T) {
+	template := &models.EmailTemplate{Subject: "Hi"}
+	assert.NoError(t, services.ApplyBlocks(template, []byte(`{"blocks": [{"type": "button", "text": "Open", "url": "{{contact.notes}}"}]}`)))
+	assert.Contains(t, template.HtmlBody, `href="{{contact.notes | url}}"`)
+
+	contact := services.SampleContact()
+	contact.Notes = "javascript:alert(1)"
+	rendered, err := services.RenderEmailTemplate(template, nil, services.BuildTemplateData(contact, nil, nil, nil))
+	assert.NoError(t, err)
+	assert.NotContains(t, rendered.HtmlBody, "javascript")
+	assert.Contains(t, rendered.HtmlBody, `href=""`)
+
+	contact.Notes = "https://example.com/brochure"
+	rendered, err = services.RenderEmailTemplate(template, nil, services.BuildTemplateData(contact, nil, nil, nil))
+	assert.NoError(t, err)
+	assert.Contains(t, rendered.HtmlBody, `href="https://example.com/brochure"`)
+
+	// A text link that is not a single tag or a safe URL stays plain text
+	assert.NoError(t, services.ApplyBlocks(template, []byte(`{"blocks": [{"type": "text", "text": "[Go]({{contact.notes}}javascript:alert(1))"}]}`)))
+	assert.NotContains(t, template.HtmlBody, "<a ")
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitRecipients(t *testing.T) {
+	count := func(assigned []int, variants int) []int {
+		counts := make([]int, variants)
+		for _, v := range assigned {
+			counts[v]++
+		}
+		return counts
+	}
+
+	assert.Equal(t, []int{5, 5}, count(services.SplitRecipients(10, []int{50, 50}), 2))
+	assert.Equal(t, []int{4, 3, 3}, count(services.SplitRecipients(10, []int{34, 33, 33}), 3))
+	assert.Equal(t, []int{1, 2}, count(services.SplitRecipients(3, []int{30, 70}), 2))
+	assert.Empty(t, services.SplitRecipients(0, []int{50, 50}))
+
+	assert.Equal(t, 10, services.TestSampleSize(100, 10, 2))
+	assert.Equal(t, 2, services.TestSampleSize(5, 10, 2))
+	assert.Equal(t, 1, services.TestSampleSize(1, 10, 2))
+}
+
+func TestValidateCampaignVariants(t *testing.T) {
+	campaign := &models.Campaign{ScheduleType: "once", VariantMode: services.VariantModeSplit}
+	variants := []models.CampaignVariant{{TemplateID: "t1"}, {TemplateID: "t2"}, {TemplateID: "t3"}}
+	assert.NoError(t, services.ValidateCampaignVariants(campaign, variants))
+	assert.Equal(t, "C", variants[2].Label)
+	assert.Equal(t, []int{34, 33, 33}, []int{variants[0].SplitPercent, variants[1].SplitPercent, variants[2].SplitPercent})
+
+	assert.Error(t, services.ValidateCampaignVariants(campaign, []models.CampaignVariant{{TemplateID: "t1"}}))
+	assert.Error(t, services.ValidateCampaignVariants(campaign, []models.CampaignVariant{
+		{TemplateID: "t1", SplitPercent: 60}, {TemplateID: "t2", SplitPercent: 30},
+	}))
+
+	recurring := &models.Campaign{ScheduleType: "recurring", VariantMode: services.VariantModeSplit}
+	assert.Error(t, services.ValidateCampaignVariants(recurring, []models.CampaignVariant{{TemplateID: "t1"}, {TemplateID: "t2"}}))
+
+	testWinner := &models.Campaign{ScheduleType: "once", VariantMode: services.VariantModeTestWinner, TestPercent: 20, TestDurationHours: 4}
+	assert.ErrorContains(t, services.ValidateCampaignVariants(testWinner, []models.CampaignVariant{{TemplateID: "t1"}, {TemplateID: "t2"}}), "winner_metric")
+	testWinner.WinnerMetric = "click"
+	assert.NoError(t, services.ValidateCampaignVariants(testWinner, []models.CampaignVariant{{TemplateID: "t1"}, {TemplateID: "t2"}}))
+}
+
+func TestPickWinner(t *testing.T) {
+	variants := []models.CampaignVariant{{ID: "a", Label: "A"}, {ID: "b", Label: "B"}}
+	stats := map[string]repository.CampaignVariantCounts{
+		"a": {Sent: 100, Opened: 30, Clicked: 10},
+		"b": {Sent: 50, Opened: 20, Clicked: 4},
+	}
+
+	assert.Equal(t, "b", services.PickWinner(variants, stats, "open").ID)
+	assert.Equal(t, "a", services.PickWinner(variants, stats, "click").ID)
+	// Without results the first variant wins
+	assert.Equal(t, "a", services.PickWinner(variants, nil, "open").ID)
+}
+
+func TestAddTracking(t *testing.T) {
+	unsubscribe := services.BuildUnsubscribeURL("contact-1")
+	body := `<html><body><a href="https://example.com/listing?id=1&amp;ref=mail">Listing</a>` +
+		`<a href="mailto:agent@example.com">Mail</a><a href="` + unsubscribe + `">Unsubscribe</a></body></html>`
+
+	tracked := services.AddTracking(body, "log-1")
+
+	assert.Contains(t, tracked, "/public/track/open/")
+	assert.True(t, strings.HasSuffix(tracked, `"></body></html>`))
+	assert.Contains(t, tracked, `href="mailto:agent@example.com"`)
+	assert.Contains(t, tracked, `href="`+unsubscribe+`"`)
+	assert.NotContains(t, tracked, "https://example.com/listing")
+
+	token := regexp.MustCompile(`/public/track/click/([^"]+)"`).FindStringSubmatch(tracked)[1]
+	logID, target, ok := services.ParseClickTrackingToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, "log-1", logID)
+	assert.Equal(t, "https://example.com/listing?id=1&ref=mail", target)
+
+	// Tokens for other links don't verify as tracking tokens
+	_, _, ok = services.ParseClickTrackingToken(strings.TrimPrefix(unsubscribe, services.BuildPublicURL("/public/unsubscribe/")))
+	assert.False(t, ok)
+}
+
+func TestTrackCampaignClick(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	campaignLog := models.CampaignLog{
+		CampaignID:     uuid.New().String(),
+		ContactID:      uuid.New().String(),
+		RecipientEmail: "ravi@example.com",
+		Status:         "sent",
+	}
+	db.Create(&campaignLog)
+
+	tracked := services.AddTracking(`<a href="https://example.com/listing">Listing</a>`, campaignLog.ID)
+	clickPath := regexp.MustCompile(`href="[^"]*(/public/track/click/[^"]+)"`).FindStringSubmatch(tracked)[1]
+
+	resp, err := app.Test(httptest.NewRequest("GET", clickPath, nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 302, resp.StatusCode)
+	assert.Equal(t, "https://example.com/listing", resp.Header.Get("Location"))
+
+	db.First(&campaignLog, "id = ?", campaignLog.ID)
+	assert.NotNil(t, campaignLog.ClickedAt)
+	assert.NotNil(t, campaignLog.OpenedAt)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/public/track/click/forged", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestProcessCampaignRun_TestWinnerCapsRemainderWhenWinnerIsSent(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+	db.Create(&models.OrganizationSettings{OrganizationID: org.ID.String(), CampaignFrequencyCap: 1})
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Test Template",
+		Subject:        "Test Subject",
+		HtmlBody:       "<p>Test Body</p>",
+		CreatedBy:      uuid.New().String(),
+	}
+	db.Create(&template)
+
+	audience := models.Audience{OrganizationID: org.ID.String(), Name: "Buyers", CreatedBy: uuid.New().String()}
+	db.Create(&audience)
+
+	// Every contact was emailed by another campaign this week
+	for i := 0; i < 10; i++ {
+		contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), Email: uuid.New().String() + "@test.com", IsActive: true}
+		db.Create(&contact)
+		db.Create(&models.AudienceContact{AudienceID: audience.ID, ContactID: contact.ID})
+		db.Create(&models.CampaignLog{CampaignID: uuid.New().String(), ContactID: contact.ID, Status: "sent"})
+	}
+
+	campaign := models.Campaign{
+		OrganizationID:       org.ID.String(),
+		Name:                 "Test Campaign",
+		TemplateID:           template.ID,
+		FollowLatestTemplate: true,
+		AudienceIDs:          []string{audience.ID},
+		ScheduleType:         "once",
+		VariantMode:          services.VariantModeTestWinner,
+		TestPercent:          20,
+		TestDurationHours:    4,
+		WinnerMetric:         "open",
+		Status:               "scheduled",
+		CreatedBy:            uuid.New().String(),
+	}
+	db.Create(&campaign)
+	for _, label := range []string{"A", "B"} {
+		db.Create(&models.CampaignVariant{CampaignID: campaign.ID, OrganizationID: org.ID.String(), Label: label, TemplateID: template.ID, SplitPercent: 50})
+	}
+
+	service := services.NewBackgroundJobService()
+	job := models.BackgroundJobLog{JobType: "campaign_run", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+	db.Create(&job)
+	service.ProcessCampaignRun(job.ID, campaign.ID)
+
+	// Only the test sample is checked against the cap
+	var skipped int64
+	db.Model(&models.CampaignLog{}).Where("campaign_id = ? AND skip_reason = ?", campaign.ID, "frequency_cap").Count(&skipped)
+	assert.Equal(t, int64(2), skipped)
+
+	// By the time the winner goes out the other campaign's emails are outside the cap window
+	db.Where("campaign_id <> ?", campaign.ID).Delete(&models.CampaignLog{})
+
+	winnerJob := models.BackgroundJobLog{JobType: "campaign_winner", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+	db.Create(&winnerJob)
+	service.ProcessCampaignWinner(winnerJob.ID, campaign.ID)
+
+	var remainder int64
+	db.Model(&models.CampaignLog{}).Where("campaign_id = ? AND status <> ?", campaign.ID, "skipped").Count(&remainder)
+	assert.Equal(t, int64(8), remainder)
+}
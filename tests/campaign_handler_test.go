@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"io"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/datatypes"
@@ -310,3 +313,280 @@ func TestGetCampaignLogs_Success(t *testing.T) {
 	logs := response["logs"].([]interface{})
 	assert.GreaterOrEqual(t, len(logs), 1)
 }
+
+func TestCampaignApprovalWorkflow(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+	db.Create(&models.OrganizationSettings{OrganizationID: org.ID.String(), RequireCampaignApproval: true})
+
+	admin := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Admin",
+		Email:          "admin@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&admin)
+
+	agent := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent",
+		Email:          "agent@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agent)
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Test Template",
+		Subject:        "Test Subject",
+		HtmlBody:       "<p>Test Body</p>",
+		CreatedBy:      agent.ID.String(),
+	}
+	db.Create(&template)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      agent.ID.String(),
+		FirstName:      "John",
+		Email:          "john@test.com",
+	}
+	db.Create(&contact)
+
+	agentToken := getAuthToken(t, agent.ID.String(), agent.Role, org.ID.String())
+	adminToken := getAuthToken(t, admin.ID.String(), admin.Role, org.ID.String())
+	send := func(path, token string, payload map[string]interface{}) int {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 201, send("/api/campaigns", agentToken, map[string]interface{}{
+		"name":          "Whole database blast",
+		"template_id":   template.ID,
+		"contact_id":    contact.ID,
+		"schedule_type": "once",
+		"scheduled_at":  time.Now().Add(time.Hour).Format(time.RFC3339),
+	}))
+
+	var campaign models.Campaign
+	db.Where("organization_id = ?", org.ID.String()).First(&campaign)
+	assert.Equal(t, "draft", campaign.Status)
+
+	assert.Equal(t, 200, send("/api/campaigns/"+campaign.ID+"/schedule", agentToken, nil))
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, "pending_approval", campaign.Status)
+
+	var notifications []models.Notification
+	db.Where("user_id = ? AND notification_type = ?", admin.ID.String(), "campaign_approval_requested").Find(&notifications)
+	assert.Len(t, notifications, 1)
+
+	// Rejecting needs a comment
+	assert.Equal(t, 400, send("/api/campaigns/"+campaign.ID+"/reject", adminToken, map[string]interface{}{}))
+	assert.Equal(t, 200, send("/api/campaigns/"+campaign.ID+"/reject", adminToken, map[string]interface{}{"comment": "Use the newsletter audience"}))
+
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, "rejected", campaign.Status)
+	assert.Equal(t, "Use the newsletter audience", campaign.ReviewComment)
+	assert.Equal(t, admin.ID.String(), *campaign.ReviewedBy)
+
+	// Editing returns it to draft, scheduling resubmits it, and approval schedules it
+	body, _ := json.Marshal(map[string]interface{}{"name": "Newsletter"})
+	req := httptest.NewRequest("PUT", "/api/campaigns/"+campaign.ID, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+agentToken)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, "draft", campaign.Status)
+
+	assert.Equal(t, 200, send("/api/campaigns/"+campaign.ID+"/schedule", agentToken, nil))
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, "pending_approval", campaign.Status)
+
+	assert.Equal(t, 200, send("/api/campaigns/"+campaign.ID+"/approve", adminToken, nil))
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, "scheduled", campaign.Status)
+	assert.Equal(t, "approved", campaign.ReviewDecision)
+	assert.NotNil(t, campaign.ReviewedAt)
+
+	assert.Equal(t, 400, send("/api/campaigns/"+campaign.ID+"/approve", adminToken, nil))
+}
+
+func TestCampaignDraftEditScheduleAndClone(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	wrongTemplate := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Wrong Template",
+		Subject:        "Old listing",
+		HtmlBody:       "<p>Old</p>",
+		CreatedBy:      user.ID.String(),
+	}
+	db.Create(&wrongTemplate)
+	rightTemplate := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Right Template",
+		Subject:        "New listing",
+		HtmlBody:       "<p>New</p>",
+		CreatedBy:      user.ID.String(),
+	}
+	db.Create(&rightTemplate)
+
+	audience := models.Audience{
+		OrganizationID: org.ID.String(),
+		Name:           "Buyers",
+		CreatedBy:      user.ID.String(),
+	}
+	db.Create(&audience)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      user.ID.String(),
+		FirstName:      "John",
+		Email:          "john@test.com",
+	}
+	db.Create(&contact)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+	send := func(method, path string, payload map[string]interface{}) int {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 201, send("POST", "/api/campaigns", map[string]interface{}{
+		"name":          "Open house invite",
+		"template_id":   wrongTemplate.ID,
+		"contact_id":    contact.ID,
+		"schedule_type": "once",
+	}))
+
+	var campaign models.Campaign
+	db.Where("organization_id = ?", org.ID.String()).First(&campaign)
+	assert.Equal(t, "draft", campaign.Status)
+
+	// A one-time campaign needs a send time before it can be scheduled
+	assert.Equal(t, 400, send("POST", "/api/campaigns/"+campaign.ID+"/schedule", nil))
+
+	// Template and recipients can be fixed in place, validated like create
+	assert.Equal(t, 400, send("PUT", "/api/campaigns/"+campaign.ID, map[string]interface{}{
+		"audience_ids": []string{audience.ID},
+	}))
+	assert.Equal(t, 200, send("PUT", "/api/campaigns/"+campaign.ID, map[string]interface{}{
+		"template_id":  rightTemplate.ID,
+		"audience_ids": []string{audience.ID},
+		"contact_id":   nil,
+	}))
+
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, rightTemplate.ID, campaign.TemplateID)
+	assert.Equal(t, []string{audience.ID}, []string(campaign.AudienceIDs))
+	assert.Nil(t, campaign.ContactID)
+	assert.NotNil(t, campaign.TemplateVersion)
+	assert.Equal(t, "Open house invite", campaign.Name)
+
+	scheduledAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	assert.Equal(t, 200, send("POST", "/api/campaigns/"+campaign.ID+"/schedule", map[string]interface{}{
+		"scheduled_at": scheduledAt.Format(time.RFC3339),
+	}))
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, "scheduled", campaign.Status)
+	assert.True(t, scheduledAt.Equal(campaign.ScheduledAt))
+
+	assert.Equal(t, 400, send("POST", "/api/campaigns/"+campaign.ID+"/schedule", nil))
+
+	assert.Equal(t, 201, send("POST", "/api/campaigns/"+campaign.ID+"/clone", nil))
+	var clone models.Campaign
+	db.Where("name = ?", "Copy of Open house invite").First(&clone)
+	assert.Equal(t, "draft", clone.Status)
+	assert.Equal(t, rightTemplate.ID, clone.TemplateID)
+	assert.Equal(t, campaign.TemplateVersion, clone.TemplateVersion)
+
+	// Once a campaign has run, its sends and logs describe what it holds, so it can't change
+	db.Model(&campaign).Update("last_run_at", time.Now())
+	assert.Equal(t, 400, send("PUT", "/api/campaigns/"+campaign.ID, map[string]interface{}{
+		"name": "Renamed after sending",
+	}))
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, "Open house invite", campaign.Name)
+}
+
+func TestCampaignClaimForRun_OnlyOneCallerWins(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	campaign := models.Campaign{
+		OrganizationID: uuid.NewString(),
+		Name:           "Launch",
+		TemplateID:     uuid.NewString(),
+		ScheduleType:   "once",
+		ScheduledAt:    time.Now(),
+		Status:         "draft",
+		CreatedBy:      uuid.NewString(),
+		AudienceIDs:    datatypes.JSONSlice[string]{},
+	}
+	db.Create(&campaign)
+
+	repo := &repository.CampaignRepository{}
+	var wg sync.WaitGroup
+	var claims atomic.Int32
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if claimed, err := repo.ClaimForRun(campaign.ID, "draft", "scheduled"); err == nil && claimed {
+				claims.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), claims.Load())
+	var stored models.Campaign
+	db.First(&stored, "id = ?", campaign.ID)
+	assert.Equal(t, "running", stored.Status)
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestPreviewCampaignRecipients(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	buyers := models.Audience{OrganizationID: org.ID.String(), Name: "Buyers", CreatedBy: uuid.New().String()}
+	db.Create(&buyers)
+	sellers := models.Audience{OrganizationID: org.ID.String(), Name: "Sellers", CreatedBy: uuid.New().String()}
+	db.Create(&sellers)
+	clients := models.Audience{OrganizationID: org.ID.String(), Name: "Current clients", CreatedBy: uuid.New().String()}
+	db.Create(&clients)
+
+	var contacts []models.Contact
+	for _, email := range []string{"keep@test.com", "", "unsubscribed@test.com", "client@test.com", "inactive@test.com"} {
+		contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), Email: email}
+		db.Create(&contact)
+		db.Create(&models.AudienceContact{AudienceID: buyers.ID, ContactID: contact.ID})
+		contacts = append(contacts, contact)
+	}
+	db.Create(&models.AudienceContact{AudienceID: sellers.ID, ContactID: contacts[0].ID})
+	db.Model(&contacts[2]).Update("unsubscribed_at", time.Now())
+	db.Create(&models.AudienceContact{AudienceID: clients.ID, ContactID: contacts[3].ID})
+	db.Model(&contacts[4]).Update("is_active", false)
+
+	campaign := models.Campaign{
+		OrganizationID:      org.ID.String(),
+		Name:                "Test Campaign",
+		TemplateID:          uuid.New().String(),
+		ScheduleType:        "once",
+		Status:              "draft",
+		CreatedBy:           uuid.New().String(),
+		AudienceIDs:         datatypes.JSONSlice[string]{buyers.ID, sellers.ID},
+		ExcludedAudienceIDs: datatypes.JSONSlice[string]{clients.ID},
+	}
+	db.Create(&campaign)
+
+	counts, recipients, err := services.NewBackgroundJobService().PreviewCampaignRecipients(&campaign)
+	assert.NoError(t, err)
+	assert.Equal(t, services.CampaignRecipientCounts{
+		Total:      5,
+		Duplicate:  1,
+		Excluded:   2,
+		NoEmail:    1,
+		Suppressed: 1,
+		Recipients: 1,
+	}, *counts)
+	assert.Len(t, recipients, 1)
+	assert.Equal(t, contacts[0].ID, recipients[0].ID)
+
+	// A contact at the frequency cap is counted on its own, not as suppressed
+	db.Create(&models.OrganizationSettings{OrganizationID: org.ID.String(), CampaignFrequencyCap: 1})
+	db.Create(&models.CampaignLog{CampaignID: uuid.New().String(), ContactID: contacts[0].ID, RecipientEmail: contacts[0].Email, Status: "sent"})
+
+	counts, recipients, err = services.NewBackgroundJobService().PreviewCampaignRecipients(&campaign)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), counts.Suppressed)
+	assert.Equal(t, int64(1), counts.FrequencyCapped)
+	assert.Equal(t, int64(0), counts.Recipients)
+	assert.Empty(t, recipients)
+}
+
+func TestProcessCampaignDryRun(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Test Template",
+		Subject:        "Hello {{.contact.first_name}}",
+		HtmlBody:       "<p>Test Body</p>",
+		CreatedBy:      uuid.New().String(),
+	}
+	db.Create(&template)
+
+	audience := models.Audience{OrganizationID: org.ID.String(), Name: "Buyers", CreatedBy: uuid.New().String()}
+	db.Create(&audience)
+	for _, contact := range []models.Contact{
+		{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), FirstName: "Asha", Email: "asha@test.com"},
+		{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), FirstName: "Nomail"},
+	} {
+		db.Create(&contact)
+		db.Create(&models.AudienceContact{AudienceID: audience.ID, ContactID: contact.ID})
+	}
+
+	campaign := models.Campaign{
+		OrganizationID:       org.ID.String(),
+		Name:                 "Test Campaign",
+		TemplateID:           template.ID,
+		FollowLatestTemplate: true,
+		AudienceIDs:          datatypes.JSONSlice[string]{audience.ID},
+		ScheduleType:         "once",
+		Status:               "draft",
+		CreatedBy:            uuid.New().String(),
+	}
+	db.Create(&campaign)
+
+	preview := models.CampaignPreview{
+		CampaignID:     campaign.ID,
+		OrganizationID: org.ID.String(),
+		Status:         "running",
+		Messages:       datatypes.JSONSlice[models.CampaignPreviewMessage]{},
+	}
+	db.Create(&preview)
+	job := models.BackgroundJobLog{JobType: "campaign_dry_run", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+	db.Create(&job)
+
+	services.NewBackgroundJobService().ProcessCampaignDryRun(job.ID, preview.ID)
+
+	db.First(&preview, "id = ?", preview.ID)
+	assert.Equal(t, "completed", preview.Status)
+	assert.Equal(t, 1, preview.Recipients)
+	assert.Equal(t, 1, preview.Rendered)
+	assert.Equal(t, 1, preview.Skipped)
+	assert.Len(t, preview.Messages, 2)
+	for _, message := range preview.Messages {
+		if message.Status == "rendered" {
+			assert.Equal(t, "Hello Asha", message.Subject)
+		} else {
+			assert.Equal(t, "no_email", message.SkipReason)
+		}
+	}
+
+	// Nothing was logged and the campaign is still a draft
+	var logCount int64
+	db.Model(&models.CampaignLog{}).Where("campaign_id = ?", campaign.ID).Count(&logCount)
+	assert.Equal(t, int64(0), logCount)
+	db.First(&campaign, "id = ?", campaign.ID)
+	assert.Equal(t, "draft", campaign.Status)
+	assert.Nil(t, campaign.LastRunAt)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
 	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/datatypes"
@@ -326,3 +327,106 @@ func TestCampaignRepository_UpdateLastRunAt(t *testing.T) {
 	db.First(&updated, "id = ?", campaign.ID)
 	assert.NotNil(t, updated.LastRunAt)
 }
+
+func TestCampaignRepository_GetRecipientContacts_Exclusions(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	repo := &repository.CampaignRepository{}
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	buyers := models.Audience{OrganizationID: org.ID.String(), Name: "Buyers", CreatedBy: uuid.New().String()}
+	db.Create(&buyers)
+	clients := models.Audience{OrganizationID: org.ID.String(), Name: "Current clients", CreatedBy: uuid.New().String()}
+	db.Create(&clients)
+
+	var contacts []models.Contact
+	for _, email := range []string{"keep@test.com", "client@test.com", "inactive@test.com"} {
+		contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), Email: email}
+		db.Create(&contact)
+		db.Create(&models.AudienceContact{AudienceID: buyers.ID, ContactID: contact.ID})
+		contacts = append(contacts, contact)
+	}
+	db.Create(&models.AudienceContact{AudienceID: clients.ID, ContactID: contacts[1].ID})
+	db.Model(&contacts[2]).Update("is_active", false)
+
+	campaign := models.Campaign{
+		OrganizationID:      org.ID.String(),
+		Name:                "Test Campaign",
+		TemplateID:          uuid.New().String(),
+		ScheduleType:        "once",
+		Status:              "scheduled",
+		CreatedBy:           uuid.New().String(),
+		AudienceIDs:         datatypes.JSONSlice[string]{buyers.ID},
+		ExcludedAudienceIDs: datatypes.JSONSlice[string]{clients.ID},
+	}
+	db.Create(&campaign)
+
+	contactIDs, err := repo.GetRecipientContacts(&campaign)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{contacts[0].ID}, contactIDs)
+
+	// An inactive single contact isn't sent to either
+	campaign.AudienceIDs = datatypes.JSONSlice[string]{}
+	campaign.ContactID = &contacts[2].ID
+	contactIDs, err = repo.GetRecipientContacts(&campaign)
+	assert.NoError(t, err)
+	assert.Empty(t, contactIDs)
+}
+
+func TestProcessCampaignRun_FrequencyCap(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+	db.Create(&models.OrganizationSettings{OrganizationID: org.ID.String(), CampaignFrequencyCap: 1})
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Test Template",
+		Subject:        "Test Subject",
+		HtmlBody:       "<p>Test Body</p>",
+		CreatedBy:      uuid.New().String(),
+	}
+	db.Create(&template)
+
+	contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), Email: "busy@test.com"}
+	db.Create(&contact)
+
+	// Another campaign already emailed the contact this week
+	db.Create(&models.CampaignLog{CampaignID: uuid.New().String(), ContactID: contact.ID, Status: "sent"})
+
+	campaign := models.Campaign{
+		OrganizationID:       org.ID.String(),
+		Name:                 "Test Campaign",
+		TemplateID:           template.ID,
+		FollowLatestTemplate: true,
+		ContactID:            &contact.ID,
+		ScheduleType:         "once",
+		Status:               "scheduled",
+		CreatedBy:            uuid.New().String(),
+	}
+	db.Create(&campaign)
+
+	job := models.BackgroundJobLog{JobType: "campaign_run", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+	db.Create(&job)
+
+	services.NewBackgroundJobService().ProcessCampaignRun(job.ID, campaign.ID)
+
+	var logs []models.CampaignLog
+	db.Where("campaign_id = ?", campaign.ID).Find(&logs)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "skipped", logs[0].Status)
+	assert.Equal(t, "frequency_cap", logs[0].SkipReason)
+}
@@ -351,3 +351,173 @@ func TestDeleteContact_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 200, resp.StatusCode)
 }
+
+func TestGetContacts_MineFilter(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and users
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	colleague := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Colleague",
+		Email:          "colleague@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&colleague)
+
+	userID := user.ID.String()
+	colleagueID := colleague.ID.String()
+	db.Create(&models.Contact{OrganizationID: org.ID.String(), CreatedBy: userID, AssignedTo: &userID, Email: "mine@test.com"})
+	db.Create(&models.Contact{OrganizationID: org.ID.String(), CreatedBy: userID, AssignedTo: &colleagueID, Email: "theirs@test.com"})
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	req := httptest.NewRequest("GET", "/api/contacts?mine=true", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var response map[string]interface{}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &response)
+
+	contacts := response["contacts"].([]interface{})
+	assert.Len(t, contacts, 1)
+
+	// An assignee that isn't an agent ID is rejected rather than sent to the database
+	req = httptest.NewRequest("GET", "/api/contacts?assigned_to=someone", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestBulkAssignContacts_AgentCannotTakeColleaguesContacts(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and users
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	colleague := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Colleague",
+		Email:          "colleague@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&colleague)
+
+	colleagueID := colleague.ID.String()
+	contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: colleagueID, AssignedTo: &colleagueID, Email: "lead@test.com"}
+	db.Create(&contact)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"contact_ids": []string{contact.ID},
+		"agent_id":    user.ID.String(),
+	})
+	req := httptest.NewRequest("POST", "/api/contacts/bulk-assign", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+func TestDeactivateAgent_ReassignsContacts(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and users
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	admin := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Admin",
+		Email:          "admin@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&admin)
+
+	leaving := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Leaving Agent",
+		Email:          "leaving@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&leaving)
+
+	leavingID := leaving.ID.String()
+	contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: leavingID, AssignedTo: &leavingID, Email: "lead@test.com"}
+	db.Create(&contact)
+
+	token := getAuthToken(t, admin.ID.String(), admin.Role, org.ID.String())
+
+	req := httptest.NewRequest("DELETE", "/api/agents/"+leavingID+"?reassign_to="+admin.ID.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var updated models.Contact
+	db.First(&updated, "id = ?", contact.ID)
+	assert.NotNil(t, updated.AssignedTo)
+	assert.Equal(t, admin.ID.String(), *updated.AssignedTo)
+}
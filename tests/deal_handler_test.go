@@ -0,0 +1,229 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestCalculateGrossCommission_Tiered(t *testing.T) {
+	firstCap := 1000000.0
+	rule := &models.CommissionRule{
+		Type: "tiered",
+		Tiers: datatypes.JSONSlice[models.CommissionTier]{
+			{UpTo: &firstCap, Rate: 2},
+			{UpTo: nil, Rate: 1},
+		},
+	}
+
+	// 2% of the first 10 lakh, 1% of the remaining 5 lakh
+	assert.Equal(t, 25000.0, services.CalculateGrossCommission(rule, 1500000))
+	assert.Equal(t, 10000.0, services.CalculateGrossCommission(rule, 500000))
+}
+
+func TestSplitCommission_MissingSideGoesToBrokerage(t *testing.T) {
+	rule := &models.CommissionRule{
+		Type:               "percentage",
+		Rate:               3,
+		ListingSidePercent: 50,
+		AgentSharePercent:  70,
+	}
+	buyerAgent := "buyer-agent"
+
+	gross := services.CalculateGrossCommission(rule, 1000000)
+	assert.Equal(t, 30000.0, gross)
+
+	payouts := services.SplitCommission(rule, gross, nil, &buyerAgent)
+	if assert.Len(t, payouts, 2) {
+		assert.Equal(t, "buyer_agent", payouts[0].Recipient)
+		assert.Equal(t, 10500.0, payouts[0].Amount)
+		// The whole listing side plus the brokerage's cut of the buyer side
+		assert.Equal(t, "brokerage", payouts[1].Recipient)
+		assert.Equal(t, 19500.0, payouts[1].Amount)
+	}
+}
+
+func TestGetCommissionPayouts_Success(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and users
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	admin := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Admin",
+		Email:          "admin@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&admin)
+
+	agent := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent",
+		Email:          "agent@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agent)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      agent.ID.String(),
+		Email:          "buyer@test.com",
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	db.Create(&models.CommissionRule{
+		OrganizationID:     org.ID.String(),
+		Name:               "Standard",
+		Type:               "percentage",
+		Rate:               2,
+		Tiers:              datatypes.JSONSlice[models.CommissionTier]{},
+		ListingSidePercent: 50,
+		AgentSharePercent:  80,
+		IsDefault:          true,
+		CreatedBy:          admin.ID.String(),
+	})
+
+	agentID := agent.ID.String()
+	price := 5000000.0
+	closedAt := time.Date(2025, 6, 15, 12, 0, 0, 0, time.Local)
+	db.Create(&models.Deal{
+		OrganizationID: org.ID.String(),
+		ContactID:      contact.ID,
+		ListingAgentID: &agentID,
+		BuyerAgentID:   &agentID,
+		AcceptedPrice:  &price,
+		Stage:          "closed_won",
+		ClosedAt:       &closedAt,
+		CreatedBy:      agentID,
+	})
+
+	token := getAuthToken(t, admin.ID.String(), admin.Role, org.ID.String())
+
+	req := httptest.NewRequest("GET", "/api/commissions/payouts?from=2025-06-01&to=2025-06-30", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var report services.PayoutReport
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &report)
+
+	assert.Equal(t, 1, report.DealCount)
+	assert.Equal(t, 100000.0, report.TotalCommission)
+	assert.Equal(t, 20000.0, report.BrokerageTotal)
+	if assert.Len(t, report.Agents, 1) {
+		// Dual agency: both sides, counted as one deal
+		assert.Equal(t, 80000.0, report.Agents[0].Amount)
+		assert.Equal(t, 1, report.Agents[0].DealCount)
+		assert.Equal(t, 1, report.Agents[0].ListingSides)
+		assert.Equal(t, 1, report.Agents[0].BuyerSides)
+	}
+}
+
+func TestUpdateDeal_PayoutFieldsAdminOnly(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	admin := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Admin",
+		Email:          "admin@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&admin)
+
+	agent := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent",
+		Email:          "agent@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agent)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      agent.ID.String(),
+		Email:          "buyer@test.com",
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	agentID := agent.ID.String()
+	deal := models.Deal{
+		OrganizationID: org.ID.String(),
+		ContactID:      contact.ID,
+		BuyerAgentID:   &agentID,
+		Stage:          "offer",
+		CreatedBy:      agentID,
+	}
+	db.Create(&deal)
+
+	send := func(method, path, token string, payload interface{}) int {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+	agentToken := getAuthToken(t, agentID, agent.Role, org.ID.String())
+	adminToken := getAuthToken(t, admin.ID.String(), admin.Role, org.ID.String())
+
+	// Agents may edit the deal but not what decides their payout
+	assert.Equal(t, 200, send("PUT", "/api/deals/"+deal.ID, agentToken, map[string]interface{}{"title": "3BHK in Baner"}))
+	assert.Equal(t, 403, send("PUT", "/api/deals/"+deal.ID, agentToken, map[string]interface{}{"accepted_price": 9000000}))
+	assert.Equal(t, 403, send("PUT", "/api/deals/"+deal.ID, agentToken, map[string]interface{}{"listing_agent_id": agentID}))
+	assert.Equal(t, 403, send("POST", "/api/deals", agentToken, map[string]interface{}{
+		"contact_id":         contact.ID,
+		"commission_rule_id": uuid.New().String(),
+	}))
+
+	assert.Equal(t, 200, send("PUT", "/api/deals/"+deal.ID, adminToken, map[string]interface{}{"accepted_price": 9000000}))
+
+	db.First(&deal, "id = ?", deal.ID)
+	assert.Equal(t, "3BHK in Baner", deal.Title)
+	if assert.NotNil(t, deal.AcceptedPrice) {
+		assert.Equal(t, 9000000.0, *deal.AcceptedPrice)
+	}
+	assert.Nil(t, deal.ListingAgentID)
+}
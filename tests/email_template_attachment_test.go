@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/middleware"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectAttachmentType(t *testing.T) {
+	contentType, err := services.DetectAttachmentType([]byte("%PDF-1.7\n%âãÏÓ\n1 0 obj"))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", contentType)
+
+	// The bytes decide the type, not the file name
+	_, err = services.DetectAttachmentType([]byte("<html><script>alert(1)</script></html>"))
+	assert.ErrorIs(t, err, services.ErrAttachmentTypeNotAllowed)
+
+	_, err = services.DetectAttachmentType(nil)
+	assert.ErrorIs(t, err, services.ErrAttachmentEmpty)
+}
+
+func TestCleanAttachmentName(t *testing.T) {
+	assert.Equal(t, "brochure.pdf", services.CleanAttachmentName("../../etc/brochure.pdf"))
+	assert.Equal(t, "plan.pdf", services.CleanAttachmentName(`C:\Users\agent\plan.pdf`))
+	assert.Equal(t, "floorplan.png", services.CleanAttachmentName("floor\r\nplan.png"))
+	assert.Equal(t, "attachment", services.CleanAttachmentName(""))
+}
+
+func TestLocalFileStorage_RejectsEscapingKeys(t *testing.T) {
+	storage := &services.LocalFileStorage{Root: t.TempDir()}
+
+	assert.Error(t, storage.Save("../outside", strings.NewReader("x")))
+	assert.NoError(t, storage.Save("templates/a/b", strings.NewReader("x")))
+	assert.NoError(t, storage.Delete("templates/a/b"))
+	assert.NoError(t, storage.Delete("templates/a/b"))
+}
+
+func TestEmailTemplateAttachments_UploadListDelete(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	agent := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent",
+		Email:          "agent@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agent)
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Brochure",
+		Subject:        "Our new project",
+		HtmlBody:       "<p>See attached</p>",
+		CreatedBy:      agent.ID.String(),
+	}
+	db.Create(&template)
+
+	token := getAuthToken(t, agent.ID.String(), agent.Role, org.ID.String())
+
+	upload := func(name string, content []byte) int {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", name)
+		part.Write(content)
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/templates/"+template.ID+"/attachments", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 201, upload("brochure.pdf", []byte("%PDF-1.7\nbrochure")))
+	assert.Equal(t, 400, upload("brochure.pdf", []byte("MZ\x90\x00 not a pdf")))
+
+	req := httptest.NewRequest("GET", "/api/templates/"+template.ID+"/attachments", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var result struct {
+		Attachments []models.EmailTemplateAttachment `json:"attachments"`
+	}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &result)
+	if assert.Len(t, result.Attachments, 1) {
+		assert.Equal(t, "application/pdf", result.Attachments[0].ContentType)
+
+		files, err := services.NewAttachmentService().LoadForTemplate(&template)
+		assert.NoError(t, err)
+		if assert.Len(t, files, 1) {
+			assert.Equal(t, "%PDF-1.7\nbrochure", string(files[0].Data))
+		}
+
+		// The upload is recorded as a template version
+		var version models.EmailTemplateVersion
+		db.Where("template_id = ? AND version = ?", template.ID, 1).First(&version)
+		assert.Equal(t, []string{result.Attachments[0].ID}, []string(version.AttachmentIDs))
+
+		req = httptest.NewRequest("DELETE", "/api/templates/"+template.ID+"/attachments/"+result.Attachments[0].ID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err = app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	}
+
+	var count int64
+	db.Model(&models.EmailTemplateAttachment{}).Where("template_id = ?", template.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// A campaign pinned to the version with the brochure still sends it
+	templateService := services.NewEmailTemplateService()
+	firstVersion := 1
+	pinned, err := templateService.ResolveTemplate(template.ID, org.ID.String(), &firstVersion)
+	if assert.NoError(t, err) {
+		files, err := services.NewAttachmentService().LoadForTemplate(pinned)
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+	}
+	current, err := templateService.ResolveTemplate(template.ID, org.ID.String(), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, current.CurrentVersion)
+		files, err := services.NewAttachmentService().LoadForTemplate(current)
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+	}
+}
+
+func TestBodyLimit_OnlyAttachmentUploadsAcceptLargeBodies(t *testing.T) {
+	app := SetupTestApp()
+	body := bytes.Repeat([]byte("x"), 5*1024*1024)
+
+	req := httptest.NewRequest("POST", "/api/contacts", bytes.NewReader(body))
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 413, resp.StatusCode)
+
+	// Only the upload route itself is exempt
+	req = httptest.NewRequest("POST", "/api/contacts/"+uuid.NewString()+"/attachments", bytes.NewReader(body))
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 413, resp.StatusCode)
+
+	// Uploads get past the limit to authentication, which turns them away unread
+	req = httptest.NewRequest("POST", "/api/templates/"+uuid.NewString()+"/attachments", bytes.NewReader(body))
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// Even uploads have a limit
+	req = httptest.NewRequest("POST", "/api/templates/"+uuid.NewString()+"/attachments",
+		bytes.NewReader(bytes.Repeat([]byte("x"), middleware.UploadBodyLimit+1)))
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 413, resp.StatusCode)
+}
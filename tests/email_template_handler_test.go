@@ -294,3 +294,66 @@ func TestDeleteEmailTemplate_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 200, resp.StatusCode)
 }
+
+func TestPreviewEmailTemplate_WithContact(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      user.ID.String(),
+		FirstName:      "<b>Asha</b>",
+		Email:          "asha@test.com",
+		BudgetMax:      7500000,
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Budget",
+		Subject:        "Homes for {{contact.first_name}}",
+		HtmlBody:       "<p>Up to {{contact.budget_max | currency}} from {{agent.name}}{{contact.nickname}}</p>",
+		CreatedBy:      user.ID.String(),
+	}
+	db.Create(&template)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	body, _ := json.Marshal(map[string]interface{}{"contact_id": contact.ID})
+	req := httptest.NewRequest("POST", "/api/templates/"+template.ID+"/preview", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var result map[string]interface{}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &result)
+
+	assert.Equal(t, "Homes for <b>Asha</b>", result["subject"])
+	assert.Equal(t, "<p>Up to ₹7,500,000 from Test User</p>", result["html_body"])
+	assert.Equal(t, []interface{}{"contact.nickname"}, result["unknown_variables"])
+}
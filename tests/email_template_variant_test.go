@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	locale, err := services.NormalizeLocale(" pt_BR ")
+	assert.NoError(t, err)
+	assert.Equal(t, "pt-br", locale)
+
+	locale, err = services.NormalizeLocale("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", locale)
+
+	_, err = services.NormalizeLocale("Hindi")
+	assert.Error(t, err)
+}
+
+func TestCompileLocalizedEmail_PicksVariant(t *testing.T) {
+	template := &models.EmailTemplate{
+		FromName: "{{agent.name}}",
+		Subject:  "New homes for you",
+		HtmlBody: "<p>Hi {{contact.first_name}}</p>",
+	}
+	variants := []models.EmailTemplateVariant{
+		{Locale: "hi", Subject: "आपके लिए नए घर", HtmlBody: "<p>नमस्ते {{contact.first_name}}</p>"},
+		{Locale: "pt-br", Subject: "Novas casas", HtmlBody: "<p>Olá {{contact.first_name}}</p>"},
+	}
+
+	localized, err := services.CompileLocalizedEmail(template, variants, nil)
+	assert.NoError(t, err)
+
+	data := services.BuildTemplateData(services.SampleContact(), nil, &models.User{Name: "Asha"}, nil)
+	cases := []struct {
+		language string
+		locale   string
+		subject  string
+	}{
+		{"hi", "hi", "आपके लिए नए घर"},
+		{"hi-in", "hi", "आपके लिए नए घर"},
+		{"pt-br", "pt-br", "Novas casas"},
+		{"pt", "", "New homes for you"},
+		{"mr", "", "New homes for you"},
+		{"", "", "New homes for you"},
+	}
+	for _, tc := range cases {
+		compiled, locale := localized.For(tc.language)
+		assert.Equal(t, tc.locale, locale, tc.language)
+		rendered, err := compiled.Render(data)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.subject, rendered.Subject, tc.language)
+		// Sender fields always come from the template
+		assert.Equal(t, "Asha", rendered.FromName, tc.language)
+	}
+
+	variants[0].HtmlBody = "{{#if contact.first_name}}"
+	_, err = services.CompileLocalizedEmail(template, variants, nil)
+	assert.ErrorContains(t, err, "variant hi")
+}
+
+func TestPreviewEmailTemplate_ContactLanguage(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	agent := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent",
+		Email:          "agent@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agent)
+
+	token := getAuthToken(t, agent.ID.String(), agent.Role, org.ID.String())
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Listing",
+		Subject:        "New listing",
+		HtmlBody:       "<p>Hi {{contact.first_name}}</p>",
+		CreatedBy:      agent.ID.String(),
+	}
+	db.Create(&template)
+
+	contact := models.Contact{
+		OrganizationID:    org.ID.String(),
+		FirstName:         "Ravi",
+		Email:             "ravi@example.com",
+		PreferredLanguage: "hi",
+	}
+	db.Create(&contact)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"locale":    "HI",
+		"subject":   "नई लिस्टिंग",
+		"html_body": `<p onclick="x()">नमस्ते {{contact.first_name}}</p>`,
+	})
+	req := httptest.NewRequest("POST", "/api/templates/"+template.ID+"/variants", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	var variant models.EmailTemplateVariant
+	db.Where("email_template_id = ?", template.ID).First(&variant)
+	assert.Equal(t, "hi", variant.Locale)
+	assert.NotContains(t, variant.HtmlBody, "onclick")
+
+	body, _ = json.Marshal(map[string]interface{}{"contact_id": contact.ID})
+	req = httptest.NewRequest("POST", "/api/templates/"+template.ID+"/preview", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var preview map[string]interface{}
+	raw, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(raw, &preview)
+	assert.Equal(t, "hi", preview["locale"])
+	assert.Equal(t, "नई लिस्टिंग", preview["subject"])
+
+	// The sample contact has no language and gets the template default
+	req = httptest.NewRequest("POST", "/api/templates/"+template.ID+"/preview", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	raw, _ = io.ReadAll(resp.Body)
+	json.Unmarshal(raw, &preview)
+	assert.Equal(t, "", preview["locale"])
+	assert.Equal(t, "New listing", preview["subject"])
+}
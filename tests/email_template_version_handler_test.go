@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	diff := services.DiffLines("Hi\nOld line\nBye", "Hi\nNew line\nBye")
+
+	assert.Equal(t, []services.DiffLine{
+		{Op: "equal", Text: "Hi"},
+		{Op: "remove", Text: "Old line"},
+		{Op: "add", Text: "New line"},
+		{Op: "equal", Text: "Bye"},
+	}, diff)
+}
+
+func TestRestoreEmailTemplateVersion_Success(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	send := func(method, path string, payload interface{}) map[string]interface{} {
+		var reader io.Reader
+		if payload != nil {
+			body, _ := json.Marshal(payload)
+			reader = bytes.NewReader(body)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Less(t, resp.StatusCode, 300, path)
+
+		var result map[string]interface{}
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		json.Unmarshal(bodyBytes, &result)
+		return result
+	}
+
+	// Version 1 on create, version 2 on update
+	created := send("POST", "/api/templates", map[string]interface{}{
+		"name":      "Welcome",
+		"subject":   "Welcome",
+		"html_body": "<p>Hello</p>",
+	})
+	templateID := created["template"].(map[string]interface{})["ID"].(string)
+
+	send("PUT", "/api/templates/"+templateID, map[string]interface{}{"subject": "Welcome aboard"})
+
+	versions := send("GET", "/api/templates/"+templateID+"/versions", nil)
+	assert.Equal(t, 2.0, versions["current_version"])
+	assert.Len(t, versions["versions"], 2)
+
+	diff := send("GET", "/api/templates/"+templateID+"/versions/diff?from=1", nil)
+	changes := diff["changes"].([]interface{})
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, "subject", changes[0].(map[string]interface{})["field"])
+	}
+
+	// Restoring version 1 records it as version 3
+	restored := send("POST", "/api/templates/"+templateID+"/versions/1/restore", nil)
+	template := restored["template"].(map[string]interface{})
+	assert.Equal(t, "Welcome", template["Subject"])
+	assert.Equal(t, 3.0, template["CurrentVersion"])
+}
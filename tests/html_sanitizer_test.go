@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeEmailHTML_RemovesActiveContent(t *testing.T) {
+	src := `<p onclick="steal()">Hi</p><script>alert(1)</script>` +
+		`<a href=" java script:alert(1)">Bad</a><a href="JavaScript:alert(1)">Bad</a>` +
+		`<iframe src="https://evil.test"><p>inside</p></iframe>` +
+		`<form action="https://evil.test"><input name="pw"><button>Go</button></form>`
+
+	out, report := services.SanitizeEmailHTML(src)
+
+	assert.NotContains(t, out, "script")
+	assert.NotContains(t, out, "onclick")
+	assert.NotContains(t, out, "iframe")
+	assert.NotContains(t, out, "inside")
+	assert.NotContains(t, out, "<form")
+	assert.NotContains(t, out, "<input")
+	assert.Contains(t, out, "<p>Hi</p>")
+	assert.Contains(t, out, "Go")
+
+	assert.Equal(t, 1, report.RemovedElements["script"])
+	assert.Equal(t, 1, report.RemovedElements["iframe"])
+	assert.Equal(t, 1, report.RemovedElements["form"])
+	assert.Equal(t, 1, report.RemovedAttributes["p onclick"])
+	assert.Equal(t, 2, report.RemovedAttributes["a href"])
+	assert.False(t, report.Empty())
+}
+
+func TestSanitizeEmailHTML_KeepsEmailMarkup(t *testing.T) {
+	src := `<style>td { padding: 8px; }</style>` +
+		`<table width="600" style="border-collapse:collapse;"><tr><td style="color:#333;">` +
+		`<img src="data:image/png;base64,AAAA" alt="Logo">` +
+		`<a href="{{unsubscribe_url}}">Unsubscribe</a></td></tr></table>`
+
+	out, report := services.SanitizeEmailHTML(src)
+
+	// The markup is re-serialized from the parsed tree, which adds the implied <tbody>
+	assert.Equal(t, `<style>td { padding: 8px; }</style>`+
+		`<table width="600" style="border-collapse:collapse;"><tbody><tr><td style="color:#333;">`+
+		`<img src="data:image/png;base64,AAAA" alt="Logo"/>`+
+		`<a href="{{unsubscribe_url}}">Unsubscribe</a></td></tr></tbody></table>`, out)
+	assert.True(t, report.Empty())
+}
+
+func TestSanitizeEmailHTML_KeepsTemplateTags(t *testing.T) {
+	src := `<table>{{#each listings}}<tr><td style="color:{{brand.primary_color | default "#1a73e8"}}">` +
+		`{{title}}</td></tr>{{/each}}</table><a href="mailto:{{agent.email}}">Email me</a>`
+
+	out, report := services.SanitizeEmailHTML(src)
+
+	assert.Contains(t, out, `<table>{{#each listings}}`)
+	assert.Contains(t, out, `<td style="color:{{brand.primary_color | default "#1a73e8"}}">{{title}}</td></tr>{{/each}}`)
+	assert.Contains(t, out, `<a href="mailto:{{agent.email}}">Email me</a>`)
+	assert.True(t, report.Empty())
+}
+
+func TestSanitizeEmailHTML_ParserDifferentialPayloads(t *testing.T) {
+	payloads := []string{
+		`<svg><style><img src=x onerror=alert(1)></style></svg>`,
+		`<math><title><img src=x onerror=alert(1)></title></math>`,
+		`<!-- --!><img src=x onerror=alert(1)> -->`,
+	}
+
+	for _, src := range payloads {
+		out, report := services.SanitizeEmailHTML(src)
+
+		assert.NotContains(t, out, "onerror", src)
+		assert.Equal(t, 1, report.RemovedAttributes["img onerror"], src)
+	}
+}
+
+func TestSanitizeEmailHTML_TemplateTagsCannotAddAttributes(t *testing.T) {
+	cases := []struct {
+		src, element string
+	}{
+		{`<img src="x" {{! " onerror="alert(1) }}>`, "img"},
+		{`<a href="#" {{!-- " onclick="alert(1) --}}>Open</a>`, "a"},
+		{`<a title="{{! " onclick="alert(1) }}">Open</a>`, "a"},
+	}
+
+	for _, tc := range cases {
+		out, report := services.SanitizeEmailHTML(tc.src)
+
+		assert.NotContains(t, out, "alert", tc.src)
+		assert.Equal(t, 1, report.RemovedAttributes[tc.element+" template tag"], tc.src)
+	}
+
+	// Simple tags in an attribute list stay
+	out, report := services.SanitizeEmailHTML(`<p {{paragraph_attributes}}>x</p>`)
+	assert.Equal(t, `<p {{paragraph_attributes}}>x</p>`, out)
+	assert.True(t, report.Empty())
+}
+
+func TestSanitizeEmailHTML_TemplateTagsCannotHideScriptURLs(t *testing.T) {
+	src := `<a href="{{contact.notes}}javascript:alert(1)">Open</a>` +
+		`<svg><a xlink:href="javascript:alert(1)"><animate attributeName="href" values="javascript:alert(1)"/>` +
+		`<text>Open</text></a></svg>`
+
+	out, report := services.SanitizeEmailHTML(src)
+
+	assert.NotContains(t, out, "javascript")
+	assert.Equal(t, 1, report.RemovedAttributes["a href"])
+	assert.Equal(t, 1, report.RemovedAttributes["a xlink:href"])
+	assert.Equal(t, 1, report.RemovedElements["animate"])
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPipelineStages_SeedsDefaults(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	req := httptest.NewRequest("GET", "/api/pipeline/stages", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var stages []map[string]interface{}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &stages)
+
+	assert.Len(t, stages, 7)
+	assert.Equal(t, "New", stages[0]["Name"])
+}
+
+func TestGetPipelineStages_ConcurrentFirstUseSeedsOnce(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	pipelineService := services.NewPipelineService()
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pipelineService.GetStages(org.ID.String())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	var count int64
+	db.Model(&models.PipelineStage{}).Where("organization_id = ?", org.ID.String()).Count(&count)
+	assert.Equal(t, int64(7), count)
+}
+
+func TestMoveContactStage_RecordsHistory(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	stage := models.PipelineStage{
+		OrganizationID: org.ID.String(),
+		Name:           "Qualified",
+		Position:       1,
+		Outcome:        "open",
+	}
+	db.Create(&stage)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      user.ID.String(),
+		FirstName:      "John",
+		Email:          "john@test.com",
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	body, _ := json.Marshal(map[string]interface{}{"stage_id": stage.ID})
+	req := httptest.NewRequest("PUT", "/api/contacts/"+contact.ID+"/stage", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var history []models.ContactStageHistory
+	db.Where("contact_id = ?", contact.ID).Find(&history)
+	assert.Len(t, history, 1)
+	assert.Equal(t, stage.ID, history[0].ToStageID)
+	assert.Equal(t, user.ID.String(), history[0].ChangedBy)
+}
+
+func TestGetPipelineBoard_Success(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	stage := models.PipelineStage{
+		OrganizationID: org.ID.String(),
+		Name:           "New",
+		Position:       1,
+		Outcome:        "open",
+	}
+	db.Create(&stage)
+
+	for _, budget := range []float64{100000, 250000} {
+		contact := models.Contact{
+			OrganizationID: org.ID.String(),
+			CreatedBy:      user.ID.String(),
+			Email:          uuid.New().String() + "@test.com",
+			BudgetMax:      budget,
+			StageID:        &stage.ID,
+			IsActive:       true,
+		}
+		db.Create(&contact)
+	}
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	req := httptest.NewRequest("GET", "/api/pipeline/board", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var response struct {
+		Columns []struct {
+			ContactCount   int64   `json:"contact_count"`
+			TotalBudgetMax float64 `json:"total_budget_max"`
+		} `json:"columns"`
+	}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &response)
+
+	assert.Len(t, response.Columns, 1)
+	assert.Equal(t, int64(2), response.Columns[0].ContactCount)
+	assert.Equal(t, float64(350000), response.Columns[0].TotalBudgetMax)
+}
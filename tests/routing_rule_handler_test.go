@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestRuleMatchesContact(t *testing.T) {
+	budgetMin := 500000.0
+	budgetMax := 1000000.0
+	rule := &models.LeadRoutingRule{
+		Locations:     datatypes.JSONSlice[string]{"Pune", "Mumbai"},
+		PropertyTypes: datatypes.JSONSlice[string]{},
+		BudgetMin:     &budgetMin,
+		BudgetMax:     &budgetMax,
+	}
+
+	// Location matches case-insensitively and the budget ranges overlap
+	assert.True(t, services.RuleMatchesContact(rule, &models.Contact{
+		PreferredLocation: "pune",
+		BudgetMin:         800000,
+		BudgetMax:         1200000,
+	}))
+
+	// Wrong location
+	assert.False(t, services.RuleMatchesContact(rule, &models.Contact{
+		PreferredLocation: "Delhi",
+		BudgetMax:         900000,
+	}))
+
+	// Budget entirely above the band
+	assert.False(t, services.RuleMatchesContact(rule, &models.Contact{
+		PreferredLocation: "Mumbai",
+		BudgetMin:         2000000,
+		BudgetMax:         3000000,
+	}))
+
+	// No budget cannot be placed in a budget band
+	assert.False(t, services.RuleMatchesContact(rule, &models.Contact{
+		PreferredLocation: "Mumbai",
+	}))
+}
+
+func TestApplyRoutingRules_RoundRobinSkipsInactiveAgents(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and admin
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	admin := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Admin",
+		Email:          "admin@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&admin)
+
+	agentA := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent A",
+		Email:          "a@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agentA)
+
+	agentB := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent B",
+		Email:          "b@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agentB)
+
+	inactive := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Inactive Agent",
+		Email:          "inactive@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&inactive)
+	db.Model(&inactive).Update("is_active", false)
+
+	for i := 0; i < 4; i++ {
+		contact := models.Contact{
+			OrganizationID:    org.ID.String(),
+			CreatedBy:         admin.ID.String(),
+			Email:             uuid.New().String() + "@test.com",
+			PreferredLocation: "Pune",
+			IsActive:          true,
+		}
+		db.Create(&contact)
+	}
+
+	token := getAuthToken(t, admin.ID.String(), admin.Role, org.ID.String())
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":      "Pune leads",
+		"locations": []string{"Pune"},
+		"agent_ids": []string{agentA.ID.String(), inactive.ID.String(), agentB.ID.String()},
+		"strategy":  "round_robin",
+	})
+	req := httptest.NewRequest("POST", "/api/routing-rules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	req = httptest.NewRequest("POST", "/api/routing-rules/apply", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var countA, countB, countInactive int64
+	db.Model(&models.Contact{}).Where("assigned_to = ?", agentA.ID.String()).Count(&countA)
+	db.Model(&models.Contact{}).Where("assigned_to = ?", agentB.ID.String()).Count(&countB)
+	db.Model(&models.Contact{}).Where("assigned_to = ?", inactive.ID.String()).Count(&countInactive)
+	assert.Equal(t, int64(2), countA)
+	assert.Equal(t, int64(2), countB)
+	assert.Equal(t, int64(0), countInactive)
+
+	// Each assignment notifies the receiving agent
+	var notifications int64
+	db.Model(&models.Notification{}).Where("notification_type = ?", "lead_assigned").Count(&notifications)
+	assert.Equal(t, int64(4), notifications)
+}
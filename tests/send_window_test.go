@@ -0,0 +1,335 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	repository "github.com/atharvpunekar/real_estate_crm_backend/internal/repositories"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestSendWindow_NextSendTime(t *testing.T) {
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	window := services.NewSendWindow(&models.OrganizationSettings{
+		Timezone:         "Asia/Kolkata",
+		QuietHoursStart:  "21:00",
+		QuietHoursEnd:    "08:00",
+		CampaignSendDays: datatypes.JSONSlice[int]{1, 2, 3, 4, 5},
+	})
+
+	// Wednesday 14 October 2026
+	at := func(day, hour, minute int, location *time.Location) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, location)
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		timezone string
+		want     time.Time
+	}{
+		{"inside the window", at(14, 10, 0, kolkata), "", at(14, 10, 0, kolkata)},
+		{"late evening waits for the morning", at(14, 22, 30, kolkata), "", at(15, 8, 0, kolkata)},
+		{"early morning waits for the end of quiet hours", at(14, 3, 0, kolkata), "", at(14, 8, 0, kolkata)},
+		{"friday night waits for monday", at(16, 23, 0, kolkata), "", at(19, 8, 0, kolkata)},
+		{"saturday waits for monday", at(17, 12, 0, kolkata), "", at(19, 8, 0, kolkata)},
+		{"contact time zone", at(14, 10, 0, kolkata), "America/New_York", at(14, 8, 0, newYork)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(window.NextSendTime(tt.now, tt.timezone)))
+		})
+	}
+
+	// Without quiet hours or send days there is no window
+	assert.Nil(t, services.NewSendWindow(&models.OrganizationSettings{Timezone: "Asia/Kolkata"}))
+	now := time.Now()
+	assert.Equal(t, now, services.NewSendWindow(nil).NextSendTime(now, ""))
+}
+
+func TestValidateSendWindow(t *testing.T) {
+	settings := &models.OrganizationSettings{
+		Timezone:         " Europe/London ",
+		QuietHoursStart:  "20:00",
+		QuietHoursEnd:    "07:30",
+		CampaignSendDays: datatypes.JSONSlice[int]{5, 1, 1, 3},
+	}
+	assert.NoError(t, services.ValidateSendWindow(settings))
+	assert.Equal(t, "Europe/London", settings.Timezone)
+	assert.Equal(t, datatypes.JSONSlice[int]{1, 3, 5}, settings.CampaignSendDays)
+
+	settings.QuietHoursEnd = ""
+	assert.Error(t, services.ValidateSendWindow(settings))
+
+	settings.QuietHoursEnd = "7pm"
+	assert.Error(t, services.ValidateSendWindow(settings))
+
+	settings.QuietHoursEnd = "07:30"
+	settings.CampaignSendDays = datatypes.JSONSlice[int]{7}
+	assert.Error(t, services.ValidateSendWindow(settings))
+
+	settings.CampaignSendDays = nil
+	settings.Timezone = "Mars/Olympus_Mons"
+	assert.Error(t, services.ValidateSendWindow(settings))
+}
+
+func TestProcessCampaignRun_DefersOutsideSendWindow(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	// Quiet hours cover the current time
+	now := time.Now().UTC()
+	settings := models.OrganizationSettings{
+		OrganizationID:  org.ID.String(),
+		QuietHoursStart: now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:   now.Add(time.Hour).Format("15:04"),
+	}
+	db.Create(&settings)
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Test Template",
+		Subject:        "Test Subject",
+		HtmlBody:       "<p>Test Body</p>",
+		CreatedBy:      uuid.New().String(),
+	}
+	db.Create(&template)
+
+	contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), Email: "night@test.com"}
+	db.Create(&contact)
+
+	campaign := models.Campaign{
+		OrganizationID:       org.ID.String(),
+		Name:                 "Test Campaign",
+		TemplateID:           template.ID,
+		FollowLatestTemplate: true,
+		ContactID:            &contact.ID,
+		ScheduleType:         "once",
+		Status:               "scheduled",
+		CreatedBy:            uuid.New().String(),
+	}
+	db.Create(&campaign)
+
+	job := models.BackgroundJobLog{JobType: "campaign_run", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+	db.Create(&job)
+
+	service := services.NewBackgroundJobService()
+	service.ProcessCampaignRun(job.ID, campaign.ID)
+
+	var logs []models.CampaignLog
+	db.Where("campaign_id = ?", campaign.ID).Find(&logs)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "deferred", logs[0].Status)
+	if assert.NotNil(t, logs[0].DeferredUntil) {
+		assert.True(t, logs[0].DeferredUntil.After(now))
+	}
+
+	// The campaign isn't done, or reported sent, while its email waits
+	var stored models.Campaign
+	db.First(&stored, "id = ?", campaign.ID)
+	assert.Equal(t, "running", stored.Status)
+	var notifications int64
+	db.Model(&models.Notification{}).Where("related_campaign_id = ?", campaign.ID).Count(&notifications)
+	assert.Equal(t, int64(0), notifications)
+
+	// Once the window opens the deferred email goes out
+	db.Model(&models.OrganizationSettings{}).Where("organization_id = ?", org.ID.String()).
+		Updates(map[string]interface{}{"quiet_hours_start": "", "quiet_hours_end": ""})
+	db.Model(&logs[0]).Update("deferred_until", now.Add(-time.Minute))
+
+	logRepo := &repository.CampaignLogRepository{}
+	due, err := logRepo.FindDueDeferred(time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+	claimed, err := logRepo.ClaimDeferred(due[0].ID)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, _ = logRepo.ClaimDeferred(due[0].ID)
+	assert.False(t, claimed)
+
+	deferredJob := models.BackgroundJobLog{JobType: "campaign_deferred", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+	db.Create(&deferredJob)
+	service.ProcessDeferredCampaignEmails(deferredJob.ID, campaign.ID, due)
+
+	db.First(&logs[0], "id = ?", logs[0].ID)
+	assert.Equal(t, "sent", logs[0].Status)
+	assert.NotNil(t, logs[0].DeferredUntil)
+
+	// Sending the last deferred email completes the campaign and reports the real total
+	db.First(&stored, "id = ?", campaign.ID)
+	assert.Equal(t, "completed", stored.Status)
+	var notification models.Notification
+	assert.NoError(t, db.Where("related_campaign_id = ?", campaign.ID).First(&notification).Error)
+	assert.Equal(t, "Your campaign has been sent to 1 recipients", notification.Message)
+}
+
+func TestProcessCampaignRun_TestClockStartsWhenDeferredSampleIsSent(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	now := time.Now().UTC()
+	settings := models.OrganizationSettings{
+		OrganizationID:  org.ID.String(),
+		QuietHoursStart: now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:   now.Add(time.Hour).Format("15:04"),
+	}
+	db.Create(&settings)
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Test Template",
+		Subject:        "Test Subject",
+		HtmlBody:       "<p>Test Body</p>",
+		CreatedBy:      uuid.New().String(),
+	}
+	db.Create(&template)
+
+	contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), Email: "night@test.com"}
+	db.Create(&contact)
+
+	campaign := models.Campaign{
+		OrganizationID:       org.ID.String(),
+		Name:                 "Test Campaign",
+		TemplateID:           template.ID,
+		FollowLatestTemplate: true,
+		ContactID:            &contact.ID,
+		ScheduleType:         "once",
+		VariantMode:          services.VariantModeTestWinner,
+		TestPercent:          20,
+		TestDurationHours:    4,
+		WinnerMetric:         "open",
+		Status:               "scheduled",
+		CreatedBy:            uuid.New().String(),
+	}
+	db.Create(&campaign)
+	for _, label := range []string{"A", "B"} {
+		db.Create(&models.CampaignVariant{CampaignID: campaign.ID, OrganizationID: org.ID.String(), Label: label, TemplateID: template.ID, SplitPercent: 50})
+	}
+
+	job := models.BackgroundJobLog{JobType: "campaign_run", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+	db.Create(&job)
+
+	service := services.NewBackgroundJobService()
+	service.ProcessCampaignRun(job.ID, campaign.ID)
+
+	// The sample is waiting on quiet hours, so the test period hasn't started
+	var stored models.Campaign
+	db.First(&stored, "id = ?", campaign.ID)
+	assert.Equal(t, "testing", stored.Status)
+	assert.Nil(t, stored.TestSentAt)
+
+	campaignRepo := &repository.CampaignRepository{}
+	waiting, err := campaignRepo.FindTestingCampaigns()
+	assert.NoError(t, err)
+	assert.Empty(t, waiting)
+
+	db.Model(&models.OrganizationSettings{}).Where("organization_id = ?", org.ID.String()).
+		Updates(map[string]interface{}{"quiet_hours_start": "", "quiet_hours_end": ""})
+	db.Model(&models.CampaignLog{}).Where("campaign_id = ?", campaign.ID).Update("deferred_until", now.Add(-time.Minute))
+
+	logRepo := &repository.CampaignLogRepository{}
+	due, err := logRepo.FindDueDeferred(time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+	logRepo.ClaimDeferred(due[0].ID)
+
+	deferredJob := models.BackgroundJobLog{JobType: "campaign_deferred", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+	db.Create(&deferredJob)
+	sentAt := time.Now()
+	service.ProcessDeferredCampaignEmails(deferredJob.ID, campaign.ID, due)
+
+	// The clock starts when the sample actually goes out
+	db.First(&stored, "id = ?", campaign.ID)
+	assert.Equal(t, "testing", stored.Status)
+	if assert.NotNil(t, stored.TestSentAt) {
+		assert.False(t, stored.TestSentAt.Before(sentAt))
+	}
+}
+
+func TestProcessCampaignRun_DeferredEmailsCountTowardFrequencyCap(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	now := time.Now().UTC()
+	settings := models.OrganizationSettings{
+		OrganizationID:       org.ID.String(),
+		QuietHoursStart:      now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:        now.Add(time.Hour).Format("15:04"),
+		CampaignFrequencyCap: 1,
+	}
+	db.Create(&settings)
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Test Template",
+		Subject:        "Test Subject",
+		HtmlBody:       "<p>Test Body</p>",
+		CreatedBy:      uuid.New().String(),
+	}
+	db.Create(&template)
+
+	contact := models.Contact{OrganizationID: org.ID.String(), CreatedBy: uuid.New().String(), Email: "night@test.com"}
+	db.Create(&contact)
+
+	service := services.NewBackgroundJobService()
+	var campaigns []models.Campaign
+	for _, name := range []string{"First Campaign", "Second Campaign"} {
+		campaign := models.Campaign{
+			OrganizationID:       org.ID.String(),
+			Name:                 name,
+			TemplateID:           template.ID,
+			FollowLatestTemplate: true,
+			ContactID:            &contact.ID,
+			ScheduleType:         "once",
+			Status:               "scheduled",
+			CreatedBy:            uuid.New().String(),
+		}
+		db.Create(&campaign)
+		campaigns = append(campaigns, campaign)
+
+		job := models.BackgroundJobLog{JobType: "campaign_run", OrganizationID: org.ID.String(), ReferenceID: &campaign.ID, Status: "queued"}
+		db.Create(&job)
+		service.ProcessCampaignRun(job.ID, campaign.ID)
+	}
+
+	// The first campaign's deferred email uses up the cap, so the second doesn't wait to go out too
+	var first, second models.CampaignLog
+	db.Where("campaign_id = ?", campaigns[0].ID).First(&first)
+	assert.Equal(t, "deferred", first.Status)
+	db.Where("campaign_id = ?", campaigns[1].ID).First(&second)
+	assert.Equal(t, "skipped", second.Status)
+	assert.Equal(t, "frequency_cap", second.SkipReason)
+
+	logRepo := &repository.CampaignLogRepository{}
+	due, err := logRepo.FindDueDeferred(now.Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjectPreheader_AfterBodyTag(t *testing.T) {
+	out := services.InjectPreheader(`<html><body class="x"><p>Hi</p></body></html>`, "New listings & more")
+
+	assert.True(t, strings.HasPrefix(out, `<html><body class="x"><div style="display:none;`))
+	assert.Contains(t, out, "New listings &amp; more")
+	assert.True(t, strings.HasSuffix(out, `</div><p>Hi</p></body></html>`))
+
+	// No preheader leaves the body untouched
+	assert.Equal(t, "<p>Hi</p>", services.InjectPreheader("<p>Hi</p>", ""))
+}
+
+func TestCheckSender(t *testing.T) {
+	verified := map[string]bool{"sales@acme.test": true}
+
+	assert.NoError(t, services.CheckSender("Acme Realty", "", verified))
+	assert.NoError(t, services.CheckSender("Acme Realty", "Sales@Acme.test", verified))
+	assert.Error(t, services.CheckSender("Acme Realty", "other@acme.test", verified))
+	assert.Error(t, services.CheckSender("Acme\r\nBcc: x@evil.test", "", verified))
+}
+
+func TestVerifySenderIdentity_ClearsTemplateWarning(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and admin
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	admin := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Admin",
+		Email:          "admin@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&admin)
+
+	token := getAuthToken(t, admin.ID.String(), admin.Role, org.ID.String())
+
+	createTemplate := func() map[string]interface{} {
+		body, _ := json.Marshal(map[string]interface{}{
+			"name":      "Welcome",
+			"subject":   "Welcome",
+			"from_name": "Acme Realty",
+			"reply_to":  "sales@acme.test",
+			"html_body": `<p>Hello</p><a href="{{unsubscribe_url}}">Unsubscribe</a>`,
+		})
+		req := httptest.NewRequest("POST", "/api/templates", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+
+		var result map[string]interface{}
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		json.Unmarshal(bodyBytes, &result)
+		return result
+	}
+
+	// Saving with an unknown Reply-To warns but does not fail
+	result := createTemplate()
+	assert.Len(t, result["warnings"], 1)
+
+	body, _ := json.Marshal(map[string]interface{}{"email": "sales@acme.test", "display_name": "Acme Sales"})
+	req := httptest.NewRequest("POST", "/api/sender-identities", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	var identity models.SenderIdentity
+	db.Where("organization_id = ?", org.ID).First(&identity)
+	if assert.NotNil(t, identity.VerificationToken) {
+		req = httptest.NewRequest("GET", "/public/sender-identities/verify/"+*identity.VerificationToken, nil)
+		resp, err = app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	}
+
+	result = createTemplate()
+	assert.Len(t, result["warnings"], 0)
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestValidateSequenceSteps(t *testing.T) {
+	templateID := "t1"
+	steps := []models.SequenceStep{
+		{Type: services.SequenceStepSendEmail, TemplateID: &templateID},
+		{Type: services.SequenceStepWait, WaitDays: 3},
+		{Type: services.SequenceStepBranch, Condition: "opened", NoStep: 5},
+		{Type: services.SequenceStepCreateTask, TaskTitle: "Call about the listing", TaskDueDays: 1},
+		{Type: services.SequenceStepSendEmail, TemplateID: &templateID},
+	}
+	assert.NoError(t, services.ValidateSequenceSteps(steps))
+	assert.Equal(t, 5, steps[4].Position)
+
+	assert.Error(t, services.ValidateSequenceSteps(nil))
+
+	// Branches only jump forward
+	steps[2].NoStep = 2
+	assert.Error(t, services.ValidateSequenceSteps(steps))
+	steps[2].NoStep = 6
+	assert.Error(t, services.ValidateSequenceSteps(steps))
+
+	// A branch needs an earlier email to check
+	assert.Error(t, services.ValidateSequenceSteps([]models.SequenceStep{
+		{Type: services.SequenceStepBranch, Condition: "clicked"},
+		{Type: services.SequenceStepWait, WaitDays: 1},
+	}))
+
+	assert.Error(t, services.ValidateSequenceSteps([]models.SequenceStep{{Type: services.SequenceStepWait}}))
+	assert.Error(t, services.ValidateSequenceSteps([]models.SequenceStep{{Type: services.SequenceStepSendEmail}}))
+	assert.Error(t, services.ValidateSequenceSteps([]models.SequenceStep{{Type: "send_sms"}}))
+}
+
+func TestValidateSequenceSettings(t *testing.T) {
+	audienceID := "a1"
+	sequence := &models.Sequence{
+		Name:              "New lead nurture",
+		TriggerType:       services.SequenceTriggerAudienceAdded,
+		TriggerAudienceID: &audienceID,
+		ExitConditions:    datatypes.JSONSlice[string]{"unsubscribed", "deal_closed"},
+	}
+	assert.NoError(t, services.ValidateSequenceSettings(sequence))
+
+	sequence.TriggerType = services.SequenceTriggerStageChanged
+	assert.Error(t, services.ValidateSequenceSettings(sequence))
+
+	sequence.TriggerType = services.SequenceTriggerContactCreated
+	assert.NoError(t, services.ValidateSequenceSettings(sequence))
+	assert.Nil(t, sequence.TriggerAudienceID)
+
+	sequence.ExitConditions = datatypes.JSONSlice[string]{"bounced"}
+	assert.Error(t, services.ValidateSequenceSettings(sequence))
+}
+
+func TestSequenceEnrollAndAdvance(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{ID: uuid.New(), Name: "Test Org"}
+	db.Create(&org)
+
+	agent := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent",
+		Email:          "agent@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agent)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		FirstName:      "Ravi",
+		Email:          "ravi@example.com",
+		CreatedBy:      agent.ID.String(),
+	}
+	db.Create(&contact)
+
+	token := getAuthToken(t, agent.ID.String(), agent.Role, org.ID.String())
+	send := func(method, path string, payload interface{}) int {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 201, send("POST", "/api/sequences", map[string]interface{}{
+		"name":            "Follow up",
+		"exit_conditions": []string{"deal_closed"},
+		"steps": []map[string]interface{}{
+			{"type": "create_task", "task_title": "Call Ravi"},
+			{"type": "wait", "wait_days": 2},
+			{"type": "create_task", "task_title": "Second call"},
+		},
+	}))
+
+	var sequence models.Sequence
+	db.Where("organization_id = ?", org.ID.String()).First(&sequence)
+
+	assert.Equal(t, 200, send("POST", "/api/sequences/"+sequence.ID+"/activate", nil))
+	assert.Equal(t, 200, send("POST", "/api/sequences/"+sequence.ID+"/enrollments", map[string]interface{}{
+		"contact_ids": []string{contact.ID},
+	}))
+
+	services.NewBackgroundJobService().ProcessSequences()
+
+	// The first task is created and the contact waits at step 3
+	var enrollment models.SequenceEnrollment
+	db.Where("sequence_id = ?", sequence.ID).First(&enrollment)
+	assert.Equal(t, "active", enrollment.Status)
+	assert.Equal(t, 3, enrollment.CurrentStep)
+	assert.True(t, enrollment.NextRunAt.After(time.Now().Add(47*time.Hour)))
+
+	var tasks []models.Task
+	db.Where("contact_id = ?", contact.ID).Find(&tasks)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, agent.ID.String(), tasks[0].AssignedTo)
+
+	// Steps are locked while the contact is part-way through
+	assert.Equal(t, 400, send("PUT", "/api/sequences/"+sequence.ID, map[string]interface{}{
+		"steps": []map[string]interface{}{{"type": "wait", "wait_days": 1}},
+	}))
+
+	// A closed deal takes the contact out before the next step
+	db.Create(&models.Deal{OrganizationID: org.ID.String(), ContactID: contact.ID, Stage: "closed_won", CreatedBy: agent.ID.String()})
+	db.Model(&enrollment).Update("next_run_at", time.Now().Add(-time.Minute))
+
+	services.NewBackgroundJobService().ProcessSequences()
+
+	db.First(&enrollment, "id = ?", enrollment.ID)
+	assert.Equal(t, "exited", enrollment.Status)
+	assert.Equal(t, "deal_closed", enrollment.ExitReason)
+	db.Where("contact_id = ?", contact.ID).Find(&tasks)
+	assert.Len(t, tasks, 1)
+}
+
+func TestSequence_AgentsNeedAdminWhenApprovalRequired(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{ID: uuid.New(), Name: "Test Org"}
+	db.Create(&org)
+	db.Create(&models.OrganizationSettings{OrganizationID: org.ID.String(), RequireCampaignApproval: true})
+
+	agent := models.User{ID: uuid.New(), OrganizationID: org.ID, Name: "Agent", Email: "agent@test.com", Role: "org_user", IsActive: true}
+	admin := models.User{ID: uuid.New(), OrganizationID: org.ID, Name: "Admin", Email: "admin@test.com", Role: "org_admin", IsActive: true}
+	db.Create(&agent)
+	db.Create(&admin)
+
+	contact := models.Contact{OrganizationID: org.ID.String(), FirstName: "Ravi", Email: "ravi@example.com", CreatedBy: agent.ID.String()}
+	db.Create(&contact)
+
+	send := func(user models.User, method, path string, payload interface{}) int {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+getAuthToken(t, user.ID.String(), user.Role, org.ID.String()))
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 201, send(agent, "POST", "/api/sequences", map[string]interface{}{
+		"name":  "Drip",
+		"steps": []map[string]interface{}{{"type": "create_task", "task_title": "Call"}},
+	}))
+	var sequence models.Sequence
+	db.Where("organization_id = ?", org.ID.String()).First(&sequence)
+
+	assert.Equal(t, 403, send(agent, "POST", "/api/sequences/"+sequence.ID+"/activate", nil))
+	assert.Equal(t, 200, send(admin, "POST", "/api/sequences/"+sequence.ID+"/activate", nil))
+
+	enroll := map[string]interface{}{"contact_ids": []string{contact.ID}}
+	assert.Equal(t, 403, send(agent, "POST", "/api/sequences/"+sequence.ID+"/enrollments", enroll))
+	assert.Equal(t, 403, send(agent, "PUT", "/api/sequences/"+sequence.ID, map[string]interface{}{"name": "Changed"}))
+	assert.Equal(t, 200, send(admin, "POST", "/api/sequences/"+sequence.ID+"/enrollments", enroll))
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultSystemTemplates_Valid(t *testing.T) {
+	slugs := map[string]bool{}
+	for _, template := range services.DefaultSystemTemplates() {
+		assert.False(t, slugs[template.Slug], template.Slug)
+		slugs[template.Slug] = true
+		assert.True(t, services.SystemTemplateCategories[template.Category], template.Slug)
+
+		content := services.SystemTemplateContent(&template)
+		assert.NoError(t, services.ValidateEmailTemplate(content), template.Slug)
+		assert.Empty(t, services.LintEmailTemplate(content, nil), template.Slug)
+
+		rendered, err := services.RenderEmailTemplate(content, nil, services.BuildTemplateData(services.SampleContact(), nil, nil, nil))
+		assert.NoError(t, err, template.Slug)
+		assert.Empty(t, rendered.Report.UnknownVariables, template.Slug)
+	}
+	assert.Len(t, slugs, 5)
+}
+
+func TestCloneSystemTemplate(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	assert.NoError(t, services.NewSystemTemplateService().SeedSystemTemplates())
+	// Seeding again leaves the library as it is
+	assert.NoError(t, services.NewSystemTemplateService().SeedSystemTemplates())
+	var count int64
+	db.Model(&models.SystemTemplate{}).Count(&count)
+	assert.Equal(t, int64(5), count)
+
+	var source models.SystemTemplate
+	db.Where("slug = ?", "open-house").First(&source)
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	agent := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent",
+		Email:          "agent@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agent)
+
+	token := getAuthToken(t, agent.ID.String(), agent.Role, org.ID.String())
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Weekend open house"})
+	req := httptest.NewRequest("POST", "/api/template-library/"+source.ID+"/clone", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	var clone models.EmailTemplate
+	db.Where("organization_id = ?", org.ID).First(&clone)
+	assert.Equal(t, "Weekend open house", clone.Name)
+	assert.Equal(t, source.HtmlBody, clone.HtmlBody)
+	assert.Contains(t, clone.HtmlBody, "{{contact.first_name")
+	assert.Equal(t, services.EditorTypeBlocks, clone.EditorType)
+	assert.Equal(t, 1, clone.CurrentVersion)
+	if assert.NotNil(t, clone.SourceSystemTemplateID) {
+		assert.Equal(t, source.ID, *clone.SourceSystemTemplateID)
+	}
+
+	db.First(&source, "id = ?", source.ID)
+	assert.Equal(t, 1, source.CloneCount)
+
+	// Unpublished templates are hidden from organizations
+	db.Model(&source).Update("is_published", false)
+	req = httptest.NewRequest("POST", "/api/template-library/"+source.ID+"/clone", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestSeedSystemTemplates_KeepsDeletedAndRenamedOnesGone(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	seeder := services.NewSystemTemplateService()
+	assert.NoError(t, seeder.SeedSystemTemplates())
+
+	db.Where("slug = ?", "open-house").Delete(&models.SystemTemplate{})
+	db.Model(&models.SystemTemplate{}).Where("slug = ?", "price-drop").Update("slug", "price-reduced")
+
+	assert.NoError(t, seeder.SeedSystemTemplates())
+
+	var count int64
+	db.Model(&models.SystemTemplate{}).Count(&count)
+	assert.Equal(t, int64(4), count)
+	db.Model(&models.SystemTemplate{}).Where("slug IN ?", []string{"open-house", "price-drop"}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTask_DefaultsToCreator(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	// Create organization and user
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	user := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Test User",
+		Email:          "user@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&user)
+
+	token := getAuthToken(t, user.ID.String(), user.Role, org.ID.String())
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"title":  "Send comps after showing",
+		"due_at": time.Now().Add(48 * time.Hour),
+	})
+	req := httptest.NewRequest("POST", "/api/tasks", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	var response struct {
+		Task models.Task `json:"task"`
+	}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(bodyBytes, &response)
+
+	assert.Equal(t, user.ID.String(), response.Task.AssignedTo)
+	assert.Equal(t, "medium", response.Task.Priority)
+	assert.Equal(t, "open", response.Task.Status)
+}
+
+func TestProcessTaskReminders_NotifiesAndCreatesFollowUp(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	// Create organization and agent
+	org := models.Organization{
+		ID:       uuid.New(),
+		Name:     "Test Org",
+		IsActive: true,
+	}
+	db.Create(&org)
+
+	agent := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Agent",
+		Email:          "agent@test.com",
+		Role:           "org_user",
+		IsActive:       true,
+	}
+	db.Create(&agent)
+
+	db.Create(&models.OrganizationSettings{
+		OrganizationID:    org.ID.String(),
+		FollowUpAfterDays: 7,
+	})
+
+	overdue := models.Task{
+		OrganizationID: org.ID.String(),
+		AssignedTo:     agent.ID.String(),
+		Title:          "Call back",
+		DueAt:          time.Now().Add(-time.Hour),
+		Priority:       "high",
+		Status:         "open",
+		CreatedBy:      agent.ID.String(),
+	}
+	db.Create(&overdue)
+
+	dueSoon := models.Task{
+		OrganizationID: org.ID.String(),
+		AssignedTo:     agent.ID.String(),
+		Title:          "Send comps",
+		DueAt:          time.Now().Add(30 * time.Minute),
+		Priority:       "medium",
+		Status:         "open",
+		CreatedBy:      agent.ID.String(),
+	}
+	db.Create(&dueSoon)
+
+	// A contact that has been quiet for longer than the follow-up window
+	agentID := agent.ID.String()
+	quiet := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      agentID,
+		AssignedTo:     &agentID,
+		FirstName:      "Quiet",
+		Email:          "quiet@test.com",
+		IsActive:       true,
+	}
+	db.Create(&quiet)
+	db.Model(&quiet).Update("created_at", time.Now().AddDate(0, 0, -30))
+
+	bgJobService := services.NewBackgroundJobService()
+	bgJobService.ProcessTaskReminders()
+
+	var dueCount, overdueCount int64
+	db.Model(&models.Notification{}).Where("notification_type = ? AND related_task_id = ?", "task_due", dueSoon.ID).Count(&dueCount)
+	db.Model(&models.Notification{}).Where("notification_type = ? AND related_task_id = ?", "task_overdue", overdue.ID).Count(&overdueCount)
+	assert.Equal(t, int64(1), dueCount)
+	assert.Equal(t, int64(1), overdueCount)
+
+	var followUps []models.Task
+	db.Where("contact_id = ? AND auto_generated = ?", quiet.ID, true).Find(&followUps)
+	assert.Len(t, followUps, 1)
+	assert.True(t, followUps[0].DueAt.After(time.Now()))
+
+	// A second pass neither repeats reminders nor duplicates the follow-up task
+	bgJobService.ProcessTaskReminders()
+
+	db.Model(&models.Notification{}).Where("notification_type = ? AND related_task_id = ?", "task_overdue", overdue.ID).Count(&overdueCount)
+	assert.Equal(t, int64(1), overdueCount)
+
+	db.Where("contact_id = ? AND auto_generated = ?", quiet.ID, true).Find(&followUps)
+	assert.Len(t, followUps, 1)
+
+	// The new follow-up task isn't reported overdue straight away
+	db.Model(&models.Notification{}).Where("notification_type = ? AND related_task_id = ?", "task_overdue", followUps[0].ID).Count(&overdueCount)
+	assert.Equal(t, int64(0), overdueCount)
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplate_ConditionalsAndDefaults(t *testing.T) {
+	src := `{{#if contact.first_name}}Hi {{contact.first_name}}{{else}}Hello{{/if}}, {{contact.preferred_location | default "your area"}}`
+
+	named := services.BuildTemplateData(&models.Contact{FirstName: "Asha"}, nil, nil, nil)
+	out, report, err := services.RenderTemplate(src, named, services.TemplateModeText)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi Asha, your area", out)
+	assert.Empty(t, report.UnresolvedVariables)
+
+	anonymous := services.BuildTemplateData(&models.Contact{}, nil, nil, nil)
+	out, _, err = services.RenderTemplate(src, anonymous, services.TemplateModeText)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, your area", out)
+}
+
+func TestRenderTemplate_FormatsBudget(t *testing.T) {
+	data := services.BuildTemplateData(&models.Contact{BudgetMin: 4500000, BudgetMax: 7500000.5}, nil, nil, nil)
+
+	out, _, err := services.RenderTemplate(
+		`{{contact.budget_min | currency}} - {{contact.budget_max | currency "USD"}} ({{contact.budget_min | number}})`,
+		data, services.TemplateModeText)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "₹4,500,000 - $7,500,000.50 (4,500,000)", out)
+}
+
+func TestRenderTemplate_EscapesContactDataInHTML(t *testing.T) {
+	data := services.BuildTemplateData(&models.Contact{FirstName: `<script>alert("x")</script>`}, nil, nil, nil)
+
+	out, _, err := services.RenderTemplate(`<p>Hi {{contact.first_name}}</p>`, data, services.TemplateModeHTML)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hi &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>`, out)
+}
+
+func TestRenderTemplate_EachAndReport(t *testing.T) {
+	data := map[string]interface{}{
+		"listings": []interface{}{
+			map[string]interface{}{"address": "12 MG Road"},
+			map[string]interface{}{"address": "4 FC Road"},
+		},
+		"note": "",
+	}
+
+	out, report, err := services.RenderTemplate(
+		`{{#each listings}}{{@index}}:{{this.address}};{{/each}}{{note}}{{contact.nickname}}`,
+		data, services.TemplateModeText)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0:12 MG Road;1:4 FC Road;", out)
+	assert.Equal(t, []string{"contact.nickname"}, report.UnknownVariables)
+	assert.Equal(t, []string{"note"}, report.UnresolvedVariables)
+}
+
+func TestParseTemplate_SyntaxErrors(t *testing.T) {
+	for _, src := range []string{
+		"{{#if contact.first_name}}Hi",
+		"Hi {{contact.first_name",
+		"{{contact.first_name | shout}}",
+		"{{/if}}",
+		"{{#with contact}}{{/with}}",
+	} {
+		_, err := services.ParseTemplate(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestSubstituteTemplateVariables_LegacyNames(t *testing.T) {
+	out := services.SubstituteTemplateVariables("Hi {{first_name}} {{ last_name }}", map[string]string{
+		"first_name": "Asha",
+		"last_name":  "Rao",
+	})
+	assert.Equal(t, "Hi Asha Rao", out)
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLToText_LinksBecomeFootnotes(t *testing.T) {
+	src := `<html><head><style>p { color: red; }</style></head><body>
+		<h1>New   listings</h1>
+		<p>Hi {{contact.first_name}}, see <a href="https://example.com/listings">our listings</a> &amp; more.</p>
+		<ul><li>2 BHK</li><li>3 BHK</li></ul>
+		<p><a href="{{unsubscribe_url}}">Unsubscribe</a></p>
+	</body></html>`
+
+	expected := "New listings\n\n" +
+		"Hi {{contact.first_name}}, see our listings [1] & more.\n\n" +
+		"- 2 BHK\n- 3 BHK\n\n" +
+		"Unsubscribe [2]\n\n" +
+		"Links:\n[1] https://example.com/listings\n[2] {{unsubscribe_url}}"
+
+	assert.Equal(t, expected, services.HTMLToText(src))
+}
+
+func TestHTMLToText_KeepsTemplateTagsInTables(t *testing.T) {
+	src := `<table>{{#each listings}}<tr><td>{{title}}</td><td>{{price}}</td></tr>{{/each}}</table>`
+
+	assert.Equal(t, "{{#each listings}}\n\n{{title}} {{price}}\n\n{{/each}}", services.HTMLToText(src))
+}
+
+func TestLintEmailTemplate_ReadsMarkupLikeTheSanitizer(t *testing.T) {
+	// Inside SVG a <style> holds markup, so this image is one a client shows
+	template := &models.EmailTemplate{
+		Subject:  "Hi",
+		HtmlBody: `<svg><style><img src="https://cdn.example.com/logo.png"></style></svg><a href="{{unsubscribe_url}}">Unsubscribe</a>`,
+	}
+
+	codes := map[string]bool{}
+	for _, warning := range services.LintEmailTemplate(template, nil) {
+		codes[warning.Code] = true
+	}
+	assert.True(t, codes["image_missing_alt"])
+}
+
+func TestLintEmailTemplate_ReportsProblems(t *testing.T) {
+	template := &models.EmailTemplate{
+		Subject:  "Hi {{contact.nickname}}",
+		HtmlBody: `<p><a href="/listings">Listings</a> <a href="">Call us</a></p><img src="https://cdn.example.com/logo.png">`,
+	}
+
+	codes := map[string]bool{}
+	for _, warning := range services.LintEmailTemplate(template, nil) {
+		codes[warning.Code] = true
+	}
+
+	assert.True(t, codes["unknown_variable"])
+	assert.True(t, codes["missing_unsubscribe"])
+	assert.True(t, codes["relative_link"])
+	assert.True(t, codes["broken_link"])
+	assert.True(t, codes["image_missing_alt"])
+	assert.False(t, codes["html_too_large"])
+}
+
+func TestLintEmailTemplate_CleanTemplate(t *testing.T) {
+	template := &models.EmailTemplate{
+		Subject:  "Hi {{contact.first_name | default \"there\"}}",
+		HtmlBody: `<p><a href="https://example.com">Site</a></p><img src="https://cdn.example.com/logo.png" alt="Logo"><a href="{{unsubscribe_url}}">Unsubscribe</a>`,
+	}
+
+	assert.Empty(t, services.LintEmailTemplate(template, nil))
+}
+
+func TestUnsubscribe_NoLinksWithoutSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+
+	assert.ErrorIs(t, services.CheckLinkSigningSecret(), services.ErrNoLinkSigningSecret)
+
+	// A token signed with an empty key is what anyone could forge
+	link := services.BuildUnsubscribeURL(uuid.New().String())
+	token := link[strings.LastIndex(link, "/")+1:]
+	_, ok := services.ParseUnsubscribeToken(token)
+	assert.False(t, ok)
+}
+
+func TestUnsubscribe_SignedLink(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	contact := models.Contact{
+		OrganizationID: org.ID.String(),
+		CreatedBy:      uuid.New().String(),
+		Email:          "buyer@test.com",
+		IsActive:       true,
+	}
+	db.Create(&contact)
+
+	link := services.BuildUnsubscribeURL(contact.ID)
+	path := link[strings.Index(link, "/public/"):]
+
+	// A tampered token is rejected
+	req := httptest.NewRequest("POST", path+"x", nil)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	// Viewing the page does not unsubscribe
+	req = httptest.NewRequest("GET", path, nil)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var updated models.Contact
+	db.First(&updated, "id = ?", contact.ID)
+	assert.Nil(t, updated.UnsubscribedAt)
+
+	req = httptest.NewRequest("POST", path, nil)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	db.First(&updated, "id = ?", contact.ID)
+	assert.NotNil(t, updated.UnsubscribedAt)
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/database"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/models"
+	"github.com/atharvpunekar/real_estate_crm_backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderEmailTemplate_PartialsAndBrand(t *testing.T) {
+	partials := []models.TemplatePartial{
+		{Name: "header", HtmlBody: `<img src="{{brand.logo_url}}" alt="Logo">`},
+		{Name: "footer", HtmlBody: `<p style="color:{{brand.primary_color}}">{{brand.office_address}}</p>{{> legal}}`},
+		{Name: "legal", HtmlBody: `<p>RERA registered</p>`, PlainTextBody: "RERA registered"},
+	}
+	template := &models.EmailTemplate{
+		Subject:  "Hello",
+		HtmlBody: `{{> header}}<p>Hi {{contact.first_name}}</p>{{> footer}}{{> missing}}`,
+	}
+	brand := &models.OrganizationSettings{
+		BrandLogoURL:       "https://cdn.example.com/logo.png",
+		BrandPrimaryColor:  "#1a73e8",
+		BrandOfficeAddress: "Baner Road, Pune & Co",
+	}
+
+	rendered, err := services.RenderEmailTemplate(template, partials, services.BuildTemplateData(services.SampleContact(), nil, nil, brand))
+	assert.NoError(t, err)
+	assert.Equal(t, `<img src="https://cdn.example.com/logo.png" alt="Logo"><p>Hi Priya</p><p style="color:#1a73e8">Baner Road, Pune &amp; Co</p><p>RERA registered</p>`, rendered.HtmlBody)
+	assert.Contains(t, rendered.TextBody, "Baner Road, Pune & Co")
+	assert.Contains(t, rendered.TextBody, "RERA registered")
+	assert.Equal(t, []string{"missing"}, rendered.Report.UnknownPartials)
+}
+
+func TestValidatePartials(t *testing.T) {
+	assert.NoError(t, services.ValidatePartials([]models.TemplatePartial{
+		{Name: "footer", HtmlBody: "{{> legal}}"},
+		{Name: "legal", HtmlBody: "RERA"},
+	}))
+
+	assert.Error(t, services.ValidatePartials([]models.TemplatePartial{
+		{Name: "a", HtmlBody: "{{> b}}"},
+		{Name: "b", HtmlBody: "{{> a}}"},
+	}))
+	assert.Error(t, services.ValidatePartials([]models.TemplatePartial{{Name: "Footer Block", HtmlBody: "x"}}))
+	assert.Error(t, services.ValidateTemplate("{{> ../etc}}"))
+}
+
+func TestLintEmailTemplate_UnsubscribeInPartial(t *testing.T) {
+	template := &models.EmailTemplate{
+		Subject:  "Hello",
+		HtmlBody: `<p>New listings</p>{{> footer}}`,
+	}
+	footer := []models.TemplatePartial{{Name: "footer", HtmlBody: `<a href="{{unsubscribe_url}}">Unsubscribe</a>`}}
+
+	codes := map[string]bool{}
+	for _, warning := range services.LintEmailTemplate(template, nil) {
+		codes[warning.Code] = true
+	}
+	assert.True(t, codes["unknown_partial"])
+	assert.True(t, codes["missing_unsubscribe"])
+
+	assert.Empty(t, services.LintEmailTemplate(template, footer))
+}
+
+func TestDeleteTemplatePartial_InUse(t *testing.T) {
+	db := SetupTestDB()
+	database.DB = db
+	defer CleanupTestDB(db)
+
+	app := SetupTestApp()
+
+	org := models.Organization{
+		ID:   uuid.New(),
+		Name: "Test Org",
+	}
+	db.Create(&org)
+
+	admin := models.User{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           "Admin",
+		Email:          "admin@test.com",
+		Role:           "org_admin",
+		IsActive:       true,
+	}
+	db.Create(&admin)
+
+	token := getAuthToken(t, admin.ID.String(), admin.Role, org.ID.String())
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":      "footer",
+		"html_body": `<p onclick="x()">{{brand.office_address}}</p>`,
+	})
+	req := httptest.NewRequest("POST", "/api/template-partials", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	var partial models.TemplatePartial
+	db.Where("organization_id = ? AND name = ?", org.ID, "footer").First(&partial)
+	assert.False(t, strings.Contains(partial.HtmlBody, "onclick"))
+
+	template := models.EmailTemplate{
+		OrganizationID: org.ID.String(),
+		Name:           "Newsletter",
+		Subject:        "News",
+		HtmlBody:       "<p>News</p>{{> footer}}",
+		CreatedBy:      admin.ID.String(),
+	}
+	db.Create(&template)
+
+	req = httptest.NewRequest("DELETE", "/api/template-partials/"+partial.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	db.Delete(&template)
+
+	req = httptest.NewRequest("DELETE", "/api/template-partials/"+partial.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
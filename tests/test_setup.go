@@ -3,6 +3,7 @@ package tests
 import (
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/handlers"
 	"github.com/atharvpunekar/real_estate_crm_backend/internal/middleware"
@@ -17,6 +18,7 @@ import (
 // init sets up environment variables before tests run
 func init() {
 	os.Setenv("JWT_SECRET", "this_is_a_test_secret_key_which_is_very_long_123")
+	os.Setenv("UPLOAD_DIR", filepath.Join(os.TempDir(), "crm_test_uploads"))
 }
 
 var TestDB *gorm.DB
@@ -42,10 +44,33 @@ func SetupTestDB() *gorm.DB {
 		&models.Audience{},
 		&models.AudienceContact{},
 		&models.EmailTemplate{},
+		&models.EmailTemplateVersion{},
+		&models.EmailTemplateAttachment{},
+		&models.EmailTemplateVariant{},
+		&models.TemplatePartial{},
+		&models.SystemTemplate{},
+		&models.SystemTemplateSeed{},
 		&models.Campaign{},
 		&models.CampaignLog{},
+		&models.CampaignVariant{},
+		&models.CampaignPreview{},
+		&models.Sequence{},
+		&models.SequenceStep{},
+		&models.SequenceEnrollment{},
+		&models.SequenceMessage{},
 		&models.Notification{},
 		&models.BackgroundJobLog{},
+		&models.PipelineStage{},
+		&models.ContactStageHistory{},
+		&models.LeadRoutingRule{},
+		&models.Activity{},
+		&models.AudienceMembershipLog{},
+		&models.Task{},
+		&models.OrganizationSettings{},
+		&models.Appointment{},
+		&models.Deal{},
+		&models.CommissionRule{},
+		&models.SenderIdentity{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
@@ -80,13 +105,24 @@ func SetupTestDB() *gorm.DB {
 
 // SetupTestApp creates a test Fiber app instance with routes
 func SetupTestApp() *fiber.App {
-	app := fiber.New()
+	app := fiber.New(middleware.BodyConfig)
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, middleware.LargeBodyRoute{
+		Method: fiber.MethodPost, Path: "/api/templates/:id/attachments", Limit: middleware.UploadBodyLimit,
+	}))
 
 	// Auth routes (no middleware)
 	app.Post("/auth/superadmin/login", handlers.SuperAdminLogin)
 	app.Post("/auth/login", handlers.OrgAdminLogin)
 	app.Post("/auth/activate", handlers.ActivatePassword)
 
+	// Public token-authenticated routes
+	app.Get("/public/calendar/:token", handlers.GetCalendarFeed)
+	app.Get("/public/sender-identities/verify/:token", handlers.VerifySenderIdentity)
+	app.Get("/public/unsubscribe/:token", handlers.GetUnsubscribePage)
+	app.Post("/public/unsubscribe/:token", handlers.Unsubscribe)
+	app.Get("/public/track/open/:token", handlers.TrackCampaignOpen)
+	app.Get("/public/track/click/:token", handlers.TrackCampaignClick)
+
 	// Protected routes with middleware
 	protected := app.Group("/api", middleware.JWTProtected)
 
@@ -97,6 +133,24 @@ func SetupTestApp() *fiber.App {
 	protected.Put("/contacts/:id", handlers.UpdateContact)
 	protected.Delete("/contacts/:id", handlers.DeleteContact)
 	protected.Post("/contacts/import", handlers.ImportContactsCSV)
+	protected.Post("/contacts/bulk-stage", handlers.BulkMoveContactStage)
+	protected.Post("/contacts/bulk-assign", handlers.BulkAssignContacts)
+	protected.Put("/contacts/:id/assign", handlers.AssignContact)
+	protected.Put("/contacts/:id/stage", handlers.MoveContactStage)
+	protected.Get("/contacts/:id/stage-history", handlers.GetContactStageHistory)
+	protected.Get("/contacts/:id/timeline", handlers.GetContactTimeline)
+	protected.Post("/contacts/:id/activities", handlers.CreateContactActivity)
+	protected.Get("/contacts/:id/activities", handlers.GetContactActivities)
+	protected.Put("/contacts/:id/activities/:activityId", handlers.UpdateContactActivity)
+	protected.Delete("/contacts/:id/activities/:activityId", handlers.DeleteContactActivity)
+
+	// Pipeline routes
+	protected.Get("/pipeline/stages", handlers.GetPipelineStages)
+	protected.Get("/pipeline/board", handlers.GetPipelineBoard)
+	protected.Post("/pipeline/stages", handlers.CreatePipelineStage)
+	protected.Put("/pipeline/stages/reorder", handlers.ReorderPipelineStages)
+	protected.Put("/pipeline/stages/:id", handlers.UpdatePipelineStage)
+	protected.Delete("/pipeline/stages/:id", handlers.DeletePipelineStage)
 
 	// Audience routes
 	protected.Post("/audiences", handlers.CreateAudience)
@@ -111,10 +165,36 @@ func SetupTestApp() *fiber.App {
 	// Email Template routes
 	protected.Post("/templates", handlers.CreateEmailTemplate)
 	protected.Get("/templates", handlers.GetEmailTemplates)
+	protected.Post("/templates/blocks/compile", handlers.CompileEmailTemplateBlocks)
 	protected.Get("/templates/:id", handlers.GetEmailTemplateByID)
 	protected.Put("/templates/:id", handlers.UpdateEmailTemplate)
 	protected.Delete("/templates/:id", handlers.DeleteEmailTemplate)
+	protected.Post("/templates/:id/preview", handlers.PreviewEmailTemplate)
+	protected.Get("/templates/:id/versions", handlers.GetEmailTemplateVersions)
+	protected.Get("/templates/:id/versions/diff", handlers.DiffEmailTemplateVersions)
+	protected.Get("/templates/:id/versions/:version", handlers.GetEmailTemplateVersion)
+	protected.Post("/templates/:id/versions/:version/restore", handlers.RestoreEmailTemplateVersion)
 	protected.Post("/templates/:id/test", handlers.TestSendEmail)
+	protected.Post("/templates/:id/attachments", handlers.UploadEmailTemplateAttachment)
+	protected.Get("/templates/:id/attachments", handlers.GetEmailTemplateAttachments)
+	protected.Delete("/templates/:id/attachments/:attachmentId", handlers.DeleteEmailTemplateAttachment)
+	protected.Post("/templates/:id/variants", handlers.CreateEmailTemplateVariant)
+	protected.Get("/templates/:id/variants", handlers.GetEmailTemplateVariants)
+	protected.Put("/templates/:id/variants/:locale", handlers.UpdateEmailTemplateVariant)
+	protected.Delete("/templates/:id/variants/:locale", handlers.DeleteEmailTemplateVariant)
+	protected.Post("/template-partials", handlers.CreateTemplatePartial)
+	protected.Get("/template-partials", handlers.GetTemplatePartials)
+	protected.Get("/template-partials/:id", handlers.GetTemplatePartialByID)
+	protected.Put("/template-partials/:id", handlers.UpdateTemplatePartial)
+	protected.Delete("/template-partials/:id", handlers.DeleteTemplatePartial)
+	protected.Post("/superadmin/templates", handlers.CreateSystemTemplate)
+	protected.Get("/superadmin/templates", handlers.GetSystemTemplates)
+	protected.Get("/superadmin/templates/:id", handlers.GetSystemTemplateByID)
+	protected.Put("/superadmin/templates/:id", handlers.UpdateSystemTemplate)
+	protected.Delete("/superadmin/templates/:id", handlers.DeleteSystemTemplate)
+	protected.Get("/template-library", handlers.GetTemplateLibrary)
+	protected.Get("/template-library/:id", handlers.GetTemplateLibraryItem)
+	protected.Post("/template-library/:id/clone", handlers.CloneTemplateLibraryItem)
 
 	// Campaign routes
 	protected.Post("/campaigns", handlers.CreateCampaign)
@@ -124,7 +204,75 @@ func SetupTestApp() *fiber.App {
 	protected.Delete("/campaigns/:id", handlers.DeleteCampaign)
 	protected.Post("/campaigns/:id/pause", handlers.PauseCampaign)
 	protected.Post("/campaigns/:id/resume", handlers.ResumeCampaign)
+	protected.Post("/campaigns/:id/schedule", handlers.ScheduleCampaign)
+	protected.Post("/campaigns/:id/send-now", handlers.SendCampaignNow)
+	protected.Post("/campaigns/:id/clone", handlers.CloneCampaign)
 	protected.Get("/campaigns/:id/logs", handlers.GetCampaignLogs)
+	protected.Get("/campaigns/:id/recipients", handlers.GetCampaignRecipients)
+	protected.Post("/campaigns/:id/dry-run", handlers.DryRunCampaign)
+	protected.Get("/campaigns/:id/dry-runs/:previewId", handlers.GetCampaignPreview)
+	protected.Post("/campaigns/:id/approve", handlers.ApproveCampaign)
+	protected.Post("/campaigns/:id/reject", handlers.RejectCampaign)
+
+	// Sequence routes
+	protected.Post("/sequences", handlers.CreateSequence)
+	protected.Get("/sequences", handlers.GetSequences)
+	protected.Get("/sequences/:id", handlers.GetSequenceByID)
+	protected.Put("/sequences/:id", handlers.UpdateSequence)
+	protected.Delete("/sequences/:id", handlers.DeleteSequence)
+	protected.Post("/sequences/:id/activate", handlers.ActivateSequence)
+	protected.Post("/sequences/:id/pause", handlers.PauseSequence)
+	protected.Post("/sequences/:id/enrollments", handlers.EnrollSequenceContacts)
+	protected.Get("/sequences/:id/enrollments", handlers.GetSequenceEnrollments)
+	protected.Post("/sequences/:id/enrollments/:enrollmentId/exit", handlers.ExitSequenceEnrollment)
+
+	// Agent management routes
+	protected.Delete("/agents/:id", handlers.DeactivateAgent)
+
+	// Routing rule routes
+	protected.Post("/routing-rules", handlers.CreateRoutingRule)
+	protected.Get("/routing-rules", handlers.GetRoutingRules)
+	protected.Post("/routing-rules/apply", handlers.ApplyRoutingRules)
+	protected.Put("/routing-rules/:id", handlers.UpdateRoutingRule)
+	protected.Delete("/routing-rules/:id", handlers.DeleteRoutingRule)
+
+	// Task routes
+	protected.Post("/tasks", handlers.CreateTask)
+	protected.Get("/tasks", handlers.GetTasks)
+	protected.Get("/tasks/:id", handlers.GetTaskByID)
+	protected.Put("/tasks/:id", handlers.UpdateTask)
+	protected.Delete("/tasks/:id", handlers.DeleteTask)
+	protected.Post("/tasks/:id/complete", handlers.CompleteTask)
+
+	// Settings routes
+	protected.Get("/settings", handlers.GetOrganizationSettings)
+	protected.Put("/settings", handlers.UpdateOrganizationSettings)
+
+	// Appointment routes
+	protected.Post("/appointments", handlers.CreateAppointment)
+	protected.Get("/appointments", handlers.GetAppointments)
+	protected.Get("/appointments/:id", handlers.GetAppointmentByID)
+	protected.Put("/appointments/:id", handlers.UpdateAppointment)
+	protected.Post("/appointments/:id/send-confirmation", handlers.SendAppointmentConfirmation)
+	protected.Get("/calendar/feed-url", handlers.GetCalendarFeedURL)
+
+	// Deal and commission routes
+	protected.Post("/deals", handlers.CreateDeal)
+	protected.Get("/deals", handlers.GetDeals)
+	protected.Get("/deals/:id", handlers.GetDealByID)
+	protected.Put("/deals/:id", handlers.UpdateDeal)
+	protected.Delete("/deals/:id", handlers.DeleteDeal)
+	protected.Post("/commission-rules", handlers.CreateCommissionRule)
+	protected.Get("/commission-rules", handlers.GetCommissionRules)
+	protected.Put("/commission-rules/:id", handlers.UpdateCommissionRule)
+	protected.Delete("/commission-rules/:id", handlers.DeleteCommissionRule)
+	protected.Get("/commissions/payouts", handlers.GetCommissionPayouts)
+
+	// Sender identity routes
+	protected.Post("/sender-identities", handlers.CreateSenderIdentity)
+	protected.Get("/sender-identities", handlers.GetSenderIdentities)
+	protected.Post("/sender-identities/:id/resend", handlers.ResendSenderVerification)
+	protected.Delete("/sender-identities/:id", handlers.DeleteSenderIdentity)
 
 	// Notification routes
 	protected.Get("/notifications", handlers.GetNotifications)
@@ -137,12 +285,35 @@ func SetupTestApp() *fiber.App {
 // CleanupTestDB cleans up all tables
 func CleanupTestDB(db *gorm.DB) {
 	db.Exec("DELETE FROM notification")
+	db.Exec("DELETE FROM contact_stage_history")
+	db.Exec("DELETE FROM activity")
+	db.Exec("DELETE FROM task")
+	db.Exec("DELETE FROM appointment")
+	db.Exec("DELETE FROM deal")
+	db.Exec("DELETE FROM commission_rule")
+	db.Exec("DELETE FROM organization_settings")
+	db.Exec("DELETE FROM audience_membership_log")
+	db.Exec("DELETE FROM campaign_preview")
 	db.Exec("DELETE FROM campaign_log")
+	db.Exec("DELETE FROM campaign_variant")
 	db.Exec("DELETE FROM campaign")
+	db.Exec("DELETE FROM sequence_message")
+	db.Exec("DELETE FROM sequence_enrollment")
+	db.Exec("DELETE FROM sequence_step")
+	db.Exec("DELETE FROM sequence")
+	db.Exec("DELETE FROM sender_identity")
+	db.Exec("DELETE FROM email_template_version")
+	db.Exec("DELETE FROM email_template_attachment")
+	db.Exec("DELETE FROM email_template_variant")
+	db.Exec("DELETE FROM template_partial")
 	db.Exec("DELETE FROM email_template")
+	db.Exec("DELETE FROM system_template_seed")
+	db.Exec("DELETE FROM system_template")
 	db.Exec("DELETE FROM audience_contact")
 	db.Exec("DELETE FROM audience")
 	db.Exec("DELETE FROM contact")
+	db.Exec("DELETE FROM pipeline_stage")
+	db.Exec("DELETE FROM lead_routing_rule")
 	db.Exec("DELETE FROM background_job_log")
 	db.Exec("DELETE FROM \"user\"")
 	db.Exec("DELETE FROM organization")